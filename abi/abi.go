@@ -2,23 +2,29 @@ package abi
 
 import (
 	"bytes"
-	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"hash"
 	"io"
+	"reflect"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/boolw/go-web3"
-	"golang.org/x/crypto/sha3"
 )
 
 // ABI represents the ethereum abi format
 type ABI struct {
 	Constructor *Method
+	Fallback    *Method
+	Receive     *Method
 	Methods     map[string]*Method
 	Events      map[string]*Event
+	Errors      map[string]*Error
+
+	// hasher is the hash of the signatures of the ids, keccak256 if nil
+	hasher func() hash.Hash
 }
 
 // NewABI returns a parsed ABI struct
@@ -35,6 +41,17 @@ func MustNewABI(s string) *ABI {
 	return a
 }
 
+// NewABIWithHasher returns a parsed ABI struct whose methods, events and errors
+// compute their ids with the given hash function instead of keccak256 (i.e. for
+// chains with a different selector hash)
+func NewABIWithHasher(s string, h func() hash.Hash) (*ABI, error) {
+	abi := &ABI{hasher: h}
+	if err := json.Unmarshal([]byte(s), abi); err != nil {
+		return nil, err
+	}
+	return abi, nil
+}
+
 // NewABIFromReader returns an ABI object from a reader
 func NewABIFromReader(r io.Reader) (*ABI, error) {
 	abi := new(ABI)
@@ -51,6 +68,7 @@ func (a *ABI) UnmarshalJSON(data []byte) error {
 		Type            string
 		Name            string
 		Constant        bool
+		Payable         bool
 		Anonymous       bool
 		StateMutability string
 		Inputs          arguments
@@ -63,6 +81,7 @@ func (a *ABI) UnmarshalJSON(data []byte) error {
 
 	a.Methods = make(map[string]*Method, 0)
 	a.Events = make(map[string]*Event, 0)
+	a.Errors = make(map[string]*Error, 0)
 
 	for _, field := range fields {
 		switch field.Type {
@@ -70,38 +89,77 @@ func (a *ABI) UnmarshalJSON(data []byte) error {
 			if a.Constructor != nil {
 				return fmt.Errorf("multiple constructor declaration")
 			}
+			mutability, err := parseStateMutability(field.StateMutability, false, field.Payable)
+			if err != nil {
+				return err
+			}
+			if mutability != MutabilityNonPayable && mutability != MutabilityPayable {
+				return fmt.Errorf("constructor cannot be %s", mutability)
+			}
 			a.Constructor = &Method{
-				Inputs: field.Inputs.Type(),
+				Mutability: mutability,
+				Inputs:     field.Inputs.Type(),
 			}
 
 		case "function", "":
+			mutability, err := parseStateMutability(field.StateMutability, field.Constant, field.Payable)
+			if err != nil {
+				return err
+			}
 			c := field.Constant
-			if field.StateMutability == "view" || field.StateMutability == "pure" {
+			if mutability == MutabilityView || mutability == MutabilityPure {
 				c = true
 			}
-			name := a.overloadedMethodName(field.Name)
-			a.Methods[name] = &Method{
-				Name:    field.Name,
-				Const:   c,
-				Inputs:  field.Inputs.Type(),
-				Outputs: field.Outputs.Type(),
+			method := &Method{
+				Name:       field.Name,
+				Const:      c,
+				Mutability: mutability,
+				Inputs:     field.Inputs.Type(),
+				Outputs:    field.Outputs.Type(),
+				hasher:     a.hasher,
 			}
+			// compute the id at parse time since the abi is usually shared
+			method.ID()
+
+			name := a.overloadedMethodName(field.Name)
+			a.Methods[name] = method
 
 		case "event":
-			name := a.overloadedEventName(field.Name)
-			a.Events[name] = &Event{
+			event := &Event{
 				Name:      field.Name,
 				Anonymous: field.Anonymous,
 				Inputs:    field.Inputs.Type(),
+				hasher:    a.hasher,
 			}
+			event.ID()
+
+			name := a.overloadedEventName(field.Name)
+			a.Events[name] = event
 		case "error":
-			// do nothing
+			name := a.overloadedErrorName(field.Name)
+			a.Errors[name] = &Error{
+				Name:   field.Name,
+				Inputs: field.Inputs.Type(),
+				hasher: a.hasher,
+			}
 
 		case "fallback":
-			// do nothing
+			mutability, err := parseStateMutability(field.StateMutability, false, field.Payable)
+			if err != nil {
+				return err
+			}
+			a.Fallback = &Method{
+				Mutability: mutability,
+				Inputs:     field.Inputs.Type(),
+				Outputs:    field.Outputs.Type(),
+			}
 
 		case "receive":
-			// do nothing
+			a.Receive = &Method{
+				Mutability: MutabilityPayable,
+				Inputs:     field.Inputs.Type(),
+				Outputs:    field.Outputs.Type(),
+			}
 
 		default:
 			return fmt.Errorf("unknown field type '%s'", field.Type)
@@ -110,6 +168,27 @@ func (a *ABI) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// HasFallback returns true if the abi has a fallback function
+func (a *ABI) HasFallback() bool {
+	return a.Fallback != nil
+}
+
+// HasReceive returns true if the abi has a receive function
+func (a *ABI) HasReceive() bool {
+	return a.Receive != nil
+}
+
+// FallbackPayable returns true if the abi has a payable fallback function
+func (a *ABI) FallbackPayable() bool {
+	return a.Fallback != nil && a.Fallback.Payable()
+}
+
+// AcceptsEther returns true if a transfer without calldata to the contract
+// does not revert, that is, it has a receive or a payable fallback function.
+func (a *ABI) AcceptsEther() bool {
+	return a.HasReceive() || a.FallbackPayable()
+}
+
 // overloadedMethodName returns the next available name for a given function.
 // Needed since solidity allows for function overload.
 //
@@ -125,6 +204,111 @@ func (abi *ABI) overloadedMethodName(rawName string) string {
 	return name
 }
 
+// EventByID returns the event with the given topic id. Overloaded events
+// share the name but not the id, so the lookup does not depend on the
+// (mangled) name in Events. Anonymous events do not have an id.
+func (abi *ABI) EventByID(topic web3.Hash) (*Event, bool) {
+	for _, event := range abi.Events {
+		if !event.Anonymous && event.ID() == topic {
+			return event, true
+		}
+	}
+	return nil, false
+}
+
+// EventsByName returns the events with the given name, including the
+// overloaded ones stored with a mangled name in Events. The events are
+// sorted by signature.
+func (abi *ABI) EventsByName(name string) []*Event {
+	res := []*Event{}
+	for _, event := range abi.Events {
+		if event.Name == name {
+			res = append(res, event)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Sig() < res[j].Sig()
+	})
+	return res
+}
+
+// MethodsByName returns the methods with the given name, including the
+// overloaded ones stored with a mangled name in Methods. The methods are
+// sorted by signature.
+func (abi *ABI) MethodsByName(name string) []*Method {
+	res := []*Method{}
+	for _, method := range abi.Methods {
+		if method.Name == name {
+			res = append(res, method)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Sig() < res[j].Sig()
+	})
+	return res
+}
+
+// MethodByID returns the method with the given 4 bytes selector
+func (abi *ABI) MethodByID(id []byte) (*Method, bool) {
+	if len(id) != 4 {
+		return nil, false
+	}
+	for _, method := range abi.Methods {
+		if bytes.Equal(method.ID(), id) {
+			return method, true
+		}
+	}
+	return nil, false
+}
+
+// Selectors returns the selectors of the methods keyed by their signature
+func (abi *ABI) Selectors() map[string][4]byte {
+	res := make(map[string][4]byte, len(abi.Methods))
+	for _, method := range abi.Methods {
+		var id [4]byte
+		copy(id[:], method.ID())
+		res[method.Sig()] = id
+	}
+	return res
+}
+
+// ParseCalldata decodes the input of a transaction with the method of the abi
+// that matches its selector (the method id followed by the encoded inputs).
+func (abi *ABI) ParseCalldata(data []byte) (*Method, map[string]interface{}, error) {
+	if len(data) < 4 {
+		return nil, nil, fmt.Errorf("calldata too short to have a selector")
+	}
+	method, ok := abi.MethodByID(data[:4])
+	if !ok {
+		return nil, nil, fmt.Errorf("no method found for selector 0x%x", data[:4])
+	}
+	val, err := Decode(method.Inputs, data[4:])
+	if err != nil {
+		return nil, nil, err
+	}
+	args, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("failed to decode the inputs of %s", method.Name)
+	}
+	return method, args, nil
+}
+
+// ParseLog decodes the log with the event of the abi that matches its topic id
+func (abi *ABI) ParseLog(log *web3.Log) (map[string]interface{}, *Event, error) {
+	if len(log.Topics) == 0 {
+		return nil, nil, fmt.Errorf("log does not have topics")
+	}
+	event, ok := abi.EventByID(log.Topics[0])
+	if !ok {
+		return nil, nil, fmt.Errorf("no event found for topic %s", log.Topics[0])
+	}
+	res, err := event.ParseLog(log)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, event, nil
+}
+
 // overloadedEventName returns the next available name for a given event.
 // Needed since solidity allows for event overload.
 //
@@ -140,13 +324,214 @@ func (abi *ABI) overloadedEventName(rawName string) string {
 	return name
 }
 
+// overloadedErrorName returns the next available name for a given error.
+// Needed since solidity allows for error overload.
+func (abi *ABI) overloadedErrorName(rawName string) string {
+	name := rawName
+	_, ok := abi.Errors[name]
+	for idx := 0; ok; idx++ {
+		name = fmt.Sprintf("%s%d", rawName, idx)
+		_, ok = abi.Errors[name]
+	}
+	return name
+}
+
+// MarshalJSON implements the json.Marshaler interface. The abi is encoded
+// in the standard json format with the constructor, fallback and receive
+// functions first and then the methods, events and errors sorted by name.
+func (a *ABI) MarshalJSON() ([]byte, error) {
+	fields := []*abiField{}
+
+	if a.Constructor != nil {
+		fields = append(fields, &abiField{
+			Type:            "constructor",
+			Inputs:          newArguments(a.Constructor.Inputs, false),
+			StateMutability: a.Constructor.Mutability.String(),
+		})
+	}
+	if a.Fallback != nil {
+		fields = append(fields, &abiField{
+			Type:            "fallback",
+			StateMutability: a.Fallback.Mutability.String(),
+		})
+	}
+	if a.Receive != nil {
+		fields = append(fields, &abiField{
+			Type:            "receive",
+			StateMutability: a.Receive.Mutability.String(),
+		})
+	}
+
+	for _, name := range sortedKeys(a.Methods) {
+		method := a.Methods[name]
+		fields = append(fields, &abiField{
+			Type:            "function",
+			Name:            method.Name,
+			Inputs:          newArguments(method.Inputs, false),
+			Outputs:         newArguments(method.Outputs, false),
+			StateMutability: method.Mutability.String(),
+		})
+	}
+	for _, name := range sortedKeys(a.Events) {
+		event := a.Events[name]
+		anonymous := event.Anonymous
+		fields = append(fields, &abiField{
+			Type:      "event",
+			Name:      event.Name,
+			Inputs:    newArguments(event.Inputs, true),
+			Anonymous: &anonymous,
+		})
+	}
+	for _, name := range sortedKeys(a.Errors) {
+		e := a.Errors[name]
+		fields = append(fields, &abiField{
+			Type:   "error",
+			Name:   e.Name,
+			Inputs: newArguments(e.Inputs, false),
+		})
+	}
+	return json.Marshal(fields)
+}
+
+// abiField is an entry of the json abi
+type abiField struct {
+	Type            string        `json:"type"`
+	Name            string        `json:"name,omitempty"`
+	Inputs          *argumentsStr `json:"inputs,omitempty"`
+	Outputs         *argumentsStr `json:"outputs,omitempty"`
+	StateMutability string        `json:"stateMutability,omitempty"`
+	Anonymous       *bool         `json:"anonymous,omitempty"`
+}
+
+// argumentStr is the json form of an argument. It is not ArgumentStr since
+// the names of the fields are not lowercase.
+type argumentStr struct {
+	Name       string       `json:"name"`
+	Type       string       `json:"type"`
+	Indexed    *bool        `json:"indexed,omitempty"`
+	Components argumentsStr `json:"components,omitempty"`
+}
+
+type argumentsStr []*argumentStr
+
+// newArguments returns the json arguments of a tuple type
+func newArguments(t *Type, withIndexed bool) *argumentsStr {
+	args := argumentsStr{}
+	if t == nil {
+		return &args
+	}
+	for _, elem := range t.tuple {
+		arg := newArgument(elem.Name, elem.Elem)
+		if withIndexed {
+			indexed := elem.Indexed
+			arg.Indexed = &indexed
+		}
+		args = append(args, arg)
+	}
+	return &args
+}
+
+// newArgument returns the json argument of a type. Tuples are encoded as
+// 'tuple' (with the array suffixes) and the components.
+func newArgument(name string, t *Type) *argumentStr {
+	arg := &argumentStr{Name: name}
+
+	suffix := ""
+	elem := t
+	for elem.kind == KindSlice || elem.kind == KindArray {
+		if elem.kind == KindSlice {
+			suffix = "[]" + suffix
+		} else {
+			suffix = fmt.Sprintf("[%d]", elem.size) + suffix
+		}
+		elem = elem.elem
+	}
+	if elem.kind != KindTuple {
+		arg.Type = t.raw
+		return arg
+	}
+
+	arg.Type = "tuple" + suffix
+	arg.Components = *newArguments(elem, false)
+	return arg
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// StateMutability is the state mutability of a method
+type StateMutability int
+
+const (
+	// MutabilityNonPayable does not accept value
+	MutabilityNonPayable StateMutability = iota
+
+	// MutabilityPayable accepts value
+	MutabilityPayable
+
+	// MutabilityView does not modify the state
+	MutabilityView
+
+	// MutabilityPure does not read nor modify the state
+	MutabilityPure
+)
+
+func (s StateMutability) String() string {
+	names := [...]string{
+		"nonpayable",
+		"payable",
+		"view",
+		"pure",
+	}
+	return names[s]
+}
+
+// parseStateMutability parses the state mutability of a method. Old abis
+// without the stateMutability field use the constant and payable fields.
+func parseStateMutability(str string, constant, payable bool) (StateMutability, error) {
+	switch str {
+	case "nonpayable":
+		return MutabilityNonPayable, nil
+	case "payable":
+		return MutabilityPayable, nil
+	case "view":
+		return MutabilityView, nil
+	case "pure":
+		return MutabilityPure, nil
+	case "":
+		if constant {
+			return MutabilityView, nil
+		}
+		if payable {
+			return MutabilityPayable, nil
+		}
+		return MutabilityNonPayable, nil
+	default:
+		return 0, fmt.Errorf("unknown state mutability '%s'", str)
+	}
+}
+
 // Method is a callable function in the contract
 type Method struct {
-	Name    string
-	Const   bool
-	Inputs  *Type
-	Outputs *Type
-	id      []byte
+	Name       string
+	Const      bool
+	Mutability StateMutability
+	Inputs     *Type
+	Outputs    *Type
+	id         []byte
+	idOnce     sync.Once
+	hasher     func() hash.Hash
+}
+
+// Payable returns true if the method accepts value
+func (m *Method) Payable() bool {
+	return m.Mutability == MutabilityPayable
 }
 
 // Sig returns the signature of the method
@@ -161,24 +546,45 @@ func (m *Method) MethodSig() string {
 	return fmt.Sprintf("%s %s", buildFunctionSignature(m.Name, m.Inputs), buildFunctionSignature("returns ", m.Outputs))
 }
 
-// ID returns the id of the method
+// ID returns the id of the method. It is safe for concurrent use.
 func (m *Method) ID() []byte {
-	if len(m.id) > 0 {
-		return m.id
-	}
-	k := acquireKeccak()
-	k.Write([]byte(m.Sig()))
-	m.id = k.Sum(nil)[:4]
-	releaseKeccak(k)
+	m.idOnce.Do(func() {
+		if len(m.id) > 0 {
+			return
+		}
+		hash := signatureHash(m.hasher, m.Sig())
+		m.id = hash[:4]
+	})
 	return m.id
 }
 
+// VerifyID returns true if the id of the method is the expected selector
+func (m *Method) VerifyID(expected []byte) bool {
+	return bytes.Equal(m.ID(), expected)
+}
+
+// Encode encodes the arguments with the inputs of the method without the
+// selector (i.e. the arguments of the constructor appended to the bytecode).
+// The calldata of a call is the ID of the method followed by this encoding.
+// A nil method (i.e. the Constructor of an abi without one) takes no arguments.
+func (m *Method) Encode(args ...interface{}) ([]byte, error) {
+	if m == nil || m.Inputs == nil {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("expected no arguments but found %d", len(args))
+		}
+		return []byte{}, nil
+	}
+	return Encode(args, m.Inputs)
+}
+
 // Event is a triggered log mechanism
 type Event struct {
 	Name      string
 	Anonymous bool
 	Inputs    *Type
 	id        web3.Hash
+	idOnce    sync.Once
+	hasher    func() hash.Hash
 }
 
 // Sig returns the signature of the event
@@ -190,19 +596,35 @@ func (e *Event) MethodSig() string {
 	return buildFunctionSignature(e.Name, e.Inputs)
 }
 
-// ID returns the id of the event used during logs
+// ID returns the id of the event used during logs. It is safe for concurrent use.
 func (e *Event) ID() web3.Hash {
-	if binary.BigEndian.Uint64(e.id[:]) > 0 {
-		return e.id
-	}
-	k := acquireKeccak()
-	k.Write([]byte(e.Sig()))
-	dst := k.Sum(nil)
-	releaseKeccak(k)
-	copy(e.id[:], dst)
+	e.idOnce.Do(func() {
+		if !e.id.IsZero() {
+			return
+		}
+		e.id = signatureHash(e.hasher, e.Sig())
+	})
 	return e.id
 }
 
+// Error is a custom error of the contract
+type Error struct {
+	Name   string
+	Inputs *Type
+	hasher func() hash.Hash
+}
+
+// Sig returns the signature of the error
+func (e *Error) Sig() string {
+	return buildSignature(e.Name, e.Inputs)
+}
+
+// ID returns the selector of the error used in the revert data
+func (e *Error) ID() []byte {
+	hash := signatureHash(e.hasher, e.Sig())
+	return hash[:4]
+}
+
 // MustNewEvent creates a new solidity event object or fails
 func MustNewEvent(name string) *Event {
 	evnt, err := NewEvent(name)
@@ -242,7 +664,9 @@ func parseFunctionSignature(name string) (string, *Type, error) {
 
 // NewEventFromType creates a new solidity event object using the name and type
 func NewEventFromType(name string, typ *Type) *Event {
-	return &Event{Name: name, Inputs: typ}
+	e := &Event{Name: name, Inputs: typ}
+	e.ID()
+	return e
 }
 
 // Match checks wheter the log is from this event
@@ -264,10 +688,19 @@ func (e *Event) ParseLog(log *web3.Log) (map[string]interface{}, error) {
 	return e.Inputs.ParseLog(log)
 }
 
+// ParseLogInto parses a log with this event into out without the intermediate
+// map of ParseLog (see DecodeInto)
+func (e *Event) ParseLogInto(log *web3.Log, out interface{}) error {
+	if !e.Match(log) {
+		return fmt.Errorf("log does not match this event")
+	}
+	return ParseLogInto(e.Inputs, log, out)
+}
+
 func buildSignature(name string, typ *Type) string {
 	types := make([]string, len(typ.tuple))
 	for i, input := range typ.tuple {
-		types[i] = input.Elem.raw
+		types[i] = input.Elem.String()
 	}
 	return fmt.Sprintf("%v(%v)", name, strings.Join(types, ","))
 }
@@ -307,9 +740,9 @@ func (a *arguments) Type() *Type {
 
 	tt := &Type{
 		kind:  KindTuple,
-		raw:   "tuple",
 		tuple: inputs,
 	}
+	tt.raw = tt.String()
 	return tt
 }
 
@@ -338,25 +771,24 @@ type ArgumentStr struct {
 	Components []*ArgumentStr
 }
 
-var keccakPool = sync.Pool{
-	New: func() interface{} {
-		return sha3.NewLegacyKeccak256()
-	},
-}
-
-func acquireKeccak() hash.Hash {
-	return keccakPool.Get().(hash.Hash)
+// KeccakHash returns the keccak256 hash of the data. See web3.Keccak256.
+func KeccakHash(data []byte) []byte {
+	hash := web3.Keccak256(data)
+	return hash[:]
 }
 
-func releaseKeccak(k hash.Hash) {
-	k.Reset()
-	keccakPool.Put(k)
+// KeccakHashWith returns the hash of the data with the given hash function
+func KeccakHashWith(h func() hash.Hash, data []byte) []byte {
+	hh := h()
+	hh.Write(data)
+	return hh.Sum(nil)
 }
 
-func KeccakHash(data []byte) []byte {
-	k := acquireKeccak()
-	k.Write(data)
-	hash := k.Sum(nil)
-	releaseKeccak(k)
-	return hash
+// signatureHash returns the hash of the signature with h or keccak256 if h is nil
+func signatureHash(h func() hash.Hash, sig string) (res web3.Hash) {
+	if h == nil {
+		return web3.Keccak256([]byte(sig))
+	}
+	copy(res[:], KeccakHashWith(h, []byte(sig)))
+	return
 }
@@ -2,9 +2,13 @@ package abi
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"github.com/boolw/go-web3"
+	"golang.org/x/crypto/sha3"
+	"math/big"
 	"reflect"
+	"sync"
 	"testing"
 )
 
@@ -30,8 +34,8 @@ func TestAbi(t *testing.T) {
 				Methods: map[string]*Method{
 					"abc": {
 						Name:    "abc",
-						Inputs:  &Type{kind: KindTuple, raw: "tuple", tuple: []*TupleElem{}},
-						Outputs: &Type{kind: KindTuple, raw: "tuple", tuple: []*TupleElem{}},
+						Inputs:  &Type{kind: KindTuple, raw: "()", tuple: []*TupleElem{}},
+						Outputs: &Type{kind: KindTuple, raw: "()", tuple: []*TupleElem{}},
 						id:      []byte{146, 39, 121, 51},
 					},
 				},
@@ -39,7 +43,7 @@ func TestAbi(t *testing.T) {
 					"Transfer": {
 						Name:      "Transfer",
 						Anonymous: false,
-						Inputs:    &Type{kind: KindTuple, size: 0, raw: "tuple", tuple: []*TupleElem{
+						Inputs:    &Type{kind: KindTuple, size: 0, raw: "()", tuple: []*TupleElem{
 							//{
 							//	Name:    "from",
 							//	Elem:    &Type{kind: KindAddress, size: 0, raw: "address", t: reflect.TypeOf(web3.Address{}), tuple: []*TupleElem{}},
@@ -59,6 +63,7 @@ func TestAbi(t *testing.T) {
 						id: web3.HexToHash("0x406dade31f7ae4b5dbc276258c28dde5ae6d5c2773c5745802c493a2360e55e0"),
 					},
 				},
+				Errors: map[string]*Error{},
 			},
 		},
 	}
@@ -70,14 +75,14 @@ func TestAbi(t *testing.T) {
 				t.Fatal(err)
 			}
 			for k, evt := range abi.Events {
-				if evt.ID() != c.Output.Events[k].id {
+				if evt.ID() != c.Output.Events[k].ID() {
 					t.Fatal("bad")
 				}
 				fmt.Println(evt.ID())
 			}
 			for k, method := range abi.Methods {
 				fmt.Println(method.ID())
-				if bytes.Compare(method.ID(), c.Output.Methods[k].id) != 0 {
+				if bytes.Compare(method.ID(), c.Output.Methods[k].ID()) != 0 {
 					t.Fatal("bad")
 				}
 			}
@@ -87,3 +92,548 @@ func TestAbi(t *testing.T) {
 		})
 	}
 }
+
+func TestAbiStateMutability(t *testing.T) {
+	abi, err := NewABI(`[
+		{"name": "a", "type": "function", "stateMutability": "pure"},
+		{"name": "b", "type": "function", "stateMutability": "view"},
+		{"name": "c", "type": "function", "stateMutability": "nonpayable"},
+		{"name": "d", "type": "function", "stateMutability": "payable"},
+		{"name": "e", "type": "function", "constant": true},
+		{"name": "f", "type": "function", "payable": true},
+		{"name": "g", "type": "function"}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]StateMutability{
+		"a": MutabilityPure,
+		"b": MutabilityView,
+		"c": MutabilityNonPayable,
+		"d": MutabilityPayable,
+		"e": MutabilityView,
+		"f": MutabilityPayable,
+		"g": MutabilityNonPayable,
+	}
+	for name, mutability := range cases {
+		method := abi.Methods[name]
+		if method.Mutability != mutability {
+			t.Fatalf("bad mutability for %s: %s", name, method.Mutability)
+		}
+		if method.Payable() != (mutability == MutabilityPayable) {
+			t.Fatal("bad payable")
+		}
+		if method.Clone().Mutability != mutability {
+			t.Fatal("bad clone")
+		}
+	}
+
+	if _, err := NewABI(`[{"name": "a", "type": "function", "stateMutability": "other"}]`); err == nil {
+		t.Fatal("it should fail")
+	}
+}
+
+func TestAbiConstructorPayable(t *testing.T) {
+	cases := map[string]bool{
+		`[{"type": "constructor", "stateMutability": "payable"}]`:    true,
+		`[{"type": "constructor", "stateMutability": "nonpayable"}]`: false,
+		`[{"type": "constructor", "payable": true}]`:                 true,
+		`[{"type": "constructor"}]`:                                  false,
+	}
+	for str, payable := range cases {
+		abi, err := NewABI(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if abi.Constructor.Payable() != payable {
+			t.Fatalf("bad payable for %s", str)
+		}
+		if len(abi.Methods) != 0 {
+			t.Fatal("constructor should not be a method")
+		}
+	}
+
+	if _, err := NewABI(`[{"type": "constructor", "stateMutability": "view"}]`); err == nil {
+		t.Fatal("it should fail")
+	}
+}
+
+func TestAbiMarshalJSON(t *testing.T) {
+	str := `[
+		{"type": "constructor", "inputs": [{"name": "a", "type": "address"}], "stateMutability": "payable"},
+		{"type": "fallback", "stateMutability": "nonpayable"},
+		{"type": "receive", "stateMutability": "payable"},
+		{
+			"type": "function",
+			"name": "swap",
+			"inputs": [
+				{
+					"name": "orders",
+					"type": "tuple[2][]",
+					"components": [
+						{"name": "amount", "type": "uint256"},
+						{"name": "path", "type": "address[]"},
+						{"name": "", "type": "tuple", "components": [{"name": "ok", "type": "bool"}]}
+					]
+				}
+			],
+			"outputs": [{"name": "", "type": "bytes32"}],
+			"stateMutability": "nonpayable"
+		},
+		{"type": "function", "name": "transfer", "inputs": [{"name": "to", "type": "address"}], "outputs": [], "stateMutability": "view"},
+		{"type": "function", "name": "transfer", "inputs": [], "outputs": [], "stateMutability": "pure"},
+		{
+			"type": "event",
+			"name": "Transfer",
+			"inputs": [{"name": "from", "type": "address", "indexed": true}, {"name": "value", "type": "uint256", "indexed": false}],
+			"anonymous": false
+		},
+		{"type": "error", "name": "Unauthorized", "inputs": [{"name": "caller", "type": "address"}]}
+	]`
+
+	abi, err := NewABI(str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !abi.Constructor.Payable() || abi.Fallback == nil || !abi.Receive.Payable() {
+		t.Fatal("bad special functions")
+	}
+	if abi.Errors["Unauthorized"].Sig() != "Unauthorized(address)" {
+		t.Fatal("bad error")
+	}
+
+	raw, err := abi.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the abi is the same after a round trip
+	abi2, err := NewABI(string(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw2, err := abi2.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(raw, raw2) {
+		t.Fatalf("round trip is not stable:\n%s\n%s", raw, raw2)
+	}
+
+	for name, method := range abi.Methods {
+		if !bytes.Equal(method.ID(), abi2.Methods[name].ID()) {
+			t.Fatalf("bad method %s", name)
+		}
+		if abi2.Methods[name].Inputs.String() != method.Inputs.String() {
+			t.Fatal("bad inputs")
+		}
+	}
+	if abi2.Events["Transfer"].ID() != abi.Events["Transfer"].ID() {
+		t.Fatal("bad event")
+	}
+	if !abi2.Events["Transfer"].Inputs.tuple[0].Indexed {
+		t.Fatal("bad indexed")
+	}
+
+	// the components of the tuples are kept
+	expected := `{"name":"orders","type":"tuple[2][]","components":[{"name":"amount","type":"uint256"},` +
+		`{"name":"path","type":"address[]"},{"name":"","type":"tuple","components":[{"name":"ok","type":"bool"}]}]}`
+	if !bytes.Contains(raw, []byte(expected)) {
+		t.Fatalf("bad components: %s", raw)
+	}
+}
+
+func TestAbiIDConcurrent(t *testing.T) {
+	method := &Method{Name: "transfer", Inputs: MustNewType("tuple(address,uint256)")}
+	event := NewEventFromType("Transfer", MustNewType("tuple(address indexed from, address indexed to, uint256 value)"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !bytes.Equal(method.ID(), []byte{0xa9, 0x05, 0x9c, 0xbb}) {
+				t.Error("bad method id")
+			}
+			if event.ID() != web3.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef") {
+				t.Error("bad event id")
+			}
+			method.Clone()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAbiOverloadedEvents(t *testing.T) {
+	abi, err := NewABI(`[
+		{"type": "event", "name": "Transfer", "inputs": [
+			{"name": "from", "type": "address", "indexed": true},
+			{"name": "value", "type": "uint256"}
+		]},
+		{"type": "event", "name": "Transfer", "inputs": [
+			{"name": "from", "type": "address", "indexed": true},
+			{"name": "to", "type": "address", "indexed": true},
+			{"name": "value", "type": "uint256"}
+		]}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(abi.Events) != 2 {
+		t.Fatal("expected two events")
+	}
+
+	event := MustNewEvent("Transfer(address indexed from, address indexed to, uint256 value)")
+	found, ok := abi.EventByID(event.ID())
+	if !ok {
+		t.Fatal("event not found")
+	}
+	if found.Sig() != event.Sig() {
+		t.Fatalf("bad event %s", found.Sig())
+	}
+
+	data, err := MustNewType("uint256").Encode(big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := &web3.Log{
+		Topics: []web3.Hash{
+			event.ID(),
+			{0x1},
+			{0x2},
+		},
+		Data: data,
+	}
+	res, found, err := abi.ParseLog(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Sig() != event.Sig() {
+		t.Fatal("bad event")
+	}
+	if res["value"].(*big.Int).Uint64() != 10 {
+		t.Fatal("bad value")
+	}
+
+	if _, ok := abi.EventByID(web3.Hash{0x1}); ok {
+		t.Fatal("event should not be found")
+	}
+}
+
+func TestAbiByName(t *testing.T) {
+	abi, err := NewABI(`[
+		{"type": "event", "name": "Transfer", "inputs": [
+			{"name": "from", "type": "address", "indexed": true},
+			{"name": "to", "type": "address", "indexed": true},
+			{"name": "value", "type": "uint256"}
+		]},
+		{"type": "event", "name": "Transfer", "inputs": [
+			{"name": "from", "type": "address", "indexed": true},
+			{"name": "value", "type": "uint256"}
+		]},
+		{"type": "event", "name": "Approval", "inputs": []},
+		{"type": "function", "name": "send", "inputs": [{"name": "a", "type": "uint256"}]},
+		{"type": "function", "name": "send", "inputs": [{"name": "a", "type": "address"}]},
+		{"type": "function", "name": "send", "inputs": []},
+		{"type": "function", "name": "sendAll", "inputs": []}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := abi.EventsByName("Transfer")
+	if len(events) != 2 {
+		t.Fatalf("expected two events but found %d", len(events))
+	}
+	if events[0].Sig() != "Transfer(address,address,uint256)" || events[1].Sig() != "Transfer(address,uint256)" {
+		t.Fatalf("bad events %s %s", events[0].Sig(), events[1].Sig())
+	}
+
+	methods := abi.MethodsByName("send")
+	if len(methods) != 3 {
+		t.Fatalf("expected three methods but found %d", len(methods))
+	}
+	sigs := []string{methods[0].Sig(), methods[1].Sig(), methods[2].Sig()}
+	if !reflect.DeepEqual(sigs, []string{"send()", "send(address)", "send(uint256)"}) {
+		t.Fatalf("bad methods %v", sigs)
+	}
+
+	if len(abi.EventsByName("Unknown")) != 0 || len(abi.MethodsByName("Unknown")) != 0 {
+		t.Fatal("expected no results")
+	}
+}
+
+func TestAbiSelectorHash(t *testing.T) {
+	sig := "transfer(address,uint256)"
+	if !bytes.Equal(KeccakHashWith(sha3.NewLegacyKeccak256, []byte(sig)), KeccakHash([]byte(sig))) {
+		t.Fatal("bad keccak hash")
+	}
+
+	source := `[
+		{"type": "function", "name": "transfer", "inputs": [
+			{"name": "to", "type": "address"},
+			{"name": "value", "type": "uint256"}
+		]},
+		{"type": "event", "name": "Transfer", "inputs": [
+			{"name": "from", "type": "address", "indexed": true},
+			{"name": "to", "type": "address", "indexed": true},
+			{"name": "value", "type": "uint256"}
+		]},
+		{"type": "error", "name": "Insufficient", "inputs": [{"name": "value", "type": "uint256"}]}
+	]`
+	abi, err := NewABIWithHasher(source, sha3.New256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := KeccakHashWith(sha3.New256, []byte(sig))
+	if !bytes.Equal(abi.Methods["transfer"].ID(), expected[:4]) {
+		t.Fatal("bad method id")
+	}
+	if _, ok := abi.MethodByID(expected[:4]); !ok {
+		t.Fatal("method not found by id")
+	}
+	event := abi.Events["Transfer"]
+	expected = KeccakHashWith(sha3.New256, []byte(event.Sig()))
+	if id := event.ID(); !bytes.Equal(id[:], expected) {
+		t.Fatal("bad event id")
+	}
+	expected = KeccakHashWith(sha3.New256, []byte("Insufficient(uint256)"))
+	if !bytes.Equal(abi.Errors["Insufficient"].ID(), expected[:4]) {
+		t.Fatal("bad error id")
+	}
+
+	// the other abis use keccak256
+	abi, err = NewABI(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(abi.Methods["transfer"].ID(), []byte{0xa9, 0x05, 0x9c, 0xbb}) {
+		t.Fatal("bad method id")
+	}
+}
+
+func TestAbiParseCalldata(t *testing.T) {
+	abi, err := NewABI(`[
+		{"type": "function", "name": "transfer", "inputs": [
+			{"name": "to", "type": "address"},
+			{"name": "value", "type": "uint256"}
+		]},
+		{"type": "function", "name": "pause", "inputs": []}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transfer := abi.Methods["transfer"]
+	data, err := transfer.Inputs.Encode(map[string]interface{}{
+		"to":    web3.Address{0x1},
+		"value": big.NewInt(10),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	method, args, err := abi.ParseCalldata(append(transfer.ID(), data...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method != transfer {
+		t.Fatalf("bad method %s", method.Name)
+	}
+	if args["to"].(web3.Address) != (web3.Address{0x1}) {
+		t.Fatal("bad to")
+	}
+	if args["value"].(*big.Int).Uint64() != 10 {
+		t.Fatal("bad value")
+	}
+
+	// method without inputs
+	method, args, err = abi.ParseCalldata(abi.Methods["pause"].ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method.Name != "pause" || len(args) != 0 {
+		t.Fatal("bad pause")
+	}
+
+	if _, _, err := abi.ParseCalldata([]byte{0x1, 0x2, 0x3, 0x4}); err == nil {
+		t.Fatal("unknown selector should fail")
+	}
+	if _, _, err := abi.ParseCalldata([]byte{0x1}); err == nil {
+		t.Fatal("short calldata should fail")
+	}
+	if _, _, err := abi.ParseCalldata(transfer.ID()); err == nil {
+		t.Fatal("missing inputs should fail")
+	}
+}
+
+func TestAbiAcceptsEther(t *testing.T) {
+	cases := []struct {
+		abi      string
+		fallback bool
+		receive  bool
+		payable  bool
+		accepts  bool
+	}{
+		{
+			`[]`,
+			false, false, false, false,
+		},
+		{
+			`[{"type": "fallback", "stateMutability": "nonpayable"}]`,
+			true, false, false, false,
+		},
+		{
+			`[{"type": "fallback", "stateMutability": "payable"}]`,
+			true, false, true, true,
+		},
+		{
+			`[{"type": "receive", "stateMutability": "payable"}]`,
+			false, true, false, true,
+		},
+		{
+			`[
+				{"type": "fallback", "stateMutability": "nonpayable"},
+				{"type": "receive", "stateMutability": "payable"}
+			]`,
+			true, true, false, true,
+		},
+	}
+	for _, c := range cases {
+		abi := MustNewABI(c.abi)
+		if abi.HasFallback() != c.fallback {
+			t.Fatalf("bad fallback for %s", c.abi)
+		}
+		if abi.HasReceive() != c.receive {
+			t.Fatalf("bad receive for %s", c.abi)
+		}
+		if abi.FallbackPayable() != c.payable {
+			t.Fatalf("bad fallback payable for %s", c.abi)
+		}
+		if abi.AcceptsEther() != c.accepts {
+			t.Fatalf("bad accepts ether for %s", c.abi)
+		}
+	}
+}
+
+func TestAbiSelectors(t *testing.T) {
+	abi := MustNewABI(`[
+		{"type": "function", "name": "transfer", "inputs": [
+			{"name": "to", "type": "address"},
+			{"name": "value", "type": "uint256"}
+		]},
+		{"type": "function", "name": "transfer", "inputs": [
+			{"name": "to", "type": "address"}
+		]},
+		{"type": "function", "name": "balanceOf", "inputs": [
+			{"name": "owner", "type": "address"}
+		]}
+	]`)
+
+	selectors := abi.Selectors()
+	expected := map[string]string{
+		"transfer(address,uint256)": "a9059cbb",
+		"transfer(address)":         "1a695230",
+		"balanceOf(address)":        "70a08231",
+	}
+	if len(selectors) != len(expected) {
+		t.Fatalf("bad selectors %v", selectors)
+	}
+	for sig, id := range expected {
+		found, ok := selectors[sig]
+		if !ok {
+			t.Fatalf("selector for %s not found", sig)
+		}
+		if hex.EncodeToString(found[:]) != id {
+			t.Fatalf("bad selector for %s: %x", sig, found)
+		}
+	}
+
+	method := abi.Methods["balanceOf"]
+	if !method.VerifyID([]byte{0x70, 0xa0, 0x82, 0x31}) {
+		t.Fatal("the selector should match")
+	}
+	if method.VerifyID([]byte{0xa9, 0x05, 0x9c, 0xbb}) {
+		t.Fatal("the selector should not match")
+	}
+	if method.VerifyID(nil) {
+		t.Fatal("an empty selector should not match")
+	}
+}
+
+func TestAbiConstructorEncode(t *testing.T) {
+	abi := MustNewABI(`[
+		{"type": "constructor", "inputs": [
+			{"name": "owner", "type": "address"},
+			{"name": "supply", "type": "uint256"}
+		]}
+	]`)
+
+	owner := web3.Address{0x1}
+	data, err := abi.Constructor.Encode(owner, big.NewInt(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := MustNewType("tuple(address,uint256)").Encode([]interface{}{owner, big.NewInt(100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, expected) {
+		t.Fatalf("bad encoding %x", data)
+	}
+	if _, err := abi.Constructor.Encode(owner); err == nil {
+		t.Fatal("it should fail with missing arguments")
+	}
+
+	// an abi without constructor takes no arguments
+	empty := MustNewABI(`[]`)
+	if data, err := empty.Constructor.Encode(); err != nil || len(data) != 0 {
+		t.Fatal("bad empty constructor")
+	}
+	if _, err := empty.Constructor.Encode(owner); err == nil {
+		t.Fatal("it should fail with arguments")
+	}
+}
+
+func TestAbiCloneNames(t *testing.T) {
+	abi := MustNewABI(`[
+		{"type": "function", "name": "get", "inputs": [], "outputs": [
+			{"name": "owner", "type": "address"},
+			{"name": "items", "type": "tuple[]", "components": [
+				{"name": "id", "type": "uint256"},
+				{"name": "label", "type": "string"}
+			]}
+		]}
+	]`)
+	method := abi.Methods["get"].Clone()
+
+	if method.Outputs.String() != abi.Methods["get"].Outputs.String() {
+		t.Fatal("bad clone")
+	}
+	input := map[string]interface{}{
+		"owner": web3.Address{0x1},
+		"items": []map[string]interface{}{
+			{"id": big.NewInt(1), "label": "a"},
+		},
+	}
+	data, err := method.Outputs.Encode(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out struct {
+		Owner web3.Address
+		Items []struct {
+			ID    *big.Int
+			Label string
+		}
+	}
+	if err := method.Outputs.DecodeStruct(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Owner != (web3.Address{0x1}) || len(out.Items) != 1 || out.Items[0].Label != "a" {
+		t.Fatal("bad decoding of the cloned type")
+	}
+}
@@ -13,7 +13,7 @@ func (t *Type) Clone() *Type {
 		item.tuple = make([]*TupleElem, len(t.tuple))
 		for k, v := range t.tuple {
 			item.tuple[k] = &TupleElem{
-				//Name: v.Name,
+				Name:    v.Name,
 				Elem:    v.Elem.Clone(),
 				Indexed: v.Indexed,
 			}
@@ -31,7 +31,7 @@ func (e *Event) Clone() *Event {
 	item.Name = e.Name
 	item.Anonymous = e.Anonymous
 	item.Inputs = e.Inputs.Clone()
-	item.id = e.id
+	item.id = e.ID()
 	return item
 }
 
@@ -42,8 +42,9 @@ func (m *Method) Clone() *Method {
 	item := new(Method)
 	item.Name = m.Name
 	item.Const = m.Const
+	item.Mutability = m.Mutability
 	item.Inputs = m.Inputs.Clone()
 	item.Outputs = m.Outputs.Clone()
-	item.id = m.id
+	item.id = m.ID()
 	return item
 }
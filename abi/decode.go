@@ -1,11 +1,15 @@
 package abi
 
 import (
+	"bytes"
 	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/boolw/go-web3"
 	"github.com/mitchellh/mapstructure"
@@ -22,18 +26,252 @@ func Decode(t *Type, input []byte) (val interface{}, err error) {
 	return val, err
 }
 
-// DecodeStruct decodes the input with a type to a struct
+// DecodeStruct decodes the input with a type to a struct. If out is a slice
+// (i.e. []MyStruct) and the type is a tuple with a single array or slice
+// element (i.e. the outputs of a method that returns MyStruct[]), the element
+// is decoded into out.
 func DecodeStruct(t *Type, input []byte, out interface{}) error {
 	val, err := Decode(t, input)
 	if err != nil {
 		return err
 	}
-	if err := mapstructure.Decode(val, out); err != nil {
+	if t.kind == KindTuple && len(t.tuple) == 1 && isSliceTarget(out) {
+		if k := t.tuple[0].Elem.kind; k == KindSlice || k == KindArray {
+			name := t.tuple[0].Name
+			if name == "" {
+				name = "0"
+			}
+			val = val.(map[string]interface{})[name]
+		}
+	}
+	// the fields match the names of the elements by the abi tag or their
+	// name as in the encoding of structs, also in the nested tuples. The
+	// fields without an abi tag also match their mapstructure tag.
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(mapstructureTagHook, bytesDecodeHook),
+		TagName:    "abi",
+		Result:     out,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(val); err != nil {
 		return err
 	}
 	return nil
 }
 
+// isSliceTarget returns true if out is a pointer to a slice or an array
+// that is not a byte array
+func isSliceTarget(out interface{}) bool {
+	typ := reflect.TypeOf(out)
+	if typ == nil || typ.Kind() != reflect.Ptr {
+		return false
+	}
+	typ = typ.Elem()
+	return (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) && !isBytesType(typ)
+}
+
+// bytesDecodeHook converts between fixed bytes (i.e. bytes32 decoded as [32]byte)
+// and []byte or other byte arrays of the same length (i.e. web3.Hash)
+func bytesDecodeHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if !isBytesType(from) || !isBytesType(to) || from == to {
+		return data, nil
+	}
+	v := reflect.ValueOf(data)
+	if to.Kind() == reflect.Slice {
+		res := reflect.MakeSlice(to, v.Len(), v.Len())
+		reflect.Copy(res, v)
+		return res.Interface(), nil
+	}
+	if v.Len() != to.Len() {
+		return nil, fmt.Errorf("cannot decode %d bytes into %s", v.Len(), to.String())
+	}
+	res := reflect.New(to).Elem()
+	reflect.Copy(res, v)
+	return res.Interface(), nil
+}
+
+// mapstructureTagHook adds the values of a tuple decoded into a struct under
+// the name of the fields that have a mapstructure tag but not an abi tag
+func mapstructureTagHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.Map || to.Kind() != reflect.Struct {
+		return data, nil
+	}
+	m, ok := data.(map[string]interface{})
+	if !ok {
+		return data, nil
+	}
+	var res map[string]interface{}
+	for i := 0; i < to.NumField(); i++ {
+		f := to.Field(i)
+		if _, ok := f.Tag.Lookup("abi"); ok {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("mapstructure"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		val, ok := m[name]
+		if !ok {
+			continue
+		}
+		if res == nil {
+			// do not modify the decoded values
+			res = make(map[string]interface{}, len(m)+1)
+			for k, v := range m {
+				res[k] = v
+			}
+		}
+		res[f.Name] = val
+	}
+	if res == nil {
+		return data, nil
+	}
+	return res, nil
+}
+
+func isBytesType(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8
+}
+
+// NamedValue is a decoded value of a tuple with its name. The name
+// is empty if the element of the tuple does not have one.
+type NamedValue struct {
+	Name  string
+	Value interface{}
+}
+
+// DecodeOrdered decodes a tuple into its values in the order of the tuple.
+// Nested tuples are also decoded as a list of named values. The result
+// can be encoded again with the same type.
+func DecodeOrdered(t *Type, input []byte) ([]NamedValue, error) {
+	if t.kind != KindTuple {
+		return nil, fmt.Errorf("expected a tuple but found %s", t.kind)
+	}
+	val, err := Decode(t, input)
+	if err != nil {
+		return nil, err
+	}
+	return toOrdered(t, val).([]NamedValue), nil
+}
+
+func toOrdered(t *Type, val interface{}) interface{} {
+	switch t.kind {
+	case KindTuple:
+		m := val.(map[string]interface{})
+		res := make([]NamedValue, len(t.tuple))
+		for indx, elem := range t.tuple {
+			name := elem.Name
+			if name == "" {
+				name = strconv.Itoa(indx)
+			}
+			res[indx] = NamedValue{Name: elem.Name, Value: toOrdered(elem.Elem, m[name])}
+		}
+		return res
+
+	case KindSlice, KindArray:
+		if !hasTuple(t.elem) {
+			return val
+		}
+		v := reflect.ValueOf(val)
+		res := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			res[i] = toOrdered(t.elem, v.Index(i).Interface())
+		}
+		return res
+	}
+	return val
+}
+
+// DecodeToJSON decodes the input and encodes the value in json with the
+// keys of the tuples in the order of the type, so the output is stable
+// across runs. The integers are json numbers, the addresses and the bytes
+// are hex encoded with the 0x prefix.
+func DecodeToJSON(t *Type, input []byte) ([]byte, error) {
+	val, err := Decode(t, input)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, t, val); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJSON(buf *bytes.Buffer, t *Type, val interface{}) error {
+	switch t.kind {
+	case KindTuple:
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected a map for %s but found %T", t.String(), val)
+		}
+		buf.WriteByte('{')
+		for indx, elem := range t.tuple {
+			name := elem.Name
+			if name == "" {
+				name = strconv.Itoa(indx)
+			}
+			if indx != 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(strconv.Quote(name))
+			buf.WriteByte(':')
+			if err := writeJSON(buf, elem.Elem, m[name]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+
+	case KindSlice, KindArray:
+		v := reflect.ValueOf(val)
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, t.elem, v.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+
+	case KindBool, KindInt, KindUInt:
+		fmt.Fprint(buf, val)
+
+	case KindString:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+
+	case KindAddress:
+		addr, ok := val.(web3.Address)
+		if !ok {
+			return fmt.Errorf("expected an address but found %T", val)
+		}
+		buf.WriteString(strconv.Quote(addr.String()))
+
+	case KindBytes, KindFixedBytes, KindFunction:
+		v := reflect.ValueOf(val)
+		data := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(data), v)
+		buf.WriteString(strconv.Quote("0x" + hex.EncodeToString(data)))
+
+	default:
+		return fmt.Errorf("json not available for type '%s'", t.kind)
+	}
+	return nil
+}
+
+func hasTuple(t *Type) bool {
+	for ; t.kind == KindSlice || t.kind == KindArray; t = t.elem {
+	}
+	return t.kind == KindTuple
+}
+
 func decode(t *Type, input []byte) (interface{}, []byte, error) {
 	var length int
 	var err error
@@ -74,16 +312,17 @@ func decode(t *Type, input []byte) (interface{}, []byte, error) {
 			val = readInteger(t, data)
 		}
 	case KindString:
+		// a zero length in readSlice reads until the end of the input
 		if data,e := readSlice(input,32,length);e != nil {
 			return nil, nil, e
 		}else{
-			val = string(data)
+			val = string(data[:length])
 		}
 	case KindBytes:
 		if data,e := readSlice(input,32,length);e != nil {
 			return nil, nil, e
 		}else{
-			val = data
+			val = data[:length]
 		}
 	case KindAddress:
 		if data,e := readSlice(input,0,32);e != nil {
@@ -114,15 +353,6 @@ func decode(t *Type, input []byte) (interface{}, []byte, error) {
 	}
 }
 
-var (
-	maxUint256 = big.NewInt(0).Add(
-		big.NewInt(0).Exp(big.NewInt(2), big.NewInt(256), nil),
-		big.NewInt(-1))
-	maxInt256 = big.NewInt(0).Add(
-		big.NewInt(0).Exp(big.NewInt(2), big.NewInt(255), nil),
-		big.NewInt(-1))
-)
-
 func readAddr(b []byte) (web3.Address, error) {
 	res := web3.Address{}
 	if len(b) != 32 {
@@ -142,8 +372,8 @@ func readSlice(input []byte, start int, length int) ([]byte, error) {
 	} else if length > 0 {
 		end = start + length
 	}
-	if len(input) < end{
-		return nil,fmt.Errorf("input %d read [%d:%d] error",len(input),start,length)
+	if start < 0 || start > end || len(input) < end {
+		return nil, fmt.Errorf("cannot read bytes [%d:%d] of an input of %d bytes", start, end, len(input))
 	}
 	return input[start:end], nil
 }
@@ -180,10 +410,17 @@ func readInteger(t *Type, b []byte) interface{} {
 			return ret
 		}
 
-		if ret.Cmp(maxInt256) > 0 {
-			ret.Add(maxUint256, big.NewInt(0).Neg(ret))
-			ret.Add(ret, big.NewInt(1))
-			ret.Neg(ret)
+		// two's complement on the size of the type. Values are sign extended
+		// to 32 bytes but some encoders only pad the intN bytes with zeros.
+		size := uint(t.size)
+		if size == 0 || size > 256 {
+			size = 256
+		}
+		if size < 256 {
+			ret.And(ret, new(big.Int).Sub(new(big.Int).Lsh(one, size), one))
+		}
+		if ret.Bit(int(size)-1) == 1 {
+			ret.Sub(ret, new(big.Int).Lsh(one, size))
 		}
 		return ret
 	}
@@ -207,16 +444,23 @@ func readFixedBytes(t *Type, word []byte) (interface{}, error) {
 func decodeTuple(t *Type, data []byte) (interface{}, []byte, error) {
 	res := make(map[string]interface{})
 
+	// the dynamic values are after the head and in order
+	minOffset := 0
+	for _, arg := range t.tuple {
+		minOffset += getTypeSize(arg.Elem)
+	}
+
 	orig := data
 	origLen := len(orig)
 	for indx, arg := range t.tuple {
 		entry := data
 		if arg.Elem.isDynamicType() {
-			offset, err := readOffset(data, origLen)
+			offset, err := readOffset(data, minOffset, origLen)
 			if err != nil {
 				return nil, nil, err
 			}
 			entry = orig[offset:]
+			minOffset = offset + 1
 		}
 
 		val, tail, err := decode(arg.Elem, entry)
@@ -258,6 +502,9 @@ func decodeArraySlice(t *Type, data []byte, size int) (interface{}, []byte, erro
 		res = reflect.New(t.t).Elem()
 	}
 
+	// the dynamic values are after the head and in order
+	minOffset := size * getTypeSize(t.elem)
+
 	orig := data
 	origLen := len(orig)
 	for indx := 0; indx < size; indx++ {
@@ -265,11 +512,12 @@ func decodeArraySlice(t *Type, data []byte, size int) (interface{}, []byte, erro
 
 		entry := data
 		if isDynamic {
-			offset, err := readOffset(data, origLen)
+			offset, err := readOffset(data, minOffset, origLen)
 			if err != nil {
 				return nil, nil, err
 			}
 			entry = orig[offset:]
+			minOffset = offset + 1
 		}
 
 		val, tail, err := decode(t.elem, entry)
@@ -298,18 +546,30 @@ func decodeBool(data []byte) (interface{}, error) {
 	}
 }
 
-func readOffset(data []byte, len int) (int, error) {
+// readOffset reads the offset of a dynamic value in the head of the data.
+// The value must start inside the encoding and leave space for at least
+// one word (i.e. the length of a dynamic array). It cannot be lower than min,
+// so that the values do not overlap the head or the previous dynamic values.
+func readOffset(data []byte, min, size int) (int, error) {
+	if len(data) < 32 {
+		return 0, fmt.Errorf("offset requires 32 bytes but found %d", len(data))
+	}
 	offsetBig := big.NewInt(0).SetBytes(data[0:32])
 	if offsetBig.BitLen() > 63 {
-		return 0, fmt.Errorf("offset larger than int64: %v", offsetBig.Int64())
+		return 0, fmt.Errorf("offset larger than int64: %s", offsetBig)
 	}
 	offset := int(offsetBig.Int64())
-	if offset > len {
-		return 0, fmt.Errorf("offset insufficient %v require %v", len, offset)
+	if offset > size-32 {
+		return 0, fmt.Errorf("offset %d out of bounds for %d bytes", offset, size)
+	}
+	if offset < min {
+		return 0, fmt.Errorf("offset %d points backwards, expected at least %d", offset, min)
 	}
 	return offset, nil
 }
 
+// readLength reads the length of a dynamic value. The length is the
+// number of elements or bytes after the word of the length.
 func readLength(data []byte) (int, error) {
 	input ,err := readSlice(data,0,32)
 	if err != nil {
@@ -317,11 +577,11 @@ func readLength(data []byte) (int, error) {
 	}
 	lengthBig := big.NewInt(0).SetBytes(input)
 	if lengthBig.BitLen() > 63 {
-		return 0, fmt.Errorf("length larger than int64: %v", lengthBig.Int64())
+		return 0, fmt.Errorf("length larger than int64: %s", lengthBig)
 	}
 	length := int(lengthBig.Uint64())
-	if length > len(data) {
-		return 0, fmt.Errorf("length insufficient %v require %v", len(data), length)
+	if length > len(data)-32 {
+		return 0, fmt.Errorf("length %d exceeds the %d bytes available", length, len(data)-32)
 	}
 	return length, nil
 }
@@ -0,0 +1,438 @@
+package abi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/boolw/go-web3"
+)
+
+// DecodeInto decodes the input with a type directly into out without the
+// intermediate values of Decode. Tuples are decoded into structs whose
+// fields match the names of the elements (the abi tag or the name of the
+// field, case insensitive). The unnamed elements match their index. The
+// slices, the bytes and the *big.Int of out are reused when possible, so
+// decoding into the same object again does not allocate them. The fields
+// of type interface{} are decoded as in Decode.
+func DecodeInto(t *Type, input []byte, out interface{}) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("expected a non-nil pointer but found %T", out)
+	}
+	_, err := decodeInto(t, input, v.Elem())
+	return err
+}
+
+// ParseLogInto parses an event log into out as in DecodeInto. Indexed
+// strings, bytes, arrays and tuples are decoded as the IndexedHash of the
+// topic (or any array of 32 bytes, i.e. web3.Hash).
+func ParseLogInto(args *Type, log *web3.Log, out interface{}) error {
+	if err := checkLogTopics(args, log); err != nil {
+		return err
+	}
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("expected a non-nil pointer but found %T", out)
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot decode %s into %s", args.String(), v.Type())
+	}
+
+	// decode indexed fields
+	topics := log.Topics[1:]
+	numIndexed := 0
+	for indx, arg := range args.tuple {
+		if !arg.Indexed {
+			continue
+		}
+		topic := topics[numIndexed]
+		numIndexed++
+
+		field, ok := structField(v, arg.Name, indx)
+		if !ok {
+			continue
+		}
+		if err := decodeTopicInto(arg.Elem, topic, field); err != nil {
+			return err
+		}
+	}
+
+	// the non indexed fields are encoded in the data as a tuple
+	if numIndexed == len(args.tuple) {
+		return nil
+	}
+	_, err := decodeTupleInto(args.tuple, log.Data, v, true)
+	return err
+}
+
+func decodeTopicInto(t *Type, topic web3.Hash, v reflect.Value) error {
+	switch t.kind {
+	case KindBool, KindInt, KindUInt, KindAddress, KindFixedBytes:
+		_, err := decodeInto(t, topic[:], v)
+		return err
+	}
+
+	// the topic is the hash of the value
+	if v.Kind() == reflect.Interface {
+		v.Set(reflect.ValueOf(IndexedHash(topic)))
+		return nil
+	}
+	if isBytesType(v.Type()) && v.Kind() == reflect.Array && v.Len() == len(topic) {
+		reflect.Copy(v, reflect.ValueOf(topic[:]))
+		return nil
+	}
+	return fmt.Errorf("cannot decode the indexed %s into %s", t.String(), v.Type())
+}
+
+func decodeInto(t *Type, input []byte, v reflect.Value) ([]byte, error) {
+	if v.Kind() == reflect.Interface {
+		val, tail, err := decode(t, input)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.TypeOf(val).AssignableTo(v.Type()) {
+			return nil, fmt.Errorf("cannot decode %s into %s", t.String(), v.Type())
+		}
+		v.Set(reflect.ValueOf(val))
+		return tail, nil
+	}
+	if v.Kind() == reflect.Ptr && v.Type() != bigIntT {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeInto(t, input, v.Elem())
+	}
+
+	var length int
+	var err error
+
+	if t.isVariableInput() {
+		length, err = readLength(input)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch t.kind {
+	case KindTuple:
+		if v.Kind() != reflect.Struct {
+			return nil, decodeIntoErr(t, v)
+		}
+		return decodeTupleInto(t.tuple, input, v, false)
+
+	case KindSlice:
+		data, err := readSlice(input, 32, 0)
+		if err != nil {
+			return nil, err
+		}
+		return decodeArraySliceInto(t, data, length, v)
+
+	case KindArray:
+		return decodeArraySliceInto(t, input, t.size, v)
+	}
+
+	switch t.kind {
+	case KindBool:
+		data, err := readSlice(input, 0, 32)
+		if err != nil {
+			return nil, err
+		}
+		if v.Kind() != reflect.Bool {
+			return nil, decodeIntoErr(t, v)
+		}
+		val, err := decodeBool(data)
+		if err != nil {
+			return nil, err
+		}
+		v.SetBool(val.(bool))
+
+	case KindInt, KindUInt:
+		data, err := readSlice(input, 0, 32)
+		if err != nil {
+			return nil, err
+		}
+		if err := readIntegerInto(t, data, v); err != nil {
+			return nil, err
+		}
+
+	case KindString:
+		data, err := readSlice(input, 32, length)
+		if err != nil {
+			return nil, err
+		}
+		if v.Kind() != reflect.String {
+			return nil, decodeIntoErr(t, v)
+		}
+		v.SetString(string(data[:length]))
+
+	case KindBytes:
+		data, err := readSlice(input, 32, length)
+		if err != nil {
+			return nil, err
+		}
+		if v.Kind() != reflect.Slice || !isBytesType(v.Type()) {
+			return nil, decodeIntoErr(t, v)
+		}
+		v.SetBytes(append(v.Bytes()[:0], data[:length]...))
+
+	case KindAddress:
+		data, err := readSlice(input, 0, 32)
+		if err != nil {
+			return nil, err
+		}
+		if err := copyBytesInto(t, data[12:], v); err != nil {
+			return nil, err
+		}
+
+	case KindFixedBytes:
+		data, err := readSlice(input, 0, 32)
+		if err != nil {
+			return nil, err
+		}
+		if err := copyBytesInto(t, data[:t.size], v); err != nil {
+			return nil, err
+		}
+
+	case KindFunction:
+		data, err := readSlice(input, 0, 32)
+		if err != nil {
+			return nil, err
+		}
+		if !allZeros(data[24:32]) {
+			return nil, fmt.Errorf("function type expects the last 8 bytes to be empty but found: %b", data[24:32])
+		}
+		if err := copyBytesInto(t, data[:24], v); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("decoding not available for type '%s'", t.kind)
+	}
+	return readSlice(input, 32, 0)
+}
+
+// decodeTupleInto decodes the elements of a tuple into the fields of the
+// struct. The indexed elements are not in the data of a log and are
+// skipped if skipIndexed is set.
+func decodeTupleInto(elems []*TupleElem, data []byte, v reflect.Value, skipIndexed bool) ([]byte, error) {
+	// the dynamic values are after the head and in order
+	minOffset := 0
+	for _, arg := range elems {
+		if !skipIndexed || !arg.Indexed {
+			minOffset += getTypeSize(arg.Elem)
+		}
+	}
+
+	orig := data
+	origLen := len(orig)
+	for indx, arg := range elems {
+		if skipIndexed && arg.Indexed {
+			continue
+		}
+		isDynamic := arg.Elem.isDynamicType()
+
+		entry := data
+		if isDynamic {
+			offset, err := readOffset(data, minOffset, origLen)
+			if err != nil {
+				return nil, err
+			}
+			entry = orig[offset:]
+			minOffset = offset + 1
+		}
+
+		field, ok := structField(v, arg.Name, indx)
+		var tail []byte
+		var err error
+		if ok {
+			tail, err = decodeInto(arg.Elem, entry, field)
+		} else if !isDynamic {
+			// skip the head of the value without decoding it
+			tail, err = readSlice(entry, getTypeSize(arg.Elem), 0)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !isDynamic {
+			data = tail
+		} else {
+			data = data[32:]
+		}
+	}
+	return data, nil
+}
+
+func decodeArraySliceInto(t *Type, data []byte, size int, v reflect.Value) ([]byte, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("size is lower than zero")
+	}
+	if 32*size > len(data) {
+		return nil, fmt.Errorf("size is too big")
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		// reuse the backing array of the slice if it is large enough
+		if v.Cap() >= size {
+			v.SetLen(size)
+		} else {
+			v.Set(reflect.MakeSlice(v.Type(), size, size))
+		}
+	case reflect.Array:
+		if v.Len() != size {
+			return nil, fmt.Errorf("cannot decode %d elements into %s", size, v.Type())
+		}
+	default:
+		return nil, decodeIntoErr(t, v)
+	}
+
+	// the dynamic values are after the head and in order
+	minOffset := size * getTypeSize(t.elem)
+	isDynamic := t.elem.isDynamicType()
+
+	orig := data
+	origLen := len(orig)
+	for indx := 0; indx < size; indx++ {
+		entry := data
+		if isDynamic {
+			offset, err := readOffset(data, minOffset, origLen)
+			if err != nil {
+				return nil, err
+			}
+			entry = orig[offset:]
+			minOffset = offset + 1
+		}
+
+		tail, err := decodeInto(t.elem, entry, v.Index(indx))
+		if err != nil {
+			return nil, err
+		}
+
+		if !isDynamic {
+			data = tail
+		} else {
+			data = data[32:]
+		}
+	}
+	return data, nil
+}
+
+// readIntegerInto decodes the integer word into an integer or a *big.Int.
+// The value must fit in the integer.
+func readIntegerInto(t *Type, word []byte, v reflect.Value) error {
+	if v.Type() == bigIntT {
+		if v.IsNil() {
+			v.Set(reflect.ValueOf(new(big.Int)))
+		}
+		num := v.Interface().(*big.Int)
+		if t.kind == KindUInt {
+			num.SetBytes(word)
+		} else {
+			num.Set(readInteger(t, word).(*big.Int))
+		}
+		return nil
+	}
+
+	negative := t.kind == KindInt && word[0]&0x80 != 0
+	for _, b := range word[:24] {
+		if (negative && b != 0xff) || (!negative && b != 0) {
+			return fmt.Errorf("value of %s overflows %s", t.String(), v.Type())
+		}
+	}
+	num := binary.BigEndian.Uint64(word[24:])
+
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if negative || v.OverflowUint(num) {
+			return fmt.Errorf("value of %s overflows %s", t.String(), v.Type())
+		}
+		v.SetUint(num)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if negative != (int64(num) < 0) || v.OverflowInt(int64(num)) {
+			return fmt.Errorf("value of %s overflows %s", t.String(), v.Type())
+		}
+		v.SetInt(int64(num))
+
+	default:
+		return decodeIntoErr(t, v)
+	}
+	return nil
+}
+
+// copyBytesInto copies the bytes into a byte array of the same
+// length (i.e. web3.Address) or a byte slice
+func copyBytesInto(t *Type, b []byte, v reflect.Value) error {
+	if !isBytesType(v.Type()) {
+		return decodeIntoErr(t, v)
+	}
+	if v.Kind() == reflect.Slice {
+		v.SetBytes(append(v.Bytes()[:0], b...))
+		return nil
+	}
+	if v.Len() != len(b) {
+		return fmt.Errorf("cannot decode %d bytes into %s", len(b), v.Type())
+	}
+	reflect.Copy(v, reflect.ValueOf(b))
+	return nil
+}
+
+func decodeIntoErr(t *Type, v reflect.Value) error {
+	return fmt.Errorf("cannot decode %s into %s", t.String(), v.Type())
+}
+
+// structFields caches the index of the fields of the structs by their
+// lowercase name
+var structFields sync.Map
+
+// structField returns the field of the struct for the tuple element
+// with the name or at the index if the element does not have a name
+func structField(v reflect.Value, name string, indx int) (reflect.Value, bool) {
+	if name == "" {
+		name = strconv.Itoa(indx)
+	}
+	fields, ok := structFields.Load(v.Type())
+	if !ok {
+		fields, _ = structFields.LoadOrStore(v.Type(), fieldsByName(v.Type()))
+	}
+	i, ok := fields.(map[string]int)[strings.ToLower(name)]
+	if !ok {
+		return reflect.Value{}, false
+	}
+	return v.Field(i), true
+}
+
+// fieldsByName indexes the exported fields of the struct by the abi tag
+// or their name as in the encoding of structs
+func fieldsByName(typ reflect.Type) map[string]int {
+	res := map[string]int{}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		tagValue := f.Tag.Get("abi")
+		if tagValue == "-" {
+			continue
+		}
+
+		name := f.Name
+		if tagValue != "" {
+			name = tagValue
+		}
+
+		name = strings.ToLower(name)
+		if _, ok := res[name]; !ok {
+			res[name] = i
+		}
+	}
+	return res
+}
@@ -0,0 +1,191 @@
+package abi
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/boolw/go-web3"
+)
+
+func TestDecodeInto(t *testing.T) {
+	typ := MustNewType("tuple(uint64 a, int32 b, uint256 c, address d, bytes32 e, string f, bytes g, uint8[] h, tuple(bool ok, int256 n)[2] i, uint256 j)")
+
+	type inner struct {
+		Ok bool
+		N  *big.Int
+	}
+	type obj struct {
+		A uint64
+		B int32
+		C *big.Int
+		D web3.Address
+		E web3.Hash
+		F string
+		G []byte
+		H []uint8
+		I [2]inner
+		J interface{}
+	}
+
+	input := map[string]interface{}{
+		"a": uint64(10),
+		"b": int32(-5),
+		"c": big.NewInt(100),
+		"d": web3.Address{0x1},
+		"e": [32]byte{0x2},
+		"f": "hello",
+		"g": []byte{0x3, 0x4},
+		"h": []uint8{1, 2, 3},
+		"i": [2]map[string]interface{}{
+			{"ok": true, "n": big.NewInt(-1)},
+			{"ok": false, "n": big.NewInt(2)},
+		},
+		"j": big.NewInt(7),
+	}
+	data, err := Encode(input, typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out obj
+	if err := DecodeInto(typ, data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.A != 10 || out.B != -5 || out.C.String() != "100" || out.D != (web3.Address{0x1}) || out.E != (web3.Hash{0x2}) {
+		t.Fatalf("bad values %v", out)
+	}
+	if out.F != "hello" || !reflect.DeepEqual(out.G, []byte{0x3, 0x4}) || !reflect.DeepEqual(out.H, []uint8{1, 2, 3}) {
+		t.Fatalf("bad dynamic values %v", out)
+	}
+	if !out.I[0].Ok || out.I[0].N.String() != "-1" || out.I[1].Ok || out.I[1].N.String() != "2" {
+		t.Fatalf("bad tuples %v", out.I)
+	}
+	if out.J.(*big.Int).String() != "7" {
+		t.Fatalf("bad interface %v", out.J)
+	}
+
+	// decoding again reuses the values of the object
+	num, slice := out.C, out.H
+	if err := DecodeInto(typ, data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.C != num || &out.H[0] != &slice[0] {
+		t.Fatal("expected the values to be reused")
+	}
+}
+
+func TestDecodeIntoErrors(t *testing.T) {
+	cases := []struct {
+		typ string
+		val interface{}
+		out interface{}
+	}{
+		{"uint256", new(big.Int).Lsh(big.NewInt(1), 64), new(uint64)},
+		{"int256", big.NewInt(-1), new(uint64)},
+		{"int256", big.NewInt(-129), new(int8)},
+		{"uint8", uint8(1), new(string)},
+		{"bytes4", [4]byte{}, new(web3.Hash)},
+		{"uint8[]", []uint8{1, 2}, new([3]uint8)},
+	}
+	for _, c := range cases {
+		typ := MustNewType(c.typ)
+		data, err := Encode(c.val, typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := DecodeInto(typ, data, c.out); err == nil {
+			t.Fatalf("%s: expected an error", c.typ)
+		}
+	}
+
+	var num uint64
+	if err := DecodeInto(MustNewType("uint64"), make([]byte, 32), num); err == nil {
+		t.Fatal("expected an error for a value that is not a pointer")
+	}
+}
+
+type transferEvent struct {
+	From  web3.Address
+	To    web3.Address
+	Value *big.Int
+}
+
+var transferEventT = MustNewEvent("Transfer(address indexed from, address indexed to, uint256 value)")
+
+func testTransferLog(t testing.TB) *web3.Log {
+	data, err := MustNewType("uint256").Encode(big.NewInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &web3.Log{
+		Topics: []web3.Hash{
+			transferEventT.ID(),
+			{0x1},
+			{0x2},
+		},
+		Data: data,
+	}
+}
+
+func TestEventParseLogInto(t *testing.T) {
+	log := testTransferLog(t)
+	log.Topics[1] = web3.Hash{31: 0x1}
+	log.Topics[2] = web3.Hash{31: 0x2}
+
+	var out transferEvent
+	if err := transferEventT.ParseLogInto(log, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.From != (web3.Address{19: 0x1}) || out.To != (web3.Address{19: 0x2}) || out.Value.String() != "1000" {
+		t.Fatalf("bad transfer %v", out)
+	}
+
+	// indexed reference values are decoded as the hash of the topic
+	event := MustNewEvent("Named(string indexed name, string value)")
+	data, err := MustNewType("tuple(string)").Encode([]interface{}{"b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var named struct {
+		Name  web3.Hash
+		Value string
+	}
+	log = &web3.Log{Topics: []web3.Hash{event.ID(), {0x3}}, Data: data}
+	if err := event.ParseLogInto(log, &named); err != nil {
+		t.Fatal(err)
+	}
+	if named.Name != (web3.Hash{0x3}) || named.Value != "b" {
+		t.Fatalf("bad named %v", named)
+	}
+
+	log.Topics = log.Topics[:1]
+	if err := event.ParseLogInto(log, &named); err == nil {
+		t.Fatal("expected an error for a missing topic")
+	}
+}
+
+func BenchmarkParseLogTransfer(b *testing.B) {
+	log := testTransferLog(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := transferEventT.ParseLog(log); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseLogIntoTransfer(b *testing.B) {
+	log := testTransferLog(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var out transferEvent
+	for i := 0; i < b.N; i++ {
+		if err := transferEventT.ParseLogInto(log, &out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
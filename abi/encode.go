@@ -1,6 +1,7 @@
 package abi
 
 import (
+	"encoding/hex"
 	"fmt"
 	"math/big"
 	"reflect"
@@ -13,7 +14,12 @@ var (
 	one  = big.NewInt(1)
 )
 
-// Encode encodes a value
+// Encode encodes a value with the type. Types that are not tuples are
+// encoded bare, i.e. a uint256 or an address is a single 32 bytes word and
+// a string or a dynamic array is its length followed by the values. The
+// arguments of a method are always encoded as a tuple instead, which adds
+// the offset of the dynamic values in the head. Use a tuple type (i.e.
+// tuple(string)) to encode a single argument of a call.
 func Encode(v interface{}, t *Type) ([]byte, error) {
 	return encode(reflect.ValueOf(v), t)
 }
@@ -22,6 +28,10 @@ func encode(v reflect.Value, t *Type) ([]byte, error) {
 	if v.Kind() == reflect.Interface {
 		v = v.Elem()
 	}
+	if t.isVariableInput() && isNilValue(v) {
+		// nil bytes, strings and slices are empty (a zero length)
+		return packNum(0), nil
+	}
 
 	switch t.kind {
 	case KindSlice, KindArray:
@@ -40,32 +50,40 @@ func encode(v reflect.Value, t *Type) ([]byte, error) {
 		return encodeAddress(v)
 
 	case KindInt, KindUInt:
-		return encodeNum(v)
+		return encodeInteger(v, t)
 
 	case KindBytes:
 		return encodeBytes(v)
 
 	case KindFixedBytes, KindFunction:
-		return encodeFixedBytes(v)
+		return encodeFixedBytes(v, t)
 
 	default:
 		return nil, fmt.Errorf("encoding not available for type '%s'", t.kind)
 	}
 }
 
+// isNilValue returns true for an untyped nil (i.e. a nil value in a map of
+// interfaces) and the nil pointers and slices
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Ptr, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
 func encodeSliceAndArray(v reflect.Value, t *Type) ([]byte, error) {
 	if v.Kind() != reflect.Array && v.Kind() != reflect.Slice {
 		return nil, encodeErr(v, t.kind.String())
 	}
 
-	if v.Kind() == reflect.Array && t.kind != KindArray {
-		return nil, fmt.Errorf("expected array")
-	} else if v.Kind() == reflect.Slice && t.kind != KindSlice {
-		return nil, fmt.Errorf("expected slice")
-	}
-
+	// the ABI type sets if the elements are inline (fixed array) or
+	// prefixed with the length (slice), either a Go array or slice works
 	if t.kind == KindArray && t.size != v.Len() {
-		return nil, fmt.Errorf("array len incompatible")
+		return nil, fmt.Errorf("expected %d elements for %s but found %d", t.size, t.String(), v.Len())
 	}
 
 	var ret, tail []byte
@@ -134,6 +152,10 @@ func encodeTuple(v reflect.Value, t *Type) ([]byte, error) {
 	for i, elem := range t.tuple {
 		if isList {
 			aux = v.Index(i)
+			if aux.Type() == namedValueT {
+				// list of named values from DecodeOrdered
+				aux = aux.Field(1)
+			}
 		} else {
 			name := elem.Name
 			if name == "" {
@@ -167,25 +189,62 @@ func convertArrayToBytes(value reflect.Value) reflect.Value {
 	return slice
 }
 
-func encodeFixedBytes(v reflect.Value) ([]byte, error) {
-	if v.Kind() == reflect.Array {
-		v = convertArrayToBytes(v)
+// bytesValue returns the bytes of a byte array or slice or of a hex string with 0x prefix
+func bytesValue(v reflect.Value, t string) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return nil, encodeErr(v, t)
+		}
+		if v.Kind() == reflect.Array {
+			v = convertArrayToBytes(v)
+		}
+		return v.Bytes(), nil
+
+	case reflect.String:
+		str := v.String()
+		if !strings.HasPrefix(str, "0x") {
+			return nil, fmt.Errorf("failed to encode string '%s' as %s: 0x prefix not found", str, t)
+		}
+		buf, err := hex.DecodeString(str[2:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode string '%s' as %s: %v", str, t, err)
+		}
+		return buf, nil
+
+	default:
+		return nil, encodeErr(v, t)
 	}
-	return rightPad(v.Bytes(), 32), nil
+}
+
+func encodeFixedBytes(v reflect.Value, t *Type) ([]byte, error) {
+	buf, err := bytesValue(v, t.String())
+	if err != nil {
+		return nil, err
+	}
+	if v.Kind() == reflect.String && len(buf) != t.size {
+		return nil, fmt.Errorf("failed to encode string '%s' as %s: expected %d bytes but found %d", v.String(), t.String(), t.size, len(buf))
+	}
+	return rightPad(buf, 32), nil
 }
 
 func encodeAddress(v reflect.Value) ([]byte, error) {
-	if v.Kind() == reflect.Array {
-		v = convertArrayToBytes(v)
+	buf, err := bytesValue(v, "address")
+	if err != nil {
+		return nil, err
+	}
+	if v.Kind() == reflect.String && len(buf) != 20 {
+		return nil, fmt.Errorf("failed to encode string '%s' as address: expected 20 bytes but found %d", v.String(), len(buf))
 	}
-	return leftPad(v.Bytes(), 32), nil
+	return leftPad(buf, 32), nil
 }
 
 func encodeBytes(v reflect.Value) ([]byte, error) {
-	if v.Kind() == reflect.Array {
-		v = convertArrayToBytes(v)
+	buf, err := bytesValue(v, "bytes")
+	if err != nil {
+		return nil, err
 	}
-	return packBytesSlice(v.Bytes(), v.Len())
+	return packBytesSlice(buf, len(buf))
 }
 
 func encodeString(v reflect.Value) ([]byte, error) {
@@ -209,18 +268,69 @@ func packNum(offset int) []byte {
 }
 
 func encodeNum(v reflect.Value) ([]byte, error) {
+	num, err := bigFromValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return toU256(num), nil
+}
+
+// encodeInteger encodes the number as a word of the integer type. The number
+// must be in the range of the type. The negative numbers are encoded in two's
+// complement sign extended to 32 bytes.
+func encodeInteger(v reflect.Value, t *Type) ([]byte, error) {
+	num, err := bigFromValue(v)
+	if err != nil {
+		return nil, err
+	}
+	size := uint(t.size)
+	if size == 0 || size > 256 {
+		size = 256
+	}
+	if t.kind == KindUInt {
+		if num.Sign() < 0 || uint(num.BitLen()) > size {
+			return nil, fmt.Errorf("value %s out of the range of %s", num, t.String())
+		}
+	} else {
+		// the range of intN is [-2^(N-1), 2^(N-1)-1]
+		limit := new(big.Int).Lsh(one, size-1)
+		if num.Cmp(limit) >= 0 || num.Cmp(new(big.Int).Neg(limit)) < 0 {
+			return nil, fmt.Errorf("value %s out of the range of %s", num, t.String())
+		}
+	}
+	return toU256(num), nil
+}
+
+func bigFromValue(v reflect.Value) (*big.Int, error) {
 	switch v.Kind() {
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return toU256(new(big.Int).SetUint64(v.Uint())), nil
+		return new(big.Int).SetUint64(v.Uint()), nil
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return toU256(big.NewInt(v.Int())), nil
+		return big.NewInt(v.Int()), nil
 
 	case reflect.Ptr:
 		if v.Type() != bigIntT {
 			return nil, encodeErr(v.Elem(), "number")
 		}
-		return toU256(v.Interface().(*big.Int)), nil
+		if v.IsNil() {
+			return nil, fmt.Errorf("failed to encode a nil *big.Int as number")
+		}
+		return v.Interface().(*big.Int), nil
+
+	case reflect.String:
+		// decimal or hex (with 0x prefix) number
+		str := v.String()
+		num, ok := new(big.Int), false
+		if strings.HasPrefix(str, "0x") {
+			num, ok = num.SetString(str[2:], 16)
+		} else {
+			num, ok = num.SetString(str, 10)
+		}
+		if !ok {
+			return nil, fmt.Errorf("failed to encode string '%s' as number", str)
+		}
+		return num, nil
 
 	default:
 		return nil, encodeErr(v, "number")
@@ -285,13 +395,41 @@ func toU256(n *big.Int) []byte {
 	return leftPad(b.Bytes(), 32)
 }
 
+// EncodeUint256 encodes the number as a 32 bytes word. Negative numbers are
+// encoded in two's complement.
+func EncodeUint256(n *big.Int) [32]byte {
+	var res [32]byte
+	copy(res[:], toU256(n))
+	return res
+}
+
+// PadLeft pads the bytes with zeros on the left to a 32 bytes word (i.e. numbers and addresses).
+// If b is longer than 32 bytes only the last 32 bytes are used.
+func PadLeft(b []byte) [32]byte {
+	var res [32]byte
+	copy(res[:], leftPad(b, 32))
+	return res
+}
+
+// PadRight pads the bytes with zeros on the right to a 32 bytes word (i.e. bytesN).
+// If b is longer than 32 bytes only the first 32 bytes are used.
+func PadRight(b []byte) [32]byte {
+	var res [32]byte
+	copy(res[:], rightPad(b, 32))
+	return res
+}
+
 func padBytes(b []byte, size int, left bool) []byte {
 	l := len(b)
 	if l == size {
 		return b
 	}
 	if l > size {
-		return b[l-size:]
+		// keep the aligned side of the value
+		if left {
+			return b[l-size:]
+		}
+		return b[:size]
 	}
 	tmp := make([]byte, size)
 	if left {
@@ -1,7 +1,9 @@
 package abi
 
 import (
+	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"math/rand"
@@ -536,3 +538,863 @@ func TestEncodingStruct(t *testing.T) {
 		t.Fatal("bad")
 	}
 }
+
+func TestDecodeSignedIntegers(t *testing.T) {
+	ones := func(n int) string {
+		return strings.Repeat("ff", n)
+	}
+	zeros := func(n int) string {
+		return strings.Repeat("00", n)
+	}
+	pow2 := func(n uint) *big.Int {
+		return new(big.Int).Lsh(big.NewInt(1), n)
+	}
+
+	cases := []struct {
+		Type     string
+		Input    string
+		Expected interface{}
+	}{
+		{"int256", ones(32), big.NewInt(-1)},
+		{"int256", "80" + zeros(31), new(big.Int).Neg(pow2(255))},
+		{"int256", "7f" + ones(31), new(big.Int).Sub(pow2(255), big.NewInt(1))},
+		{"int256", ones(31) + "fe", big.NewInt(-2)},
+		{"int128", ones(32), big.NewInt(-1)},
+		{"int128", ones(16) + "80" + zeros(15), new(big.Int).Neg(pow2(127))},
+		{"int128", zeros(16) + "7f" + ones(15), new(big.Int).Sub(pow2(127), big.NewInt(1))},
+		// negative value padded with zeros instead of sign extended
+		{"int128", zeros(16) + ones(16), big.NewInt(-1)},
+		{"int24", ones(31) + "fe", big.NewInt(-2)},
+		{"int8", ones(32), int8(-1)},
+		{"int8", ones(31) + "80", int8(-128)},
+		{"int8", zeros(31) + "7f", int8(127)},
+		{"int64", ones(24) + "80" + zeros(7), int64(-1 << 63)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Type, func(t *testing.T) {
+			typ := MustNewType(c.Type)
+			res, err := typ.Decode(decodeHex(c.Input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(res, c.Expected) {
+				t.Fatalf("expected %v but found %v", c.Expected, res)
+			}
+
+			// the values are sign extended when encoded
+			encoded, err := typ.Encode(c.Expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err = typ.Decode(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(res, c.Expected) {
+				t.Fatalf("expected %v but found %v", c.Expected, res)
+			}
+		})
+	}
+}
+
+func TestEncodeSignedIntegers(t *testing.T) {
+	ones := func(n int) string {
+		return strings.Repeat("ff", n)
+	}
+	zeros := func(n int) string {
+		return strings.Repeat("00", n)
+	}
+	pow2 := func(n uint) *big.Int {
+		return new(big.Int).Lsh(big.NewInt(1), n)
+	}
+
+	cases := []struct {
+		Type     string
+		Value    interface{}
+		Expected string
+	}{
+		{"int256", big.NewInt(-1), ones(32)},
+		{"int256", new(big.Int).Neg(pow2(255)), "80" + zeros(31)},
+		{"int256", new(big.Int).Sub(pow2(255), big.NewInt(1)), "7f" + ones(31)},
+		{"int128", big.NewInt(-1), ones(32)},
+		{"int128", new(big.Int).Neg(pow2(127)), ones(16) + "80" + zeros(15)},
+		{"int128", big.NewInt(-1000), ones(30) + "fc18"},
+		{"int8", int8(-128), ones(31) + "80"},
+		{"int24", "-2", ones(31) + "fe"},
+	}
+
+	for _, c := range cases {
+		typ := MustNewType(c.Type)
+		encoded, err := typ.Encode(c.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(encoded) != c.Expected {
+			t.Fatalf("%s: expected %s but found %x", c.Type, c.Expected, encoded)
+		}
+		res, err := typ.Decode(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(res) != fmt.Sprint(c.Value) {
+			t.Fatalf("%s: expected %v but found %v", c.Type, c.Value, res)
+		}
+	}
+
+	// the values out of the range of the type are not encoded
+	outOfRange := []struct {
+		Type  string
+		Value interface{}
+	}{
+		{"int256", pow2(255)},
+		{"int256", new(big.Int).Sub(new(big.Int).Neg(pow2(255)), big.NewInt(1))},
+		{"int128", pow2(127)},
+		{"int8", 128},
+		{"uint256", big.NewInt(-1)},
+		{"uint8", 256},
+		{"uint64", -1},
+	}
+	for _, c := range outOfRange {
+		if _, err := MustNewType(c.Type).Encode(c.Value); err == nil {
+			t.Fatalf("%s: expected an error for %v", c.Type, c.Value)
+		}
+	}
+}
+
+func TestEncodeBareValues(t *testing.T) {
+	word := func(b byte) string {
+		return strings.Repeat("00", 31) + hex.EncodeToString([]byte{b})
+	}
+
+	cases := []struct {
+		Type     string
+		Value    interface{}
+		Expected string
+	}{
+		{"uint256", big.NewInt(1), word(1)},
+		{"address", web3.Address{19: 0x2}, word(2)},
+		{"bool", true, word(1)},
+		// dynamic values are the length and the data without an offset
+		{"string", "a", word(1) + "61" + strings.Repeat("00", 31)},
+		{"uint8[]", []uint8{3}, word(1) + word(3)},
+		// a single argument of a method has the offset
+		{"tuple(string)", []interface{}{"a"}, word(0x20) + word(1) + "61" + strings.Repeat("00", 31)},
+	}
+	for _, c := range cases {
+		typ := MustNewType(c.Type)
+		encoded, err := Encode(c.Value, typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(encoded) != c.Expected {
+			t.Fatalf("%s: expected %s but found %x", c.Type, c.Expected, encoded)
+		}
+		if _, err := Decode(typ, encoded); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEncodeNilDynamicValues(t *testing.T) {
+	typ := MustNewType("tuple(bytes a, string b, uint256[] c, tuple(uint8 x)[] d)")
+
+	// nil values are encoded as the empty values
+	empty := map[string]interface{}{
+		"a": []byte{},
+		"b": "",
+		"c": []*big.Int{},
+		"d": []map[string]interface{}{},
+	}
+	expected, err := typ.Encode(empty)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nilBytes *[]byte
+	inputs := []interface{}{
+		map[string]interface{}{"a": nil, "b": nil, "c": nil, "d": nil},
+		map[string]interface{}{"a": []byte(nil), "b": "", "c": []*big.Int(nil), "d": nil},
+		map[string]interface{}{"a": nilBytes, "b": nil, "c": nil, "d": nil},
+		struct {
+			A []byte
+			B string
+			C []*big.Int
+			D []struct{ X uint8 }
+		}{},
+	}
+	for _, input := range inputs {
+		encoded, err := typ.Encode(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(encoded, expected) {
+			t.Fatalf("expected %x but found %x", expected, encoded)
+		}
+	}
+
+	// a nil value of a static type cannot be encoded
+	if _, err := MustNewType("tuple(uint256 a)").Encode(map[string]interface{}{"a": nil}); err == nil {
+		t.Fatal("expected an error for a nil number")
+	}
+}
+
+func TestDecodeOrdered(t *testing.T) {
+	typ := MustNewType("tuple(uint256 b, address a, tuple(string, bool) c, tuple(uint8 x)[] d)")
+
+	input := map[string]interface{}{
+		"b": big.NewInt(1),
+		"a": web3.Address{0x1},
+		"c": map[string]interface{}{
+			"0": "hello",
+			"1": true,
+		},
+		"d": []map[string]interface{}{
+			{"x": uint8(1)},
+			{"x": uint8(2)},
+		},
+	}
+	encoded, err := typ.Encode(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := typ.DecodeOrdered(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []NamedValue{
+		{Name: "b", Value: big.NewInt(1)},
+		{Name: "a", Value: web3.Address{0x1}},
+		{Name: "c", Value: []NamedValue{
+			{Name: "", Value: "hello"},
+			{Name: "", Value: true},
+		}},
+		{Name: "d", Value: []interface{}{
+			[]NamedValue{{Name: "x", Value: uint8(1)}},
+			[]NamedValue{{Name: "x", Value: uint8(2)}},
+		}},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("bad: %v", res)
+	}
+
+	// the ordered values encode to the same input
+	encoded2, err := typ.Encode(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(encoded, encoded2) {
+		t.Fatal("bad encoding")
+	}
+
+	if _, err := DecodeOrdered(MustNewType("uint256"), encoded); err == nil {
+		t.Fatal("it should fail for a non tuple type")
+	}
+}
+
+func TestEncodeWordHelpers(t *testing.T) {
+	cases := []struct {
+		Word     [32]byte
+		Expected string
+	}{
+		{EncodeUint256(big.NewInt(1)), strings.Repeat("00", 31) + "01"},
+		{EncodeUint256(big.NewInt(-1)), strings.Repeat("ff", 32)},
+		{EncodeUint256(big.NewInt(-2)), strings.Repeat("ff", 31) + "fe"},
+		{PadLeft([]byte{0x1, 0x2}), strings.Repeat("00", 30) + "0102"},
+		{PadRight([]byte{0x1, 0x2}), "0102" + strings.Repeat("00", 30)},
+		{PadLeft(nil), strings.Repeat("00", 32)},
+		{PadLeft(append([]byte{0x1}, bytes.Repeat([]byte{0x2}, 32)...)), strings.Repeat("02", 32)},
+		{PadRight(append(bytes.Repeat([]byte{0x2}, 32), 0x1)), strings.Repeat("02", 32)},
+	}
+	for _, c := range cases {
+		if hex.EncodeToString(c.Word[:]) != c.Expected {
+			t.Fatalf("expected %s but found %s", c.Expected, hex.EncodeToString(c.Word[:]))
+		}
+	}
+
+	// same result as the encoder
+	word := EncodeUint256(big.NewInt(-5))
+	encoded, err := Encode(big.NewInt(-5), MustNewType("int256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(word[:], encoded) {
+		t.Fatal("bad")
+	}
+}
+
+func TestDecodeStructBytes(t *testing.T) {
+	typ := MustNewType("tuple(bytes32 a, bytes32 b, bytes c, bytes20 d, bytes32[] e)")
+
+	hash := web3.Hash{0x1, 0x2}
+	input := map[string]interface{}{
+		"a": hash,
+		"b": hash,
+		"c": []byte{0x3},
+		"d": [20]byte{0x4},
+		"e": [][32]byte{hash},
+	}
+	encoded, err := typ.Encode(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bytesN into slices and byte arrays of the same length
+	var obj struct {
+		A []byte
+		B web3.Hash
+		C []byte
+		D web3.Address
+		E []web3.Hash
+	}
+	if err := typ.DecodeStruct(encoded, &obj); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(obj.A, hash[:]) || obj.B != hash || !reflect.DeepEqual(obj.C, []byte{0x3}) {
+		t.Fatal("bad")
+	}
+	if obj.D != (web3.Address{0x4}) || len(obj.E) != 1 || obj.E[0] != hash {
+		t.Fatal("bad")
+	}
+
+	// the lengths of the arrays must match
+	var obj2 struct {
+		A [20]byte
+	}
+	if err := typ.DecodeStruct(encoded, &obj2); err == nil {
+		t.Fatal("it should fail")
+	}
+}
+
+func TestDecodeStructHashesAndAddresses(t *testing.T) {
+	typ := MustNewType("tuple(bytes32 a, bytes32[2] b, address c, bytes20 d, address[] e)")
+
+	input := map[string]interface{}{
+		"a": [32]byte{0x1},
+		"b": [2][32]byte{{0x2}, {0x3}},
+		"c": web3.Address{0x4},
+		"d": [20]byte{0x5},
+		"e": []web3.Address{{0x6}},
+	}
+	encoded, err := typ.Encode(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bytes32 into web3.Hash, bytes20 into web3.Address and the other
+	// way around, also behind pointers and in arrays
+	var obj struct {
+		A *web3.Hash
+		B [2]web3.Hash
+		C [20]byte
+		D *web3.Address
+		E [][20]byte
+	}
+	if err := typ.DecodeStruct(encoded, &obj); err != nil {
+		t.Fatal(err)
+	}
+	if obj.A == nil || *obj.A != (web3.Hash{0x1}) || obj.B != [2]web3.Hash{{0x2}, {0x3}} {
+		t.Fatalf("bad hashes %+v", obj)
+	}
+	if obj.C != [20]byte{0x4} || obj.D == nil || *obj.D != (web3.Address{0x5}) || !reflect.DeepEqual(obj.E, [][20]byte{{0x6}}) {
+		t.Fatalf("bad addresses %+v", obj)
+	}
+}
+
+func TestDecodeStructNestedTuples(t *testing.T) {
+	typ := MustNewType("tuple(uint256 id, tuple(address owner, uint256 balance, tuple(bool frozen) flags) account)")
+
+	type flags struct {
+		Frozen bool
+	}
+	type account struct {
+		Owner web3.Address
+		Funds *big.Int `abi:"balance"`
+		Flags flags
+	}
+	input := struct {
+		ID      *big.Int
+		Account account
+	}{
+		ID:      big.NewInt(1),
+		Account: account{Owner: web3.Address{0x1}, Funds: big.NewInt(5), Flags: flags{Frozen: true}},
+	}
+	encoded, err := typ.Encode(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the fields of the nested structs match the abi tags and the names
+	var obj struct {
+		ID   *big.Int
+		Data account `abi:"account"`
+	}
+	if err := typ.DecodeStruct(encoded, &obj); err != nil {
+		t.Fatal(err)
+	}
+	if obj.ID.String() != "1" || !reflect.DeepEqual(obj.Data, input.Account) {
+		t.Fatalf("bad nested tuple %+v", obj)
+	}
+
+	// nested pointers to structs
+	var obj2 struct {
+		Account *account
+	}
+	if err := typ.DecodeStruct(encoded, &obj2); err != nil {
+		t.Fatal(err)
+	}
+	if obj2.Account == nil || !reflect.DeepEqual(*obj2.Account, input.Account) {
+		t.Fatalf("bad nested tuple %+v", obj2)
+	}
+}
+
+func TestDecodeStructTags(t *testing.T) {
+	typ := MustNewType("tuple(uint256 id, address owner, tuple(bool frozen, uint8 level) flags)")
+
+	encoded, err := typ.Encode(map[string]interface{}{
+		"id":    big.NewInt(1),
+		"owner": web3.Address{0x1},
+		"flags": map[string]interface{}{"frozen": true, "level": uint8(2)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the abi tags are used first and the mapstructure tags are used for
+	// the fields without an abi tag, also in the nested tuples
+	var obj struct {
+		Num   *big.Int     `abi:"id"`
+		Addr  web3.Address `mapstructure:"owner"`
+		Other web3.Address `abi:"other" mapstructure:"owner"`
+		Flags struct {
+			IsFrozen bool  `mapstructure:"frozen"`
+			Level    uint8 `abi:"level"`
+		} `mapstructure:"flags"`
+	}
+	if err := typ.DecodeStruct(encoded, &obj); err != nil {
+		t.Fatal(err)
+	}
+	if obj.Num.String() != "1" || obj.Addr != (web3.Address{0x1}) || obj.Other != (web3.Address{}) {
+		t.Fatalf("bad tags %+v", obj)
+	}
+	if !obj.Flags.IsFrozen || obj.Flags.Level != 2 {
+		t.Fatalf("bad nested tags %+v", obj.Flags)
+	}
+}
+
+func TestDecodeStructSlice(t *testing.T) {
+	type obj struct {
+		A *big.Int
+		B string
+	}
+	elems := []map[string]interface{}{
+		{"a": big.NewInt(1), "b": "x"},
+		{"a": big.NewInt(2), "b": "y"},
+	}
+	expected := []obj{{big.NewInt(1), "x"}, {big.NewInt(2), "y"}}
+
+	// a slice of tuples
+	typ := MustNewType("tuple(uint256 a, string b)[]")
+	encoded, err := typ.Encode(elems)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res []obj
+	if err := typ.DecodeStruct(encoded, &res); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatal("bad")
+	}
+
+	// the outputs of a method that returns a slice of tuples
+	for _, name := range []string{"res", ""} {
+		typ = MustNewType("tuple(tuple(uint256 a, string b)[] " + name + ")")
+		encoded, err = typ.Encode([]interface{}{elems})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res []obj
+		if err := typ.DecodeStruct(encoded, &res); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(res, expected) {
+			t.Fatal("bad")
+		}
+	}
+}
+
+func TestEncodeCoercion(t *testing.T) {
+	addr := web3.Address{0x1, 0x2}
+
+	cases := []struct {
+		Type     string
+		Input    interface{}
+		Expected interface{}
+	}{
+		{"uint256", "1000", big.NewInt(1000)},
+		{"uint256", "0x3e8", big.NewInt(1000)},
+		{"int256", "-5", big.NewInt(-5)},
+		{"uint8", int(10), uint8(10)},
+		{"address", addr.String(), addr},
+		{"bytes", "0x0102", []byte{0x1, 0x2}},
+		{"bytes", "0x", []byte{}},
+		{"bytes4", "0x01020304", [4]byte{0x1, 0x2, 0x3, 0x4}},
+		{"tuple(uint256 a, address b)", map[string]interface{}{"a": "1", "b": addr.String()}, map[string]interface{}{"a": big.NewInt(1), "b": addr}},
+		{"uint256[]", []string{"1", "0x2"}, []*big.Int{big.NewInt(1), big.NewInt(2)}},
+	}
+	for _, c := range cases {
+		typ := MustNewType(c.Type)
+
+		found, err := Encode(c.Input, typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := Encode(c.Expected, typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(found, expected) {
+			t.Fatalf("bad encoding for %s", c.Type)
+		}
+	}
+
+	invalid := []struct {
+		Type  string
+		Input interface{}
+	}{
+		{"uint256", "1.5"},
+		{"uint256", "0xzz"},
+		{"uint256", true},
+		{"address", "0x0102"},
+		{"address", "1234"},
+		{"address", 1},
+		{"bytes", "hello"},
+		{"bytes4", "0x0102"},
+		{"bytes", []int{1, 2}},
+	}
+	for _, c := range invalid {
+		if _, err := Encode(c.Input, MustNewType(c.Type)); err == nil {
+			t.Fatalf("expected an error encoding %v as %s", c.Input, c.Type)
+		}
+	}
+}
+
+func TestEncodeNestedArrays(t *testing.T) {
+	words := func(w ...string) []byte {
+		var res []byte
+		for _, i := range w {
+			res = append(res, decodeHex(i+strings.Repeat("0", 64-len(i)))...)
+		}
+		return res
+	}
+	num := func(i uint64) string {
+		word := EncodeUint256(new(big.Int).SetUint64(i))
+		return hex.EncodeToString(word[:])
+	}
+
+	// example of the solidity abi spec g(uint256[][],string[])
+	typ := MustNewType("tuple(uint256[][] a, string[] b)")
+	input := map[string]interface{}{
+		"a": [][]*big.Int{{big.NewInt(1), big.NewInt(2)}, {big.NewInt(3)}},
+		"b": []string{"one", "two", "three"},
+	}
+	expected := words(
+		num(0x40), num(0x140),
+		num(2), num(0x40), num(0xa0), num(2), num(1), num(2), num(1), num(3),
+		num(3), num(0x60), num(0xa0), num(0xe0),
+		num(3), hex.EncodeToString([]byte("one")),
+		num(3), hex.EncodeToString([]byte("two")),
+		num(5), hex.EncodeToString([]byte("three")),
+	)
+	found, err := Encode(input, typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(found, expected) {
+		t.Fatalf("bad encoding %s", hex.EncodeToString(found))
+	}
+
+	cases := []struct {
+		Type     string
+		Input    interface{}
+		Expected []byte
+	}{
+		{
+			// fixed arrays inside a slice are inline
+			"uint256[2][]",
+			[][2]*big.Int{{big.NewInt(1), big.NewInt(2)}, {big.NewInt(3), big.NewInt(4)}},
+			words(num(2), num(1), num(2), num(3), num(4)),
+		},
+		{
+			"uint256[3][]",
+			[][3]*big.Int{{big.NewInt(1), big.NewInt(2), big.NewInt(3)}},
+			words(num(1), num(1), num(2), num(3)),
+		},
+		{
+			// a fixed array of dynamic types has offsets but not length
+			"bytes[2]",
+			[2][]byte{{0x1}, {0x2, 0x3}},
+			words(num(0x40), num(0x80), num(1), "01", num(2), "0203"),
+		},
+		{
+			"string[3]",
+			[3]string{"a", "b", "c"},
+			words(num(0x60), num(0xa0), num(0xe0), num(1), "61", num(1), "62", num(1), "63"),
+		},
+	}
+	for _, c := range cases {
+		typ := MustNewType(c.Type)
+
+		found, err := Encode(c.Input, typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(found, c.Expected) {
+			t.Fatalf("bad encoding for %s: %s", c.Type, hex.EncodeToString(found))
+		}
+		decoded, err := Decode(typ, found)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded, c.Input) {
+			t.Fatalf("bad round trip for %s", c.Type)
+		}
+	}
+
+	// a slice can be used for a fixed array with the same length
+	found, err = Encode([]string{"a", "b", "c"}, MustNewType("string[3]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(found, cases[3].Expected) {
+		t.Fatal("bad encoding of the slice as an array")
+	}
+	if _, err := Encode([]string{"a", "b"}, MustNewType("string[3]")); err == nil {
+		t.Fatal("expected an error for the wrong length")
+	}
+}
+
+func TestDecodeEmptyValues(t *testing.T) {
+	typ := MustNewType("tuple(bytes a, string b, uint256[] c, string[] d, bytes e)")
+	input := map[string]interface{}{
+		"a": []byte{},
+		"b": "",
+		"c": []*big.Int{},
+		"d": []string{},
+		"e": []byte{0x1},
+	}
+	data, err := Encode(input, typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := Decode(typ, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(found, input) {
+		t.Fatalf("bad decoding %v", found)
+	}
+
+	// the empty values are not nil
+	res := found.(map[string]interface{})
+	if res["a"].([]byte) == nil || res["c"].([]*big.Int) == nil || res["d"].([]string) == nil {
+		t.Fatal("expected empty values but found nil")
+	}
+
+	// a single empty value
+	for _, c := range []struct {
+		Type  string
+		Input interface{}
+	}{
+		{"bytes", []byte{}},
+		{"string", ""},
+		{"uint256[]", []*big.Int{}},
+	} {
+		typ := MustNewType(c.Type)
+		data, err := Encode(c.Input, typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found, err := Decode(typ, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(found, c.Input) {
+			t.Fatalf("bad decoding of %s: %v", c.Type, found)
+		}
+	}
+}
+
+func TestDecodeToJSON(t *testing.T) {
+	typ := MustNewType("tuple(uint256 z, address a, int8 m, tuple(string, bool) c, bytes4 f, bytes b, tuple(uint8 x)[2] d)")
+
+	input := map[string]interface{}{
+		"z": big.NewInt(1000),
+		"a": web3.HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"),
+		"m": int8(-5),
+		"c": map[string]interface{}{
+			"0": "he\"llo",
+			"1": true,
+		},
+		"f": [4]byte{0x1, 0x2, 0x3, 0x4},
+		"b": []byte{0xff},
+		"d": []map[string]interface{}{
+			{"x": uint8(1)},
+			{"x": uint8(2)},
+		},
+	}
+	encoded, err := typ.Encode(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"z":1000,"a":"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed","m":-5,"c":{"0":"he\"llo","1":true},"f":"0x01020304","b":"0xff","d":[{"x":1},{"x":2}]}`
+	for i := 0; i < 10; i++ {
+		res, err := typ.DecodeToJSON(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(res) != expected {
+			t.Fatalf("bad: %s", res)
+		}
+	}
+
+	// the output is valid json
+	var out map[string]interface{}
+	res, _ := DecodeToJSON(typ, encoded)
+	if err := json.Unmarshal(res, &out); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecodeMalformedOffsets(t *testing.T) {
+	// word returns the hex of a 32 bytes word with the value
+	word := func(n string) string {
+		return strings.Repeat("0", 64-len(n)) + n
+	}
+
+	cases := []struct {
+		Type  string
+		Input string
+	}{
+		// input shorter than the offset
+		{"tuple(string)", "00"},
+		{"tuple(uint256,bytes)", word("1") + "0020"},
+		// offset at the end of the input
+		{"tuple(string)", word("20")},
+		// offset beyond the input
+		{"tuple(string)", word("60") + word("0")},
+		// offset without space for the length
+		{"tuple(string)", word("21") + word("1")},
+		// offset larger than int64
+		{"tuple(bytes)", strings.Repeat("ff", 32) + word("0")},
+		// length beyond the input
+		{"tuple(string)", word("20") + word("40") + word("0")},
+		{"tuple(bytes)", word("20") + word("21") + word("0")},
+		{"string", word("1")},
+		// length larger than int64
+		{"tuple(bytes)", word("20") + strings.Repeat("ff", 32)},
+		// more elements than the input
+		{"tuple(uint256[])", word("20") + word("100000000") + word("1")},
+		{"uint256[]", word("2") + word("1")},
+		// nested offset beyond the input
+		{"tuple(string[])", word("20") + word("1") + word("80")},
+		{"tuple(tuple(string,uint8)[2])", word("20") + word("40") + word("0")},
+		{"tuple(bytes[][])", word("20") + word("1") + word("20") + word("1") + word("ff")},
+		// offset inside the head
+		{"tuple(string)", word("0") + word("0")},
+		{"tuple(uint256,string)", word("40") + word("0") + word("0")},
+		{"string[]", word("1") + word("0") + word("0")},
+		// offsets backwards or overlapping the previous value
+		{"tuple(string,string)", word("40") + word("40") + word("0")},
+		{"tuple(string,bytes)", word("60") + word("40") + word("0") + word("0")},
+		{"string[]", word("2") + word("40") + word("40") + word("0")},
+	}
+	for _, c := range cases {
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					t.Fatalf("decoding %s panics with %s: %v", c.Type, c.Input, err)
+				}
+			}()
+			if _, err := Decode(MustNewType(c.Type), decodeHex(c.Input)); err == nil {
+				t.Fatalf("decoding %s should fail with %s", c.Type, c.Input)
+			}
+		}()
+	}
+
+	// random corruptions of a valid encoding do not panic
+	typ := MustNewType("tuple(string a, bytes[] b, tuple(uint8 c, string d)[2] e)")
+	data, err := typ.Encode(map[string]interface{}{
+		"a": "hello",
+		"b": [][]byte{{0x1}, {0x2, 0x3}},
+		"e": []map[string]interface{}{
+			{"c": uint8(1), "d": "a"},
+			{"c": uint8(2), "d": "b"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		input := make([]byte, r.Intn(len(data)+1))
+		copy(input, data)
+		for j := 0; j < 3 && len(input) != 0; j++ {
+			input[r.Intn(len(input))] = byte(r.Intn(256))
+		}
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					t.Fatalf("decoding panics with 0x%x: %v", input, err)
+				}
+			}()
+			Decode(typ, input)
+		}()
+	}
+}
+
+func TestDecodeLengthOverflow(t *testing.T) {
+	lengths := []string{
+		"41",                     // one byte more than the data
+		"7fffffffffffffff",       // max int64
+		"ffffffffffffffff",       // max uint64
+		strings.Repeat("ff", 32), // max uint256
+	}
+	values := map[string]interface{}{
+		"string": "ab",
+		"bytes":  []byte{0x1, 0x2},
+	}
+	for typ, val := range values {
+		event := &Event{Name: "A", Inputs: MustNewType("tuple(" + typ + " a)")}
+		encoded, err := event.Inputs.Encode(map[string]interface{}{"a": val})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, length := range lengths {
+			// overwrite the length word after the offset
+			data := append([]byte{}, encoded...)
+			word := decodeHex(strings.Repeat("0", 64-len(length)) + length)
+			copy(data[32:64], word)
+
+			func() {
+				defer func() {
+					if err := recover(); err != nil {
+						t.Fatalf("decoding %s with length 0x%s panics: %v", typ, length, err)
+					}
+				}()
+				if _, err := Decode(event.Inputs, data); err == nil {
+					t.Fatalf("decoding %s with length 0x%s should fail", typ, length)
+				}
+				log := &web3.Log{Topics: []web3.Hash{event.ID()}, Data: data}
+				if _, err := event.ParseLog(log); err == nil {
+					t.Fatalf("parsing a log with %s of length 0x%s should fail", typ, length)
+				}
+			}()
+		}
+	}
+}
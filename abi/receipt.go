@@ -0,0 +1,58 @@
+package abi
+
+import (
+	"fmt"
+
+	"github.com/boolw/go-web3"
+)
+
+// DecodedLog is a log decoded with its event. The log has the block,
+// the transaction and the index of the event.
+type DecodedLog struct {
+	Log   *web3.Log
+	Event *Event
+	Args  map[string]interface{}
+}
+
+// Parse decodes the log with the event and keeps the log with the arguments
+func (e *Event) Parse(log *web3.Log) (*DecodedLog, error) {
+	args, err := e.ParseLog(log)
+	if err != nil {
+		return nil, err
+	}
+	return &DecodedLog{
+		Log:   log,
+		Event: e,
+		Args:  args,
+	}, nil
+}
+
+// FilterLogs returns the logs of the receipt that match the event.
+// The web3 package cannot depend on abi so this is not a method of web3.Receipt.
+func FilterLogs(receipt *web3.Receipt, event *Event) []*web3.Log {
+	logs := []*web3.Log{}
+	for _, log := range receipt.Logs {
+		if event.Match(log) {
+			logs = append(logs, log)
+		}
+	}
+	return logs
+}
+
+// ParseReceipt decodes all the logs of the receipt that match an event
+// of the registry. Logs without a registered event are skipped.
+func (r *Registry) ParseReceipt(receipt *web3.Receipt) ([]*DecodedLog, error) {
+	res := []*DecodedLog{}
+	for _, log := range receipt.Logs {
+		event, ok := r.Match(log)
+		if !ok {
+			continue
+		}
+		decoded, err := event.Parse(log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode log %d: %v", log.LogIndex, err)
+		}
+		res = append(res, decoded)
+	}
+	return res, nil
+}
@@ -0,0 +1,85 @@
+package abi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/boolw/go-web3"
+)
+
+func TestReceiptLogs(t *testing.T) {
+	a := MustNewABI(`[
+		{"type": "event", "name": "A", "inputs": [{"name": "a", "type": "uint256"}]},
+		{"type": "event", "name": "B", "inputs": [{"name": "b", "type": "uint256"}]}
+	]`)
+
+	data, err := MustNewType("uint256").Encode(big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := &web3.Receipt{
+		Logs: []*web3.Log{
+			{LogIndex: 0, Topics: []web3.Hash{a.Events["A"].ID()}, Data: data},
+			{LogIndex: 1, Topics: []web3.Hash{{0x1}}},
+			{LogIndex: 2, Topics: []web3.Hash{a.Events["B"].ID()}, Data: data},
+			{LogIndex: 3, Topics: []web3.Hash{a.Events["A"].ID()}, Data: data},
+		},
+	}
+
+	logs := FilterLogs(receipt, a.Events["A"])
+	if len(logs) != 2 || logs[0].LogIndex != 0 || logs[1].LogIndex != 3 {
+		t.Fatal("bad filter")
+	}
+
+	decoded, err := NewRegistry(a).ParseReceipt(receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 3 {
+		t.Fatalf("expected 3 logs but found %d", len(decoded))
+	}
+	if decoded[1].Event != a.Events["B"] || decoded[1].Log.LogIndex != 2 {
+		t.Fatal("bad event")
+	}
+	if decoded[1].Args["b"].(*big.Int).Uint64() != 10 {
+		t.Fatal("bad value")
+	}
+
+	// a log with a known topic but invalid data fails
+	receipt.Logs = append(receipt.Logs, &web3.Log{Topics: []web3.Hash{a.Events["A"].ID()}})
+	if _, err := NewRegistry(a).ParseReceipt(receipt); err == nil {
+		t.Fatal("it should fail")
+	}
+}
+
+func TestEventParse(t *testing.T) {
+	event := MustNewABI(`[
+		{"type": "event", "name": "A", "inputs": [{"name": "a", "type": "uint256"}]}
+	]`).Events["A"]
+
+	data, err := MustNewType("uint256").Encode(big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := &web3.Log{
+		BlockNumber:     5,
+		TransactionHash: web3.Hash{0x1},
+		LogIndex:        2,
+		Topics:          []web3.Hash{event.ID()},
+		Data:            data,
+	}
+	decoded, err := event.Parse(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Event != event || decoded.Log.BlockNumber != 5 || decoded.Log.TransactionHash != (web3.Hash{0x1}) || decoded.Log.LogIndex != 2 {
+		t.Fatal("bad context")
+	}
+	if decoded.Args["a"].(*big.Int).Uint64() != 10 {
+		t.Fatal("bad value")
+	}
+
+	if _, err := event.Parse(&web3.Log{Topics: []web3.Hash{{0x1}}}); err == nil {
+		t.Fatal("it should fail")
+	}
+}
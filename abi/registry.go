@@ -0,0 +1,227 @@
+package abi
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/boolw/go-web3"
+)
+
+// Registry is a set of events and methods from one or more contract ABIs
+// indexed by their topic id and selector
+type Registry struct {
+	lock       sync.RWMutex
+	events     map[web3.Hash][]*Event
+	methods    map[[4]byte][]*Method
+	contracts  map[web3.Address]*ABI
+	collisions []error
+}
+
+// NewRegistry creates a new registry with the events and methods of the
+// given abis. Use Collisions to check if some of the selectors are ambiguous.
+func NewRegistry(abis ...*ABI) *Registry {
+	r := &Registry{
+		events:    map[web3.Hash][]*Event{},
+		methods:   map[[4]byte][]*Method{},
+		contracts: map[web3.Address]*ABI{},
+	}
+	for _, a := range abis {
+		r.AddABI(a)
+	}
+	return r
+}
+
+// AddABI registers all the non anonymous events and the methods of the abi
+func (r *Registry) AddABI(a *ABI) {
+	for _, event := range a.Events {
+		r.AddEvent(event)
+	}
+	for _, method := range a.Methods {
+		r.AddMethod(method)
+	}
+}
+
+// AddContract registers the abi and binds it to the address, so that the
+// calldata sent to the address is decoded with the abi even if its selectors
+// collide with the ones of other abis
+func (r *Registry) AddContract(addr web3.Address, a *ABI) {
+	r.AddABI(a)
+	r.lock.Lock()
+	r.contracts[addr] = a
+	r.lock.Unlock()
+}
+
+// AddMethod registers a method. Methods with the same signature are the same
+// method, if two methods with different signatures share the selector the
+// collision is recorded.
+func (r *Registry) AddMethod(m *Method) {
+	var id [4]byte
+	copy(id[:], m.ID())
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, method := range r.methods[id] {
+		if method.Sig() == m.Sig() {
+			return
+		}
+	}
+	for _, method := range r.methods[id] {
+		r.collisions = append(r.collisions, fmt.Errorf("selector 0x%x of %s collides with %s", id, m.Sig(), method.Sig()))
+	}
+	r.methods[id] = append(r.methods[id], m)
+}
+
+// Collisions returns an error for each pair of registered methods with
+// different signatures and the same selector
+func (r *Registry) Collisions() []error {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return append([]error{}, r.collisions...)
+}
+
+// MatchCalldata returns the method of the selector of the calldata. It does
+// not match if the selector is unknown or ambiguous.
+func (r *Registry) MatchCalldata(data []byte) (*Method, bool) {
+	if len(data) < 4 {
+		return nil, false
+	}
+	var id [4]byte
+	copy(id[:], data[:4])
+
+	r.lock.RLock()
+	methods := r.methods[id]
+	r.lock.RUnlock()
+
+	if len(methods) != 1 {
+		return nil, false
+	}
+	return methods[0], true
+}
+
+// MatchCalldataForAddress returns the method of the selector of the calldata
+// sent to the address. The abi bound to the address with AddContract is used
+// first, otherwise it is the same as MatchCalldata.
+func (r *Registry) MatchCalldataForAddress(addr web3.Address, data []byte) (*Method, bool) {
+	if len(data) < 4 {
+		return nil, false
+	}
+	r.lock.RLock()
+	a, ok := r.contracts[addr]
+	r.lock.RUnlock()
+
+	if ok {
+		if method, ok := a.MethodByID(data[:4]); ok {
+			return method, true
+		}
+	}
+	return r.MatchCalldata(data)
+}
+
+// AddEvent registers an event. Anonymous events do not have a topic id
+// and are ignored. Events with the same topic id and a different number
+// of indexed inputs (i.e. the Transfer events of ERC20 and ERC721) are
+// all kept, the first one is kept if the number is the same.
+func (r *Registry) AddEvent(e *Event) {
+	if e.Anonymous {
+		return
+	}
+	id := e.ID()
+	indexed := indexedInputs(e.Inputs)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, event := range r.events[id] {
+		if indexedInputs(event.Inputs) == indexed {
+			return
+		}
+	}
+	r.events[id] = append(r.events[id], e)
+}
+
+// Event returns the first event registered with the given topic id
+func (r *Registry) Event(topic web3.Hash) (*Event, bool) {
+	r.lock.RLock()
+	events := r.events[topic]
+	r.lock.RUnlock()
+
+	if len(events) == 0 {
+		return nil, false
+	}
+	return events[0], true
+}
+
+// Topics returns the topic ids of all the registered events sorted
+func (r *Registry) Topics() []web3.Hash {
+	r.lock.RLock()
+	topics := make([]web3.Hash, 0, len(r.events))
+	for topic := range r.events {
+		topics = append(topics, topic)
+	}
+	r.lock.RUnlock()
+
+	sort.Slice(topics, func(i, j int) bool {
+		return bytes.Compare(topics[i][:], topics[j][:]) < 0
+	})
+	return topics
+}
+
+// DispatchTable returns the decoders of the logs of the registered events by
+// their topic id. The table is a snapshot, the events added later are not in it.
+func (r *Registry) DispatchTable() map[web3.Hash]func(*web3.Log) (map[string]interface{}, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	table := make(map[web3.Hash]func(*web3.Log) (map[string]interface{}, error), len(r.events))
+	for topic, events := range r.events {
+		events := events
+		table[topic] = func(log *web3.Log) (map[string]interface{}, error) {
+			return matchEvent(events, log).ParseLog(log)
+		}
+	}
+	return table
+}
+
+// Match returns the event that matches the log. If several events have the
+// topic id of the log, it is the one with an indexed input for each topic.
+func (r *Registry) Match(log *web3.Log) (*Event, bool) {
+	if len(log.Topics) == 0 {
+		return nil, false
+	}
+	r.lock.RLock()
+	events := r.events[log.Topics[0]]
+	r.lock.RUnlock()
+
+	if len(events) == 0 {
+		return nil, false
+	}
+	return matchEvent(events, log), true
+}
+
+// matchEvent returns the event with the same number of indexed inputs as
+// the topics of the log or the first one so that decoding the log reports
+// the mismatch
+func matchEvent(events []*Event, log *web3.Log) *Event {
+	for _, event := range events {
+		if indexedInputs(event.Inputs) == len(log.Topics)-1 {
+			return event
+		}
+	}
+	return events[0]
+}
+
+// ParseLog finds the event that matches the log and decodes it
+func (r *Registry) ParseLog(log *web3.Log) (map[string]interface{}, *Event, error) {
+	event, ok := r.Match(log)
+	if !ok {
+		return nil, nil, fmt.Errorf("no event found for the log")
+	}
+	res, err := event.ParseLog(log)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, event, nil
+}
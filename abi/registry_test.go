@@ -0,0 +1,158 @@
+package abi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/boolw/go-web3"
+)
+
+func TestRegistry(t *testing.T) {
+	abi0 := MustNewABI(`[
+		{"type": "event", "name": "A", "inputs": [{"name": "a", "type": "uint256", "indexed": true}]},
+		{"type": "event", "name": "B", "inputs": [], "anonymous": true}
+	]`)
+	abi1 := MustNewABI(`[
+		{"type": "event", "name": "C", "inputs": [{"name": "c", "type": "uint256"}]}
+	]`)
+
+	r := NewRegistry(abi0, abi1)
+	if len(r.Topics()) != 2 {
+		t.Fatal("anonymous events should not be registered")
+	}
+
+	data, err := MustNewType("uint256").Encode(big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := &web3.Log{
+		Topics: []web3.Hash{abi1.Events["C"].ID()},
+		Data:   data,
+	}
+	res, event, err := r.ParseLog(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event != abi1.Events["C"] {
+		t.Fatal("bad event")
+	}
+	if res["c"].(*big.Int).Uint64() != 10 {
+		t.Fatal("bad value")
+	}
+
+	if _, _, err := r.ParseLog(&web3.Log{Topics: []web3.Hash{{0x1}}}); err == nil {
+		t.Fatal("it should fail")
+	}
+
+	table := r.DispatchTable()
+	if len(table) != 2 {
+		t.Fatal("bad dispatch table")
+	}
+	res, err = table[log.Topics[0]](log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res["c"].(*big.Int).Uint64() != 10 {
+		t.Fatal("bad value")
+	}
+}
+
+func TestRegistryTransferEvents(t *testing.T) {
+	erc20 := MustNewABI(`[{"type": "event", "name": "Transfer", "inputs": [
+		{"name": "from", "type": "address", "indexed": true},
+		{"name": "to", "type": "address", "indexed": true},
+		{"name": "value", "type": "uint256", "indexed": false}
+	]}]`)
+	erc721 := MustNewABI(`[{"type": "event", "name": "Transfer", "inputs": [
+		{"name": "from", "type": "address", "indexed": true},
+		{"name": "to", "type": "address", "indexed": true},
+		{"name": "tokenId", "type": "uint256", "indexed": true}
+	]}]`)
+
+	// both events have the same topic id
+	r := NewRegistry(erc20, erc721)
+	if len(r.Topics()) != 1 {
+		t.Fatal("expected a single topic id")
+	}
+
+	num := MustNewType("uint256")
+	id, err := EncodeTopic(num, big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := num.Encode(big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	topics := []web3.Hash{erc20.Events["Transfer"].ID(), {31: 0x1}, {31: 0x2}}
+
+	// the event is selected by the number of topics
+	res, event, err := r.ParseLog(&web3.Log{Topics: topics, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event != erc20.Events["Transfer"] || res["value"].(*big.Int).Uint64() != 10 {
+		t.Fatal("expected the erc20 transfer")
+	}
+
+	log := &web3.Log{Topics: append(topics, id)}
+	res, event, err = r.ParseLog(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event != erc721.Events["Transfer"] || res["tokenId"].(*big.Int).Uint64() != 5 {
+		t.Fatal("expected the erc721 transfer")
+	}
+
+	res, err = r.DispatchTable()[topics[0]](log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res["tokenId"].(*big.Int).Uint64() != 5 {
+		t.Fatal("bad dispatch table")
+	}
+
+	// a log that does not match any of them fails to decode
+	if _, _, err := r.ParseLog(&web3.Log{Topics: topics[:1]}); err == nil {
+		t.Fatal("it should fail")
+	}
+}
+
+func TestRegistryCalldata(t *testing.T) {
+	// transfer(address,uint256) and many_msg_babbage(bytes1) share the selector 0xa9059cbb
+	abi0 := MustNewABI(`[
+		{"type": "function", "name": "transfer", "inputs": [{"name": "to", "type": "address"}, {"name": "value", "type": "uint256"}]}
+	]`)
+	abi1 := MustNewABI(`[
+		{"type": "function", "name": "many_msg_babbage", "inputs": [{"name": "a", "type": "bytes1"}]},
+		{"type": "function", "name": "approve", "inputs": [{"name": "to", "type": "address"}, {"name": "value", "type": "uint256"}]}
+	]`)
+	abi2 := MustNewABI(`[
+		{"type": "function", "name": "transfer", "inputs": [{"name": "dst", "type": "address"}, {"name": "amount", "type": "uint256"}]}
+	]`)
+
+	r := NewRegistry(abi0, abi1, abi2)
+	if len(r.Collisions()) != 1 {
+		t.Fatal("expected one collision")
+	}
+
+	transfer := abi0.Methods["transfer"].ID()
+	if _, ok := r.MatchCalldata(transfer); ok {
+		t.Fatal("ambiguous selectors should not match")
+	}
+	if method, ok := r.MatchCalldata(abi1.Methods["approve"].ID()); !ok || method.Name != "approve" {
+		t.Fatal("bad approve")
+	}
+
+	addr := web3.Address{0x1}
+	r.AddContract(addr, abi1)
+	if method, ok := r.MatchCalldataForAddress(addr, transfer); !ok || method.Name != "many_msg_babbage" {
+		t.Fatal("bad method of the address")
+	}
+	if _, ok := r.MatchCalldataForAddress(web3.Address{0x2}, transfer); ok {
+		t.Fatal("ambiguous selectors should not match")
+	}
+	if _, ok := r.MatchCalldataForAddress(addr, []byte{0x1}); ok {
+		t.Fatal("short calldata should not match")
+	}
+}
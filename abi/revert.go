@@ -0,0 +1,69 @@
+package abi
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+)
+
+var (
+	// revertErrorSelector is the selector of Error(string) used by revert and require
+	revertErrorSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+	revertReasonT = MustNewType("tuple(string reason)")
+
+	// panicSelector is the selector of Panic(uint256) used by assert and the
+	// checks inserted by the compiler
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+
+	panicT = MustNewType("tuple(uint256 code)")
+)
+
+// panicReasons are the reasons of the panic codes of solidity
+var panicReasons = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "conversion to an invalid enum value",
+	0x22: "incorrectly encoded storage byte array",
+	0x31: "pop on an empty array",
+	0x32: "array index out of bounds",
+	0x41: "too much memory allocated",
+	0x51: "call to a zero-initialized internal function",
+}
+
+// DecodeRevertReason decodes the reason of the revert data encoded as
+// Error(string). It returns false if the data is not a revert reason.
+func DecodeRevertReason(data []byte) (string, bool) {
+	if len(data) < 4 || !bytes.Equal(data[:4], revertErrorSelector) {
+		return "", false
+	}
+	val, err := Decode(revertReasonT, data[4:])
+	if err != nil {
+		return "", false
+	}
+	reason, ok := val.(map[string]interface{})["reason"].(string)
+	return reason, ok
+}
+
+// DecodePanic decodes the code of the revert data encoded as Panic(uint256)
+// and its reason. It returns false if the data is not a panic.
+func DecodePanic(data []byte) (uint64, string, bool) {
+	if len(data) < 4 || !bytes.Equal(data[:4], panicSelector) {
+		return 0, "", false
+	}
+	val, err := Decode(panicT, data[4:])
+	if err != nil {
+		return 0, "", false
+	}
+	code, ok := val.(map[string]interface{})["code"].(*big.Int)
+	if !ok || !code.IsUint64() {
+		return 0, "", false
+	}
+	reason, ok := panicReasons[code.Uint64()]
+	if !ok {
+		reason = fmt.Sprintf("unknown panic code 0x%x", code.Uint64())
+	}
+	return code.Uint64(), reason, true
+}
@@ -0,0 +1,74 @@
+package abi
+
+import (
+	"testing"
+)
+
+func TestDecodeRevertReason(t *testing.T) {
+	// revert("ERC20: insufficient allowance")
+	data := decodeHex("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"000000000000000000000000000000000000000000000000000000000000001d" +
+		"45524332303a20696e73756666696369656e7420616c6c6f77616e6365000000")
+
+	reason, ok := DecodeRevertReason(data)
+	if !ok {
+		t.Fatal("expected a revert reason")
+	}
+	if reason != "ERC20: insufficient allowance" {
+		t.Fatalf("bad reason '%s'", reason)
+	}
+
+	invalid := [][]byte{
+		nil,
+		decodeHex("0x08c379"),
+		// Panic(uint256)
+		decodeHex("0x4e487b71" + "0000000000000000000000000000000000000000000000000000000000000001"),
+		// bad encoding of the string
+		decodeHex("0x08c379a0" + "0000000000000000000000000000000000000000000000000000000000000020"),
+	}
+	for _, data := range invalid {
+		if _, ok := DecodeRevertReason(data); ok {
+			t.Fatalf("unexpected revert reason for %x", data)
+		}
+	}
+}
+
+func TestDecodePanic(t *testing.T) {
+	cases := []struct {
+		data   string
+		code   uint64
+		reason string
+	}{
+		{"0000000000000000000000000000000000000000000000000000000000000001", 0x01, "assertion failed"},
+		{"0000000000000000000000000000000000000000000000000000000000000011", 0x11, "arithmetic underflow or overflow"},
+		{"0000000000000000000000000000000000000000000000000000000000000012", 0x12, "division or modulo by zero"},
+		{"0000000000000000000000000000000000000000000000000000000000000032", 0x32, "array index out of bounds"},
+		{"0000000000000000000000000000000000000000000000000000000000000099", 0x99, "unknown panic code 0x99"},
+	}
+	for _, c := range cases {
+		code, reason, ok := DecodePanic(decodeHex("0x4e487b71" + c.data))
+		if !ok {
+			t.Fatalf("expected a panic for %s", c.data)
+		}
+		if code != c.code || reason != c.reason {
+			t.Fatalf("bad panic %d '%s'", code, reason)
+		}
+	}
+
+	invalid := [][]byte{
+		nil,
+		decodeHex("0x4e487b"),
+		// Error(string)
+		decodeHex("0x08c379a0" + "0000000000000000000000000000000000000000000000000000000000000020"),
+		// missing code
+		decodeHex("0x4e487b71"),
+		// code larger than uint64
+		decodeHex("0x4e487b71" + "0000000000000000000000000000000100000000000000000000000000000000"),
+	}
+	for _, data := range invalid {
+		if _, _, ok := DecodePanic(data); ok {
+			t.Fatalf("unexpected panic for %x", data)
+		}
+	}
+}
@@ -9,18 +9,30 @@ import (
 	"github.com/boolw/go-web3"
 )
 
-// ParseLog parses an event log
+// ParseLog parses an event log. Indexed strings, bytes, arrays and tuples are
+// returned as the IndexedHash of the topic since their values are not in the log.
+// The log must have the event id and one topic for each indexed input.
 func ParseLog(args *Type, log *web3.Log) (map[string]interface{}, error) {
+	if err := checkLogTopics(args, log); err != nil {
+		return nil, err
+	}
 	var indexed, nonIndexed []*TupleElem
 
-	for idx, arg := range args.TupleElems() {
+	// the non indexed fields are encoded in the data as a tuple with
+	// the head and tail layout. The elements are copied to name the
+	// unnamed ones without modifying the type.
+	elems := make([]*TupleElem, len(args.TupleElems()))
+	for idx, elem := range args.TupleElems() {
+		arg := *elem
 		if arg.Name == "" {
 			arg.Name = strconv.Itoa(idx)
 		}
+		elems[idx] = &arg
+
 		if arg.Indexed {
-			indexed = append(indexed, arg)
+			indexed = append(indexed, &arg)
 		} else {
-			nonIndexed = append(nonIndexed, arg)
+			nonIndexed = append(nonIndexed, &arg)
 		}
 	}
 
@@ -44,7 +56,7 @@ func ParseLog(args *Type, log *web3.Log) (map[string]interface{}, error) {
 	}
 
 	res := map[string]interface{}{}
-	for _, arg := range args.TupleElems() {
+	for _, arg := range elems {
 		if arg.Indexed {
 			res[arg.Name] = indexedObjs[0]
 			indexedObjs = indexedObjs[1:]
@@ -56,6 +68,33 @@ func ParseLog(args *Type, log *web3.Log) (map[string]interface{}, error) {
 	return res, nil
 }
 
+// checkLogTopics checks that the log has the event id (topic0) and a topic
+// for each indexed input, otherwise the values would be misaligned
+func checkLogTopics(args *Type, log *web3.Log) error {
+	if args.kind != KindTuple {
+		return fmt.Errorf("expected a tuple type")
+	}
+	if len(log.Topics) == 0 {
+		return fmt.Errorf("log without topics, expected at least the event id")
+	}
+	indexed := indexedInputs(args)
+	if len(log.Topics)-1 != indexed {
+		return fmt.Errorf("log has %d indexed topics but the event has %d indexed inputs", len(log.Topics)-1, indexed)
+	}
+	return nil
+}
+
+// indexedInputs returns the number of indexed elements of the tuple
+func indexedInputs(args *Type) int {
+	indexed := 0
+	for _, arg := range args.tuple {
+		if arg.Indexed {
+			indexed++
+		}
+	}
+	return indexed
+}
+
 // ParseTopics parses topics from a log event
 func ParseTopics(args *Type, topics []web3.Hash) ([]interface{}, error) {
 	if args.kind != KindTuple {
@@ -77,7 +116,23 @@ func ParseTopics(args *Type, topics []web3.Hash) ([]interface{}, error) {
 	return elems, nil
 }
 
-// ParseTopic parses an individual topic
+// IndexedHash is the topic of an indexed reference value (i.e. string, bytes,
+// arrays or tuples). Solidity stores the keccak256 hash of the encoded value in
+// the topic instead of the value itself, so the original value cannot be
+// recovered from the log.
+type IndexedHash web3.Hash
+
+// Hash returns the topic hash
+func (i IndexedHash) Hash() web3.Hash {
+	return web3.Hash(i)
+}
+
+func (i IndexedHash) String() string {
+	return web3.Hash(i).String()
+}
+
+// ParseTopic parses an individual topic. Indexed strings, bytes, arrays and
+// tuples are returned as an IndexedHash.
 func ParseTopic(t *Type, topic web3.Hash) (interface{}, error) {
 	switch t.kind {
 	case KindBool:
@@ -97,6 +152,11 @@ func ParseTopic(t *Type, topic web3.Hash) (interface{}, error) {
 	case KindFixedBytes:
 		return topic, nil
 
+	case KindString, KindBytes, KindArray, KindSlice, KindTuple:
+		// the topic is the hash of the value, arrays and tuples
+		// are hashed too even if they have a fixed size
+		return IndexedHash(topic), nil
+
 	default:
 		return nil, fmt.Errorf("Topic parsing for type %s not supported", t.String())
 	}
@@ -140,7 +200,7 @@ func encodeTopicAddress(val reflect.Value) (res web3.Hash, err error) {
 
 func encodeTopicNum(t *Type, val reflect.Value) (res web3.Hash, err error) {
 	var b []byte
-	b, err = encodeNum(val)
+	b, err = encodeInteger(val, t)
 	if err != nil {
 		return
 	}
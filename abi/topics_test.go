@@ -107,3 +107,104 @@ func TestIntegrationTopics(t *testing.T) {
 		}
 	}
 }
+
+func TestParseLogIndexedDynamic(t *testing.T) {
+	evnt := MustNewEvent("A(string indexed a, bytes indexed b, uint256 c)")
+
+	var hashA web3.Hash
+	copy(hashA[:], KeccakHash([]byte("hello")))
+	hashB := web3.Hash{0x1}
+
+	data, err := MustNewType("uint256").Encode(big.NewInt(1))
+	assert.NoError(t, err)
+
+	log := &web3.Log{
+		Topics: []web3.Hash{evnt.ID(), hashA, hashB},
+		Data:   data,
+	}
+	res, err := evnt.ParseLog(log)
+	assert.NoError(t, err)
+
+	assert.Equal(t, res["a"], IndexedHash(hashA))
+	assert.Equal(t, res["b"].(IndexedHash).Hash(), hashB)
+	assert.Equal(t, res["c"], big.NewInt(1))
+}
+
+func TestParseLogNonIndexedDynamic(t *testing.T) {
+	evnt := MustNewEvent("Foo(string a, address indexed from, bytes b, uint256[] c, string)")
+
+	// the data is the encoding of the non indexed fields as a tuple
+	data, err := MustNewType("tuple(string a, bytes b, uint256[] c, string d)").Encode(map[string]interface{}{
+		"a": "hello",
+		"b": []byte{0x1, 0x2, 0x3},
+		"c": []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)},
+		"d": "world",
+	})
+	assert.NoError(t, err)
+
+	from := web3.Address{0x1}
+	topic, err := EncodeTopic(MustNewType("address"), from)
+	assert.NoError(t, err)
+
+	log := &web3.Log{
+		Topics: []web3.Hash{evnt.ID(), topic},
+		Data:   data,
+	}
+	res, err := evnt.ParseLog(log)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "hello", res["a"])
+	assert.Equal(t, from, res["from"])
+	assert.Equal(t, []byte{0x1, 0x2, 0x3}, res["b"])
+	assert.Equal(t, []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}, res["c"])
+	assert.Equal(t, "world", res["4"])
+
+	// the names of the event are not modified
+	assert.Equal(t, "", evnt.Inputs.TupleElems()[4].Name)
+}
+
+func TestParseLogIndexedArrays(t *testing.T) {
+	evnt := MustNewEvent("A(uint256[2] indexed a, bytes32[] indexed b, tuple(uint256,address) indexed c, uint256 d)")
+
+	data, err := MustNewType("uint256").Encode(big.NewInt(1))
+	assert.NoError(t, err)
+
+	topics := []web3.Hash{{0x1}, {0x2}, {0x3}}
+	log := &web3.Log{
+		Topics: append([]web3.Hash{evnt.ID()}, topics...),
+		Data:   data,
+	}
+	res, err := evnt.ParseLog(log)
+	assert.NoError(t, err)
+
+	assert.Equal(t, IndexedHash(topics[0]), res["a"])
+	assert.Equal(t, IndexedHash(topics[1]), res["b"])
+	assert.Equal(t, IndexedHash(topics[2]), res["c"])
+	assert.Equal(t, big.NewInt(1), res["d"])
+}
+
+func TestParseLogTopicsMismatch(t *testing.T) {
+	evnt := MustNewEvent("Transfer(address indexed from, address indexed to, uint256 value)")
+
+	data, err := MustNewType("uint256").Encode(big.NewInt(1))
+	assert.NoError(t, err)
+
+	cases := [][]web3.Hash{
+		{},
+		{evnt.ID(), {0x1}},
+		// an extra indexed argument
+		{evnt.ID(), {0x1}, {0x2}, {0x3}},
+	}
+	for _, topics := range cases {
+		log := &web3.Log{Topics: topics, Data: data}
+		_, err := ParseLog(evnt.Inputs, log)
+		assert.Error(t, err)
+
+		var out struct{ From, To web3.Address }
+		assert.Error(t, ParseLogInto(evnt.Inputs, log, &out))
+	}
+
+	log := &web3.Log{Topics: []web3.Hash{evnt.ID(), {0x1}, {0x2}, {0x3}}, Data: data}
+	_, err = ParseLog(evnt.Inputs, log)
+	assert.EqualError(t, err, "log has 3 indexed topics but the event has 2 indexed inputs")
+}
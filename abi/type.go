@@ -28,6 +28,7 @@ var (
 	functionT     = reflect.ArrayOf(24, reflect.TypeOf(byte(0)))
 	tupleT        = reflect.TypeOf(map[string]interface{}{})
 	bigIntT       = reflect.TypeOf(new(big.Int))
+	namedValueT   = reflect.TypeOf(NamedValue{})
 )
 
 // Kind represents the kind of abi type
@@ -122,14 +123,75 @@ func (t *Type) DecodeStruct(input []byte, out interface{}) error {
 	return DecodeStruct(t, input, out)
 }
 
+// DecodeInto decodes an object using this type directly into the out param
+func (t *Type) DecodeInto(input []byte, out interface{}) error {
+	return DecodeInto(t, input, out)
+}
+
+// DecodeOrdered decodes the tuple type into a list of named values
+func (t *Type) DecodeOrdered(input []byte) ([]NamedValue, error) {
+	return DecodeOrdered(t, input)
+}
+
+// DecodeToJSON decodes the input into json with the tuple keys in order
+func (t *Type) DecodeToJSON(input []byte) ([]byte, error) {
+	return DecodeToJSON(t, input)
+}
+
 // Encode encodes an object using this type
 func (t *Type) Encode(v interface{}) ([]byte, error) {
 	return Encode(v, t)
 }
 
-// String returns the raw representation of the type
+// String returns the canonical representation of the type. Tuples are
+// reconstructed from the components, i.e. (uint256,address[],(bool,bytes)).
 func (t *Type) String() string {
-	return t.raw
+	switch t.kind {
+	case KindTuple:
+		elems := make([]string, len(t.tuple))
+		for i, elem := range t.tuple {
+			elems[i] = elem.Elem.String()
+		}
+		return fmt.Sprintf("(%s)", strings.Join(elems, ","))
+
+	case KindSlice:
+		return t.elem.String() + "[]"
+
+	case KindArray:
+		return fmt.Sprintf("%s[%d]", t.elem.String(), t.size)
+
+	default:
+		return t.raw
+	}
+}
+
+// Equal returns true if both types have the same structure. The names of
+// the tuple elements are ignored but not if they are indexed.
+func (t *Type) Equal(other *Type) bool {
+	if t == other {
+		return true
+	}
+	if t == nil || other == nil {
+		return false
+	}
+	if t.kind != other.kind || t.size != other.size {
+		return false
+	}
+	switch t.kind {
+	case KindSlice, KindArray:
+		return t.elem.Equal(other.elem)
+
+	case KindTuple:
+		if len(t.tuple) != len(other.tuple) {
+			return false
+		}
+		for i, elem := range t.tuple {
+			if elem.Indexed != other.tuple[i].Indexed || !elem.Elem.Equal(other.tuple[i].Elem) {
+				return false
+			}
+		}
+	}
+	return true
 }
 
 // Elem returns the elem value for slice and arrays
@@ -319,6 +381,11 @@ func readType(l *lexer) (*Type, error) {
 			return nil, err
 		}
 		tt = elem
+
+		// old compilers emit 'address payable' which is encoded as an address
+		if tt.kind == KindAddress && l.peek.typ == strToken && l.peek.literal == "payable" {
+			l.nextToken()
+		}
 	}
 
 	// check for arrays at the end of the type
@@ -360,3 +360,102 @@ func simpleType(s string) *ArgumentStr {
 		Type: s,
 	}
 }
+
+func TestTypeString(t *testing.T) {
+	cases := map[string]string{
+		"uint256": "uint256",
+		"tuple(uint256 a, address[] b, tuple(bool, bytes) c)": "(uint256,address[],(bool,bytes))",
+		"tuple(uint8, tuple(string, int32)[2])[]":             "(uint8,(string,int32)[2])[]",
+	}
+	for str, expected := range cases {
+		if found := MustNewType(str).String(); found != expected {
+			t.Fatalf("expected %s but found %s", expected, found)
+		}
+	}
+
+	// tuples built from abi arguments
+	abi := MustNewABI(`[{"type": "function", "name": "f", "inputs": [
+		{"name": "a", "type": "tuple[]", "components": [{"name": "b", "type": "uint256"}, {"name": "c", "type": "bytes"}]},
+		{"name": "d", "type": "bool"}
+	]}]`)
+	if found := abi.Methods["f"].Inputs.String(); found != "((uint256,bytes)[],bool)" {
+		t.Fatalf("bad inputs %s", found)
+	}
+	if abi.Methods["f"].Sig() != "f((uint256,bytes)[],bool)" {
+		t.Fatal("bad signature")
+	}
+}
+
+func TestTypeEqual(t *testing.T) {
+	equal := [][2]string{
+		{"uint256", "uint256"},
+		{"tuple(uint256 a, address[] b)", "tuple(uint256 c, address[] d)"},
+		{"tuple(uint8, tuple(string, int32)[2])[]", "tuple(uint8 a, tuple(string b, int32 c)[2] d)[]"},
+		{"bytes32[3][]", "bytes32[3][]"},
+	}
+	for _, c := range equal {
+		if !MustNewType(c[0]).Equal(MustNewType(c[1])) {
+			t.Fatalf("expected %s and %s to be equal", c[0], c[1])
+		}
+	}
+
+	notEqual := [][2]string{
+		{"uint256", "uint8"},
+		{"uint256", "int256"},
+		{"bytes", "bytes32"},
+		{"uint256[]", "uint256[2]"},
+		{"uint256[2]", "uint256[3]"},
+		{"tuple(uint256, address)", "tuple(uint256)"},
+		{"tuple(uint256, address)", "tuple(address, uint256)"},
+		{"tuple(uint8, tuple(string, int32)[2])[]", "tuple(uint8, tuple(string, int64)[2])[]"},
+		{"tuple(address indexed a, uint256 b)", "tuple(address a, uint256 b)"},
+	}
+	for _, c := range notEqual {
+		if MustNewType(c[0]).Equal(MustNewType(c[1])) {
+			t.Fatalf("expected %s and %s to be different", c[0], c[1])
+		}
+	}
+
+	// types parsed from the abi and from a signature
+	event := MustNewEvent("Transfer(address indexed from, address indexed to, uint256 value)")
+	abi := MustNewABI(`[{"type": "event", "name": "Transfer", "inputs": [
+		{"name": "src", "type": "address", "indexed": true},
+		{"name": "dst", "type": "address", "indexed": true},
+		{"name": "wad", "type": "uint256"}
+	]}]`)
+	if !event.Inputs.Equal(abi.Events["Transfer"].Inputs) {
+		t.Fatal("expected the same event inputs")
+	}
+}
+
+func TestTypeAddressPayable(t *testing.T) {
+	cases := map[string]string{
+		"address payable":                     "address",
+		"address payable[]":                   "address[]",
+		"tuple(address payable a, uint256 b)": "(address,uint256)",
+		"tuple(address payable indexed a)":    "(address)",
+	}
+	for str, expected := range cases {
+		typ, err := NewType(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found := typ.String(); found != expected {
+			t.Fatalf("expected %s but found %s", expected, found)
+		}
+	}
+	if name := MustNewType("tuple(address payable a, uint256 b)").TupleElems()[0].Name; name != "a" {
+		t.Fatalf("bad name %s", name)
+	}
+
+	abi, err := NewABI(`[{"type": "function", "name": "send", "inputs": [
+		{"name": "to", "type": "address payable"},
+		{"name": "tos", "type": "tuple[]", "components": [{"name": "a", "type": "address payable"}]}
+	]}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig := abi.Methods["send"].Sig(); sig != "send(address,(address)[])" {
+		t.Fatalf("bad signature %s", sig)
+	}
+}
@@ -78,6 +78,13 @@ func encodeArg(str interface{}) string {
 	case abi.KindBytes:
 		return "[]byte"
 
+	case abi.KindSlice, abi.KindArray:
+		// arrays of tuples are decoded as maps and are not supported
+		if arg.Elem.Elem().Kind() != abi.KindTuple {
+			return arg.Elem.GoType().String()
+		}
+		return fmt.Sprintf("input not done for type: %s", arg.Elem.String())
+
 	default:
 		return fmt.Sprintf("input not done for type: %s", arg.Elem.String())
 	}
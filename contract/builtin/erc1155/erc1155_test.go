@@ -0,0 +1,16 @@
+package erc1155
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestERC1155Abi(t *testing.T) {
+	abi := ERC1155Abi()
+	for _, name := range []string{"TransferSingle", "TransferBatch", "ApprovalForAll", "URI"} {
+		_, ok := abi.Events[name]
+		assert.True(t, ok, name)
+	}
+	assert.Equal(t, "TransferBatch(address,address,address,uint256[],uint256[])", abi.Events["TransferBatch"].Sig())
+}
@@ -0,0 +1,123 @@
+package erc20
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	web3 "github.com/boolw/go-web3"
+	"github.com/boolw/go-web3/abi"
+	"github.com/boolw/go-web3/jsonrpc"
+)
+
+var (
+	stringT  = abi.MustNewType("string")
+	uint256T = abi.MustNewType("uint256")
+)
+
+// TokenMetadata is the metadata of an ERC20 token
+type TokenMetadata struct {
+	Address     web3.Address
+	Name        string
+	Symbol      string
+	Decimals    uint8
+	TotalSupply *big.Int
+}
+
+// TokenInfo returns the name, symbol, decimals and total supply of the token.
+// Some old tokens (i.e. MKR or SAI) return the name and symbol as bytes32
+// instead of string, both forms are decoded.
+func TokenInfo(client *jsonrpc.Client, addr web3.Address) (*TokenMetadata, error) {
+	meta := &TokenMetadata{
+		Address: addr,
+	}
+
+	var err error
+	if meta.Name, err = callString(client, addr, "name"); err != nil {
+		return nil, err
+	}
+	if meta.Symbol, err = callString(client, addr, "symbol"); err != nil {
+		return nil, err
+	}
+
+	raw, err := callRaw(client, addr, "decimals")
+	if err != nil {
+		return nil, err
+	}
+	decimals, err := decodeUint256(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode decimals: %v", err)
+	}
+	if !decimals.IsUint64() || decimals.Uint64() > 255 {
+		return nil, fmt.Errorf("decimals out of range: %s", decimals.String())
+	}
+	meta.Decimals = uint8(decimals.Uint64())
+
+	if raw, err = callRaw(client, addr, "totalSupply"); err != nil {
+		return nil, err
+	}
+	if meta.TotalSupply, err = decodeUint256(raw); err != nil {
+		return nil, fmt.Errorf("failed to decode totalSupply: %v", err)
+	}
+	return meta, nil
+}
+
+func callRaw(client *jsonrpc.Client, addr web3.Address, method string) ([]byte, error) {
+	msg := &web3.CallMsg{
+		To:   addr,
+		Data: abiERC20.Methods[method].ID(),
+	}
+	res, err := client.Eth().Call(msg, web3.Latest)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(res, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("method %s returned no data", method)
+	}
+	return raw, nil
+}
+
+func callString(client *jsonrpc.Client, addr web3.Address, method string) (string, error) {
+	raw, err := callRaw(client, addr, method)
+	if err != nil {
+		return "", err
+	}
+	str, err := decodeString(raw)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s: %v", method, err)
+	}
+	return str, nil
+}
+
+// decodeString decodes either an abi encoded string or a bytes32 value
+// padded with zeros on the right.
+func decodeString(raw []byte) (str string, err error) {
+	if len(raw) == 32 {
+		return string(bytes.TrimRight(raw, "\x00")), nil
+	}
+
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("%v", e)
+		}
+	}()
+	val, err := abi.Decode(stringT, raw)
+	if err != nil {
+		return "", err
+	}
+	return val.(string), nil
+}
+
+func decodeUint256(raw []byte) (*big.Int, error) {
+	val, err := abi.Decode(uint256T, raw)
+	if err != nil {
+		return nil, err
+	}
+	return val.(*big.Int), nil
+}
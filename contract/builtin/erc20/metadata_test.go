@@ -0,0 +1,70 @@
+package erc20
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	web3 "github.com/boolw/go-web3"
+	"github.com/boolw/go-web3/abi"
+	"github.com/boolw/go-web3/jsonrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockToken(t *testing.T, name, symbol []byte) *jsonrpc.Client {
+	decimals, _ := abi.Encode(uint8(18), uint256T)
+	totalSupply, _ := abi.Encode(big.NewInt(1000), uint256T)
+
+	results := map[string][]byte{
+		"name":        name,
+		"symbol":      symbol,
+		"decimals":    decimals,
+		"totalSupply": totalSupply,
+	}
+
+	m := jsonrpc.NewMockTransport()
+	m.Handle("eth_call", nil, func(params []interface{}) (interface{}, error) {
+		msg := params[0].(*web3.CallMsg)
+		for name, res := range results {
+			if hex.EncodeToString(msg.Data) == hex.EncodeToString(abiERC20.Methods[name].ID()) {
+				return "0x" + hex.EncodeToString(res), nil
+			}
+		}
+		t.Fatal("unexpected call")
+		return nil, nil
+	})
+	return jsonrpc.NewMockClient(m)
+}
+
+func TestTokenInfo(t *testing.T) {
+	addr := web3.Address{0x1}
+
+	name, _ := abi.Encode("Dai Stablecoin", stringT)
+	symbol, _ := abi.Encode("DAI", stringT)
+
+	info, err := TokenInfo(mockToken(t, name, symbol), addr)
+	assert.NoError(t, err)
+	assert.Equal(t, addr, info.Address)
+	assert.Equal(t, "Dai Stablecoin", info.Name)
+	assert.Equal(t, "DAI", info.Symbol)
+	assert.Equal(t, uint8(18), info.Decimals)
+	assert.Equal(t, 0, info.TotalSupply.Cmp(big.NewInt(1000)))
+}
+
+func TestTokenInfoBytes32(t *testing.T) {
+	// MKR returns the name and symbol as bytes32
+	name := make([]byte, 32)
+	copy(name, "Maker")
+	symbol := make([]byte, 32)
+	copy(symbol, "MKR")
+
+	info, err := TokenInfo(mockToken(t, name, symbol), web3.Address{0x1})
+	assert.NoError(t, err)
+	assert.Equal(t, "Maker", info.Name)
+	assert.Equal(t, "MKR", info.Symbol)
+}
+
+func TestTokenInfoNoData(t *testing.T) {
+	_, err := TokenInfo(mockToken(t, []byte{}, []byte{}), web3.Address{0x1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,18 @@
+package erc721
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestERC721Abi(t *testing.T) {
+	abi := ERC721Abi()
+	for _, name := range []string{"Transfer", "Approval", "ApprovalForAll"} {
+		_, ok := abi.Events[name]
+		assert.True(t, ok, name)
+	}
+	// the overloaded safeTransferFrom is mangled
+	assert.Equal(t, "safeTransferFrom(address,address,uint256)", abi.Methods["safeTransferFrom"].Sig())
+	assert.Equal(t, "safeTransferFrom(address,address,uint256,bytes)", abi.Methods["safeTransferFrom0"].Sig())
+}
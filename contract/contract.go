@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/boolw/go-web3"
 	"github.com/boolw/go-web3/abi"
@@ -239,7 +240,7 @@ func (t *Txn) Do() error {
 	if t.addr != nil {
 		txn.To = t.addr.String()
 	}
-	t.hash, err = t.provider.Eth().SendTransaction(txn)
+	t.hash, err = t.provider.Eth().SendTransaction(txn, jsonrpc.WithPendingNonce())
 	if err != nil {
 		return err
 	}
@@ -253,7 +254,13 @@ func (t *Txn) Validate() error {
 		return nil
 	}
 	if t.isContractDeployment() {
+		// the default constructor is not payable
+		if t.value != nil && t.value.Sign() != 0 && (t.method == nil || !t.method.Payable()) {
+			return fmt.Errorf("constructor is not payable")
+		}
 		t.data = append(t.data, t.bin...)
+	} else if t.value != nil && t.value.Sign() != 0 && t.method != nil && !t.method.Payable() {
+		return fmt.Errorf("method %s is not payable", t.method.Name)
 	}
 	if t.method != nil {
 		data, err := abi.Encode(t.args, t.method.Inputs)
@@ -281,25 +288,33 @@ func (t *Txn) SetGasLimit(gasLimit uint64) *Txn {
 	return t
 }
 
-// Wait waits till the transaction is mined
+var (
+	waitMinBackoff = 100 * time.Millisecond
+	waitMaxBackoff = 5 * time.Second
+)
+
+// Wait waits till the transaction is mined. The receipt is polled with
+// exponential backoff while the transaction is pending.
 func (t *Txn) Wait() error {
 	if (t.hash == web3.Hash{}) {
 		panic("transaction not executed")
 	}
 
-	var err error
+	backoff := waitMinBackoff
 	for {
-		t.receipt, err = t.provider.Eth().GetTransactionReceipt(t.hash)
+		receipt, err := t.provider.Eth().GetTransactionReceipt(t.hash)
 		if err != nil {
-			if err.Error() != "not found" {
-				return err
-			}
+			return err
+		}
+		if receipt != nil {
+			t.receipt = receipt
+			return nil
 		}
-		if t.receipt != nil {
-			break
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > waitMaxBackoff {
+			backoff = waitMaxBackoff
 		}
 	}
-	return nil
 }
 
 // Receipt returns the receipt of the transaction after wait
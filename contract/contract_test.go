@@ -2,8 +2,12 @@ package contract
 
 import (
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"math/big"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/boolw/go-web3"
@@ -76,3 +80,59 @@ func TestDeployContract(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, resp["0"], big.NewInt(1000))
 }
+
+func TestDeployContractPayable(t *testing.T) {
+	bin := []byte{0x1}
+
+	abi0 := abi.MustNewABI(`[{"type": "constructor", "inputs": [], "stateMutability": "payable"}]`)
+	assert.NoError(t, DeployContract(nil, addr0B, abi0, bin).SetValue(big.NewInt(1)).Validate())
+
+	abi1 := abi.MustNewABI(`[{"type": "constructor", "inputs": [], "stateMutability": "nonpayable"}]`)
+	assert.Error(t, DeployContract(nil, addr0B, abi1, bin).SetValue(big.NewInt(1)).Validate())
+
+	// no constructor
+	abi2 := abi.MustNewABI(`[]`)
+	assert.Error(t, DeployContract(nil, addr0B, abi2, bin).SetValue(big.NewInt(1)).Validate())
+	assert.NoError(t, DeployContract(nil, addr0B, abi2, bin).Validate())
+}
+
+func TestTxnWaitPending(t *testing.T) {
+	waitMinBackoff = 1 * time.Millisecond
+	defer func() {
+		waitMinBackoff = 100 * time.Millisecond
+	}()
+
+	hash := web3.Hash{0x1}
+	receipt := json.RawMessage(`{
+		"transactionHash": "` + hash.String() + `",
+		"transactionIndex": "0x0",
+		"blockHash": "` + web3.Hash{0xa}.String() + `",
+		"blockNumber": "0x1",
+		"from": "` + web3.Address{0x1}.String() + `",
+		"gasUsed": "0x5208",
+		"cumulativeGasUsed": "0x5208",
+		"status": "0x1",
+		"logsBloom": "0x` + strings.Repeat("00", 256) + `",
+		"logs": []
+	}`)
+
+	// the transaction is pending in the first two polls
+	m := jsonrpc.NewMockTransport()
+	m.Handle("eth_getTransactionReceipt", nil, func(params []interface{}) (interface{}, error) {
+		if m.Calls("eth_getTransactionReceipt") <= 2 {
+			return nil, nil
+		}
+		return receipt, nil
+	})
+
+	txn := &Txn{hash: hash, provider: jsonrpc.NewMockClient(m)}
+	assert.NoError(t, txn.Wait())
+	assert.Equal(t, 3, m.Calls("eth_getTransactionReceipt"))
+	assert.Equal(t, hash, txn.Receipt().TransactionHash)
+	assert.Equal(t, uint64(1), txn.Receipt().BlockNumber)
+
+	// the errors are returned as they are
+	m.RespondError("eth_getTransactionReceipt", nil, fmt.Errorf("failed"))
+	txn = &Txn{hash: hash, provider: jsonrpc.NewMockClient(m)}
+	assert.EqualError(t, txn.Wait(), "failed")
+}
@@ -0,0 +1,117 @@
+package contract
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/boolw/go-web3"
+	"github.com/boolw/go-web3/abi"
+)
+
+// Subscription is a subscription to the events of a contract
+type Subscription struct {
+	cancel func() error
+	errCh  chan error
+	ch     chan<- *abi.DecodedLog
+
+	// closeCh stops the pending sends on Unsubscribe and the lock waits
+	// for them to finish before errCh is closed
+	lock      sync.RWMutex
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+// Err returns the errors decoding the logs of the subscription. Only the
+// last error is kept if they are not read. The channel is closed after
+// Unsubscribe.
+func (s *Subscription) Err() <-chan error {
+	return s.errCh
+}
+
+// Unsubscribe stops the subscription. The channel of the logs is not closed
+// since it can be shared by several subscriptions.
+func (s *Subscription) Unsubscribe() error {
+	err := fmt.Errorf("subscription already closed")
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+		err = s.cancel()
+
+		s.lock.Lock()
+		close(s.errCh)
+		s.lock.Unlock()
+	})
+	return err
+}
+
+func (s *Subscription) send(log *abi.DecodedLog) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	select {
+	case <-s.closeCh:
+		return
+	default:
+	}
+	select {
+	case s.ch <- log:
+	case <-s.closeCh:
+	}
+}
+
+func (s *Subscription) sendErr(err error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	select {
+	case <-s.closeCh:
+		// errCh is closed or about to be closed
+		return
+	default:
+	}
+	select {
+	case s.errCh <- err:
+	default:
+	}
+}
+
+// WatchEvent subscribes to the logs of the event emitted by the contract and
+// sends them decoded to the channel. The logs removed by a reorg are also sent
+// with Log.Removed set. It requires a transport with subscriptions (i.e. websocket).
+func (c *Contract) WatchEvent(event *abi.Event, ch chan<- *abi.DecodedLog) (*Subscription, error) {
+	if event.Anonymous {
+		return nil, fmt.Errorf("cannot watch the anonymous event %s", event.Name)
+	}
+
+	filter := &web3.LogFilter{
+		Address: []web3.Address{c.addr},
+		Topics:  [][]web3.Hash{{event.ID()}},
+	}
+
+	sub := &Subscription{
+		errCh:   make(chan error, 1),
+		ch:      ch,
+		closeCh: make(chan struct{}),
+	}
+	cancel, err := c.provider.Subscribe("logs", func(b []byte) {
+		log := new(web3.Log)
+		if err := log.UnmarshalJSON(b); err != nil {
+			sub.sendErr(err)
+			return
+		}
+		args, err := event.ParseLog(log)
+		if err != nil {
+			sub.sendErr(fmt.Errorf("failed to decode log %d of block %d: %v", log.LogIndex, log.BlockNumber, err))
+			return
+		}
+		sub.send(&abi.DecodedLog{
+			Log:   log,
+			Event: event,
+			Args:  args,
+		})
+	}, filter)
+	if err != nil {
+		return nil, err
+	}
+	sub.cancel = cancel
+	return sub, nil
+}
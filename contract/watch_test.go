@@ -0,0 +1,104 @@
+package contract
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/boolw/go-web3"
+	"github.com/boolw/go-web3/abi"
+	"github.com/boolw/go-web3/jsonrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContractWatchEvent(t *testing.T) {
+	a := abi.MustNewABI(`[{
+		"type": "event",
+		"name": "Transfer",
+		"inputs": [
+			{"name": "from", "type": "address", "indexed": true},
+			{"name": "to", "type": "address", "indexed": true},
+			{"name": "value", "type": "uint256", "indexed": false}
+		]
+	}]`)
+	event := a.Events["Transfer"]
+	addr := web3.Address{0x1}
+
+	m := jsonrpc.NewMockTransport()
+	c := NewContract(addr, a, jsonrpc.NewMockClient(m))
+
+	ch := make(chan *abi.DecodedLog, 1)
+	sub, err := c.WatchEvent(event, ch)
+	assert.NoError(t, err)
+
+	// the filter has the address and the id of the event
+	req := m.Requests()[0]
+	assert.Equal(t, "eth_subscribe", req.Method)
+	assert.Equal(t, "logs", req.Params[0])
+	filter := req.Params[1].(*web3.LogFilter)
+	assert.Equal(t, []web3.Address{addr}, filter.Address)
+	assert.Equal(t, [][]web3.Hash{{event.ID()}}, filter.Topics)
+
+	from, _ := abi.EncodeTopic(abi.MustNewType("address"), web3.Address{0x2})
+	to, _ := abi.EncodeTopic(abi.MustNewType("address"), web3.Address{0x3})
+	data, _ := abi.Encode(big.NewInt(10), abi.MustNewType("uint256"))
+
+	log := &web3.Log{
+		Address:     addr,
+		BlockNumber: 5,
+		Topics:      []web3.Hash{event.ID(), from, to},
+		Data:        data,
+	}
+	assert.NoError(t, m.Notify("logs", log))
+
+	select {
+	case decoded := <-ch:
+		assert.Equal(t, event, decoded.Event)
+		assert.Equal(t, uint64(5), decoded.Log.BlockNumber)
+		assert.Equal(t, web3.Address{0x2}, decoded.Args["from"])
+		assert.Equal(t, big.NewInt(10), decoded.Args["value"])
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+
+	// a log that cannot be decoded is reported as an error
+	log.Data = []byte{0x1}
+	assert.NoError(t, m.Notify("logs", log))
+	assert.Error(t, <-sub.Err())
+
+	// a send blocked on a full channel does not block Unsubscribe
+	log.Data = data
+	assert.NoError(t, m.Notify("logs", log))
+
+	notified := make(chan struct{})
+	go func() {
+		m.Notify("logs", log)
+		close(notified)
+	}()
+
+	assert.NoError(t, sub.Unsubscribe())
+	assert.Error(t, sub.Unsubscribe())
+
+	select {
+	case <-notified:
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+
+	// the end of the subscription is signaled with Err
+	_, ok := <-sub.Err()
+	assert.False(t, ok)
+
+	// the channel is not closed and can be used by another subscription
+	<-ch
+	other, err := c.WatchEvent(event, ch)
+	assert.NoError(t, err)
+	assert.NoError(t, m.Notify("logs", log))
+	select {
+	case decoded := <-ch:
+		assert.Equal(t, big.NewInt(10), decoded.Args["value"])
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+	assert.NoError(t, other.Unsubscribe())
+}
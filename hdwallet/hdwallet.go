@@ -0,0 +1,153 @@
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/boolw/go-web3"
+	"github.com/boolw/go-web3/secp256k1"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// DefaultPath is the BIP-44 derivation path of the first ethereum account.
+// It is the one used by MetaMask and Hardhat.
+const DefaultPath = "m/44'/60'/0'/0/0"
+
+// HardenedOffset is the first index of the hardened child keys
+const HardenedOffset = 0x80000000
+
+// FromMnemonic derives the key and address at the given path (DefaultPath
+// if empty) from a BIP-39 mnemonic without passphrase.
+func FromMnemonic(phrase, path string) (*ecdsa.PrivateKey, web3.Address, error) {
+	return FromMnemonicWithPassphrase(phrase, "", path)
+}
+
+// FromMnemonicWithPassphrase derives the key and address at the given path
+// (DefaultPath if empty) from a BIP-39 mnemonic and a passphrase.
+func FromMnemonicWithPassphrase(phrase, passphrase, path string) (*ecdsa.PrivateKey, web3.Address, error) {
+	seed, err := NewSeed(phrase, passphrase)
+	if err != nil {
+		return nil, web3.Address{}, err
+	}
+	return FromSeed(seed, path)
+}
+
+// NewSeed returns the BIP-39 seed of the mnemonic. The words must be in the
+// english wordlist and the checksum must match, so that a mistyped mnemonic
+// does not derive another wallet.
+func NewSeed(phrase, passphrase string) ([]byte, error) {
+	words := strings.Fields(phrase)
+	if len(words) < 12 || len(words)%3 != 0 || len(words) > 24 {
+		return nil, fmt.Errorf("invalid mnemonic, expected 12, 15, 18, 21 or 24 words but found %d", len(words))
+	}
+	phrase = strings.Join(words, " ")
+	if _, err := bip39.EntropyFromMnemonic(phrase); err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %v", err)
+	}
+	return pbkdf2.Key([]byte(phrase), []byte("mnemonic"+passphrase), 2048, 64, sha512.New), nil
+}
+
+// FromSeed derives the key and address at the given path (DefaultPath if empty)
+// from a BIP-32 seed.
+func FromSeed(seed []byte, path string) (*ecdsa.PrivateKey, web3.Address, error) {
+	if path == "" {
+		path = DefaultPath
+	}
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return nil, web3.Address{}, err
+	}
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key, chainCode := sum[:32], sum[32:]
+	for _, index := range indexes {
+		if key, chainCode, err = deriveChild(key, chainCode, index); err != nil {
+			return nil, web3.Address{}, err
+		}
+	}
+
+	priv, err := secp256k1.ToECDSA(key)
+	if err != nil {
+		return nil, web3.Address{}, err
+	}
+	return priv, pubkeyToAddress(&priv.PublicKey), nil
+}
+
+// ParsePath parses a derivation path (i.e. m/44'/60'/0'/0/0) into the child
+// indexes. Hardened indexes are marked with ', h or H.
+func ParsePath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("invalid path '%s', it must start with 'm'", path)
+	}
+
+	indexes := []uint32{}
+	for _, part := range parts[1:] {
+		var offset uint32
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") || strings.HasSuffix(part, "H") {
+			part = part[:len(part)-1]
+			offset = HardenedOffset
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || index >= HardenedOffset {
+			return nil, fmt.Errorf("invalid index '%s' in path '%s'", part, path)
+		}
+		indexes = append(indexes, uint32(index)+offset)
+	}
+	return indexes, nil
+}
+
+// deriveChild returns the child private key and chain code (BIP-32 CKDpriv)
+func deriveChild(key, chainCode []byte, index uint32) ([]byte, []byte, error) {
+	var data []byte
+	if index >= HardenedOffset {
+		data = append([]byte{0x0}, key...)
+	} else {
+		priv, err := secp256k1.ToECDSA(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = secp256k1.CompressPubkey(&priv.PublicKey)
+	}
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], index)
+	data = append(data, buf[:]...)
+
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := secp256k1.S256().Params().N
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, nil, fmt.Errorf("invalid child key at index %d", index)
+	}
+	child := il.Add(il, new(big.Int).SetBytes(key))
+	child.Mod(child, n)
+	if child.Sign() == 0 {
+		return nil, nil, fmt.Errorf("invalid child key at index %d", index)
+	}
+
+	childKey := make([]byte, 32)
+	b := child.Bytes()
+	copy(childKey[32-len(b):], b)
+	return childKey, sum[32:], nil
+}
+
+func pubkeyToAddress(pub *ecdsa.PublicKey) web3.Address {
+	hash := web3.Keccak256(secp256k1.MarshalPubkey(pub)[1:])
+
+	var addr web3.Address
+	copy(addr[:], hash[12:])
+	return addr
+}
@@ -0,0 +1,110 @@
+package hdwallet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/boolw/go-web3"
+	"github.com/boolw/go-web3/secp256k1"
+)
+
+const hardhatMnemonic = "test test test test test test test test test test test junk"
+
+func TestFromMnemonic(t *testing.T) {
+	cases := []struct {
+		path string
+		key  string
+		addr string
+	}{
+		{
+			"",
+			"ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80",
+			"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+		},
+		{
+			"m/44'/60'/0'/0/1",
+			"59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d",
+			"0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+		},
+	}
+
+	for _, c := range cases {
+		key, addr, err := FromMnemonic(hardhatMnemonic, c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(secp256k1.FromECDSA(key)) != c.key {
+			t.Fatalf("bad key for path '%s'", c.path)
+		}
+		if addr != web3.HexToAddress(c.addr) {
+			t.Fatalf("bad address %s for path '%s'", addr.String(), c.path)
+		}
+	}
+
+	// the passphrase changes the seed
+	_, addr, err := FromMnemonicWithPassphrase(hardhatMnemonic, "passphrase", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr == web3.HexToAddress(cases[0].addr) {
+		t.Fatal("passphrase not used")
+	}
+
+	if _, _, err := FromMnemonic("test test", ""); err == nil {
+		t.Fatal("it should fail with a short mnemonic")
+	}
+
+	// the words are in the wordlist but the checksum does not match
+	badChecksum := "test test test test test test test test test test test test"
+	if _, _, err := FromMnemonic(badChecksum, ""); err == nil {
+		t.Fatal("it should fail with a bad checksum")
+	}
+
+	// a mistyped word is not in the wordlist
+	mistyped := "test test test test test test test test test test test jnuk"
+	if _, _, err := FromMnemonic(mistyped, ""); err == nil {
+		t.Fatal("it should fail with an unknown word")
+	}
+}
+
+func TestFromSeedBIP32(t *testing.T) {
+	// test vector 1 of BIP-32
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	cases := []struct {
+		path string
+		key  string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0H/1/2H/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+	for _, c := range cases {
+		key, _, err := FromSeed(seed, c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(secp256k1.FromECDSA(key)) != c.key {
+			t.Fatalf("bad key for path '%s'", c.path)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	indexes, err := ParsePath(DefaultPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []uint32{HardenedOffset + 44, HardenedOffset + 60, HardenedOffset, 0, 0}
+	for i := range expected {
+		if indexes[i] != expected[i] {
+			t.Fatal("bad index")
+		}
+	}
+
+	for _, path := range []string{"44'/60'", "m/a", "m/2147483648", "m//0"} {
+		if _, err := ParsePath(path); err == nil {
+			t.Fatalf("path '%s' should fail", path)
+		}
+	}
+}
@@ -1,13 +1,124 @@
 package jsonrpc
 
 import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/boolw/go-web3"
 	"github.com/boolw/go-web3/jsonrpc/transport"
 )
 
 // Client is the jsonrpc client
 type Client struct {
-	transport transport.Transport
-	endpoints endpoints
+	transport  transport.Transport
+	endpoints  endpoints
+	idGen      transport.IDGenerator
+	httpClient *http.Client
+	logger     CallLogger
+	keepAlive  time.Duration
+	limiter    *rateLimiter
+
+	// call is the chain of interceptors around the transport
+	interceptors []Interceptor
+	call         CallFunc
+
+	// dial creates a new transport to reconnect. It is only
+	// set if the client is created from an address.
+	dial   func() (transport.Transport, error)
+	lock   sync.Mutex
+	closed bool
+
+	// chainID is the cached chain id of the node
+	chainIDLock sync.Mutex
+	chainID     *big.Int
+
+	// head is the cached latest block of Eth.LatestBlock
+	headLock sync.Mutex
+	head     *web3.Block
+
+	// eip1559 is the cached result of Eth.SupportsEIP1559
+	eip1559Lock sync.Mutex
+	eip1559     *bool
+
+	// gasOracle suggests the fees of the transactions. It is shared
+	// so that its cache of the fee history is reused.
+	gasOracleLock sync.Mutex
+	gasOracle     *GasOracle
+}
+
+// ClientOption is an option to configure the client
+type ClientOption func(*Client)
+
+// WithIDGenerator sets the function that generates the ids of the requests.
+// The ids must be strings or integers and unique among the in-flight requests.
+// By default the ids are incrementing integers.
+func WithIDGenerator(gen func() interface{}) ClientOption {
+	return func(c *Client) {
+		c.idGen = gen
+	}
+}
+
+// WithHTTPClient sets the client of the http transport instead of the default
+// one, which is shared by all the clients and keeps the connections alive.
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// CallLogger is called after each jsonrpc call with the duration of the call
+// and its error, if any.
+type CallLogger func(method string, params []interface{}, duration time.Duration, err error)
+
+// WithLogger sets a function that is called after each jsonrpc call (i.e. to record
+// the latency and the errors of the calls). The requests of a batch are logged one
+// by one with the duration of the whole batch.
+func WithLogger(logger CallLogger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithKeepAlive sends a ping every interval on the persistent connections
+// (i.e. websocket) and closes the connection if the node does not answer in
+// two intervals, which triggers the reconnection of the subscriptions. It
+// keeps alive the idle connections behind load balancers.
+func WithKeepAlive(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.keepAlive = interval
+	}
+}
+
+// WithRateLimit limits the requests sent by the client to rps requests per
+// second with bursts of up to burst requests. The calls wait until they can
+// be sent and each request of a batch counts as one request.
+func WithRateLimit(rps int, burst int) ClientOption {
+	return func(c *Client) {
+		if rps > 0 {
+			c.limiter = newRateLimiter(rps, burst)
+		}
+	}
+}
+
+// CallFunc makes a jsonrpc call and decodes the result into out
+type CallFunc func(method string, out interface{}, params ...interface{}) error
+
+// Interceptor wraps the jsonrpc calls of the client. It can run code before
+// and after calling next or return without calling it (i.e. on a cache hit).
+type Interceptor func(next CallFunc) CallFunc
+
+// WithInterceptor adds an interceptor to the calls of the client (i.e. for
+// metrics, caching or retries). The first interceptor added is the outermost
+// one. The interceptors run before the rate limit and the logger and they
+// do not wrap the batch calls.
+func WithInterceptor(interceptor Interceptor) ClientOption {
+	return func(c *Client) {
+		c.interceptors = append(c.interceptors, interceptor)
+	}
 }
 
 type endpoints struct {
@@ -17,33 +128,268 @@ type endpoints struct {
 }
 
 // NewClient creates a new client
-func NewClient(addr string) (*Client, error) {
+func NewClient(addr string, opts ...ClientOption) (*Client, error) {
+	t, err := transport.NewTransport(addr)
+	if err != nil {
+		return nil, err
+	}
+	c := NewClientWithTransport(t, opts...)
+	c.dial = func() (transport.Transport, error) {
+		return transport.NewTransport(addr)
+	}
+	return c, nil
+}
+
+// NewClientWithTransport creates a new client that uses the given transport
+func NewClientWithTransport(t transport.Transport, opts ...ClientOption) *Client {
 	c := &Client{}
 	c.endpoints.w = &Web3{c}
 	c.endpoints.e = &Eth{c}
 	c.endpoints.n = &Net{c}
 
-	t, err := transport.NewTransport(addr)
-	if err != nil {
-		return nil, err
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.call = c.buildCall()
+	c.setTransport(t)
+	return c
+}
+
+// buildCall chains the interceptors around the rate limit, the logger
+// and the current transport
+func (c *Client) buildCall() CallFunc {
+	call := func(method string, out interface{}, params ...interface{}) error {
+		return c.getTransport().Call(method, out, params...)
+	}
+	if c.logger != nil {
+		next := call
+		call = func(method string, out interface{}, params ...interface{}) error {
+			now := time.Now()
+			err := next(method, out, params...)
+			c.logger(method, params, time.Since(now), err)
+			return err
+		}
+	}
+	if c.limiter != nil {
+		next := call
+		call = func(method string, out interface{}, params ...interface{}) error {
+			c.limiter.wait(1)
+			return next(method, out, params...)
+		}
+	}
+	res := CallFunc(call)
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		res = c.interceptors[i](res)
+	}
+	return res
+}
+
+func (c *Client) setTransport(t transport.Transport) {
+	if c.idGen != nil {
+		if s, ok := t.(transport.IDGeneratorSetter); ok {
+			s.SetIDGenerator(c.idGen)
+		}
+	}
+	if c.httpClient != nil {
+		if s, ok := t.(transport.HTTPClientSetter); ok {
+			s.SetHTTPClient(c.httpClient)
+		}
+	}
+	if c.keepAlive > 0 {
+		if s, ok := t.(transport.KeepAliveSetter); ok {
+			s.SetKeepAlive(c.keepAlive)
+		}
 	}
 	c.transport = t
-	return c, nil
 }
 
 // Close closes the tranport
 func (c *Client) Close() error {
-	return c.transport.Close()
+	c.lock.Lock()
+	c.closed = true
+	c.lock.Unlock()
+
+	return c.getTransport().Close()
+}
+
+// getTransport returns the current transport, it changes when the client reconnects
+func (c *Client) getTransport() transport.Transport {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.transport
+}
+
+// reconnect replaces the transport with a new connection if it is still
+// the broken one. The transport is shared by all the subscriptions of the
+// client and only the first one to notice the disconnect dials again.
+func (c *Client) reconnect(old transport.Transport) (transport.Transport, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return nil, fmt.Errorf("client closed")
+	}
+	if c.transport != old {
+		return c.transport, nil
+	}
+	if c.dial == nil {
+		return nil, fmt.Errorf("client cannot reconnect, it was not created from an address")
+	}
+	t, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	old.Close()
+	c.setTransport(t)
+	return t, nil
 }
 
 // Call makes a jsonrpc call
 func (c *Client) Call(method string, out interface{}, params ...interface{}) error {
-	return c.transport.Call(method, out, params...)
+	return c.call(method, out, params...)
+}
+
+// CallRaw makes a jsonrpc call and returns the result without decoding it.
+// A null result is returned as the raw "null" value.
+func (c *Client) CallRaw(method string, params ...interface{}) (json.RawMessage, error) {
+	var out json.RawMessage
+	if err := c.Call(method, &out, params...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BatchElem is a request in a batch call
+type BatchElem = transport.BatchElem
+
+// BatchCall makes a batch of jsonrpc calls. If the transport does not
+// support batches the calls are made one by one. The error of each
+// individual call is set on its element.
+func (c *Client) BatchCall(batch []*BatchElem) error {
+	if c.limiter != nil && len(batch) > 0 {
+		c.limiter.wait(len(batch))
+	}
+	now := time.Now()
+	err := c.batchCall(batch)
+	if c.logger != nil {
+		duration := time.Since(now)
+		for _, elem := range batch {
+			elemErr := elem.Error
+			if err != nil {
+				elemErr = err
+			}
+			c.logger(elem.Method, elem.Params, duration, elemErr)
+		}
+	}
+	return err
+}
+
+func (c *Client) batchCall(batch []*BatchElem) error {
+	t := c.getTransport()
+	if b, ok := t.(transport.BatchTransport); ok {
+		return b.BatchCall(batch)
+	}
+	for _, elem := range batch {
+		elem.Error = t.Call(elem.Method, elem.Result, elem.Params...)
+	}
+	return nil
+}
+
+// BatchErrors are the errors of a batch call aligned to the requests.
+// The error is nil for the requests that succeeded.
+type BatchErrors []error
+
+// Error implements the error interface
+func (b BatchErrors) Error() string {
+	count := 0
+	var first error
+	for _, err := range b {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			count++
+		}
+	}
+	return fmt.Sprintf("%d of %d batch requests failed: %v", count, len(b), first)
+}
+
+func batchErrors(batch []*BatchElem) error {
+	errs := make(BatchErrors, len(batch))
+	failed := false
+	for indx, elem := range batch {
+		if elem.Error != nil {
+			errs[indx] = elem.Error
+			failed = true
+		}
+	}
+	if !failed {
+		return nil
+	}
+	return errs
 }
 
 func (c *Client) SetTransport(trans transport.Transport)  {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
 	if c.transport != nil {
 		c.transport.Close()
 	}
-	c.transport = trans
+	c.setTransport(trans)
+
+	// the new endpoint can be a different chain
+	c.ResetChainID()
+
+	c.headLock.Lock()
+	c.head = nil
+	c.headLock.Unlock()
+
+	c.eip1559Lock.Lock()
+	c.eip1559 = nil
+	c.eip1559Lock.Unlock()
+
+	c.gasOracleLock.Lock()
+	c.gasOracle = nil
+	c.gasOracleLock.Unlock()
+}
+
+// getGasOracle returns the gas oracle of the client, it is created the
+// first time it is used
+func (c *Client) getGasOracle() *GasOracle {
+	c.gasOracleLock.Lock()
+	defer c.gasOracleLock.Unlock()
+
+	if c.gasOracle == nil {
+		c.gasOracle = NewGasOracle(c)
+	}
+	return c.gasOracle
+}
+
+// ChainID returns the chain id of the node. It is only requested the first
+// time since it does not change for an endpoint.
+func (c *Client) ChainID() (*big.Int, error) {
+	c.chainIDLock.Lock()
+	defer c.chainIDLock.Unlock()
+
+	if c.chainID == nil {
+		var out quantity
+		if err := c.Call("eth_chainId", &out); err != nil {
+			return nil, err
+		}
+		chainID, err := parseBigInt(string(out))
+		if err != nil {
+			return nil, err
+		}
+		c.chainID = chainID
+	}
+	return new(big.Int).Set(c.chainID), nil
+}
+
+// ResetChainID clears the cached chain id so that the next call to ChainID
+// requests it again to the node (i.e. after switching endpoints).
+func (c *Client) ResetChainID() {
+	c.chainIDLock.Lock()
+	c.chainID = nil
+	c.chainIDLock.Unlock()
 }
@@ -0,0 +1,221 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/boolw/go-web3"
+	"github.com/boolw/go-web3/jsonrpc/codec"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientCallRaw(t *testing.T) {
+	m := NewMockTransport()
+	m.Respond("eth_syncing", nil, json.RawMessage(`{"startingBlock": "0x1", "currentBlock": "0x2"}`))
+	m.Respond("eth_getTransactionByHash", nil, nil)
+	m.RespondError("eth_chainId", nil, fmt.Errorf("failed"))
+
+	c := NewMockClient(m)
+
+	raw, err := c.CallRaw("eth_syncing")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"startingBlock": "0x1", "currentBlock": "0x2"}`, string(raw))
+
+	raw, err = c.CallRaw("eth_getTransactionByHash", "0x1")
+	assert.NoError(t, err)
+	assert.Equal(t, "null", string(raw))
+
+	_, err = c.CallRaw("eth_chainId")
+	assert.Error(t, err)
+}
+
+func TestClientChainIDCache(t *testing.T) {
+	m := NewMockTransport()
+	m.RespondError("eth_chainId", nil, fmt.Errorf("failed"))
+	c := NewMockClient(m)
+
+	// errors are not cached
+	_, err := c.ChainID()
+	assert.Error(t, err)
+
+	m.Respond("eth_chainId", nil, "0x1")
+	for i := 0; i < 3; i++ {
+		chainID, err := c.Eth().ChainID()
+		assert.NoError(t, err)
+		assert.Equal(t, big.NewInt(1), chainID)
+
+		// the cached value cannot be modified
+		chainID.SetUint64(100)
+	}
+	assert.Equal(t, 2, m.Calls("eth_chainId"))
+
+	// the cache is cleared
+	m.Respond("eth_chainId", nil, "0x5")
+	c.ResetChainID()
+
+	chainID, err := c.ChainID()
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(5), chainID)
+
+	// a new endpoint clears the cache
+	m2 := NewMockTransport()
+	m2.Respond("eth_chainId", nil, "0x89")
+	c.SetTransport(m2)
+
+	chainID, err = c.ChainID()
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(0x89), chainID)
+}
+
+func TestClientWithLogger(t *testing.T) {
+	type entry struct {
+		method string
+		params []interface{}
+		err    error
+	}
+	var entries []entry
+
+	m := NewMockTransport()
+	m.Respond("eth_blockNumber", nil, "0x1")
+	m.RespondError("eth_gasPrice", nil, fmt.Errorf("failed"))
+	m.Respond("eth_getBalance", nil, "0x10")
+
+	c := NewMockClient(m, WithLogger(func(method string, params []interface{}, duration time.Duration, err error) {
+		assert.True(t, duration >= 0)
+		entries = append(entries, entry{method, params, err})
+	}))
+
+	_, err := c.Eth().BlockNumber()
+	assert.NoError(t, err)
+
+	_, err = c.Eth().GasPrice()
+	assert.Error(t, err)
+
+	_, err = c.Eth().GetBalances([]web3.Address{{0x1}, {0x2}}, web3.Latest)
+	assert.NoError(t, err)
+
+	assert.Len(t, entries, 4)
+	assert.Equal(t, "eth_blockNumber", entries[0].method)
+	assert.NoError(t, entries[0].err)
+	assert.Equal(t, "eth_gasPrice", entries[1].method)
+	assert.Error(t, entries[1].err)
+
+	// each request of the batch
+	assert.Equal(t, "eth_getBalance", entries[2].method)
+	assert.Equal(t, []interface{}{web3.Address{0x2}, "latest"}, entries[3].params)
+}
+
+type countTransport struct {
+	count int32
+}
+
+func (c *countTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.count, 1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientWithHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+
+		var req codec.Request
+		if err := json.Unmarshal(data, &req); err != nil {
+			t.Fatal(err)
+		}
+		raw, _ := json.Marshal(&codec.Response{ID: req.ID, Result: json.RawMessage(`"0x10"`)})
+		w.Write(raw)
+	}))
+	defer srv.Close()
+
+	tr := &countTransport{}
+	c, err := NewClient(srv.URL, WithHTTPClient(&http.Client{Transport: tr}))
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		num, err := c.Eth().BlockNumber()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(16), num)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&tr.count))
+}
+
+func TestClientWithRateLimit(t *testing.T) {
+	m := NewMockTransport()
+	m.Respond("eth_blockNumber", nil, "0x1")
+	m.Respond("eth_getBalance", nil, "0x10")
+
+	c := NewMockClient(m, WithRateLimit(50, 1))
+
+	// the first request uses the burst and the others wait 20ms each
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		_, err := c.Eth().BlockNumber()
+		assert.NoError(t, err)
+	}
+	assert.True(t, time.Since(now) >= 50*time.Millisecond)
+
+	// each request of the batch counts
+	now = time.Now()
+	_, err := c.Eth().GetBalances([]web3.Address{{0x1}, {0x2}, {0x3}}, web3.Latest)
+	assert.NoError(t, err)
+	assert.True(t, time.Since(now) >= 50*time.Millisecond)
+}
+
+func TestClientWithInterceptor(t *testing.T) {
+	var order []string
+	trace := func(name string) Interceptor {
+		return func(next CallFunc) CallFunc {
+			return func(method string, out interface{}, params ...interface{}) error {
+				order = append(order, name+":"+method)
+				return next(method, out, params...)
+			}
+		}
+	}
+
+	// cache of the chain id that does not reach the transport on a hit
+	var cached *string
+	cache := func(next CallFunc) CallFunc {
+		return func(method string, out interface{}, params ...interface{}) error {
+			if method != "eth_chainId" {
+				return next(method, out, params...)
+			}
+			if cached != nil {
+				*(out.(*string)) = *cached
+				return nil
+			}
+			var res string
+			if err := next(method, &res, params...); err != nil {
+				return err
+			}
+			cached = &res
+			*(out.(*string)) = res
+			return nil
+		}
+	}
+
+	m := NewMockTransport()
+	m.Respond("eth_blockNumber", nil, "0x1")
+	m.Respond("eth_chainId", nil, "0x5")
+
+	c := NewMockClient(m, WithInterceptor(trace("a")), WithInterceptor(trace("b")), WithInterceptor(cache))
+
+	num, err := c.Eth().BlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), num)
+	assert.Equal(t, []string{"a:eth_blockNumber", "b:eth_blockNumber"}, order)
+
+	for i := 0; i < 2; i++ {
+		var out string
+		assert.NoError(t, c.Call("eth_chainId", &out))
+		assert.Equal(t, "0x5", out)
+	}
+	assert.Equal(t, 1, m.Calls("eth_chainId"))
+}
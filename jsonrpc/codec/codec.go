@@ -1,13 +1,14 @@
 package codec
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 )
 
 // Request is a jsonrpc request
 type Request struct {
-	ID      uint64          `json:"id"`
+	ID      json.RawMessage `json:"id"`
 	Method  string          `json:"method"`
 	Params  json.RawMessage `json:"params"`
 	Jsonrpc string          `json:"jsonrpc"`
@@ -15,11 +16,37 @@ type Request struct {
 
 // Response is a jsonrpc response
 type Response struct {
-	ID     uint64          `json:"id"`
+	ID     json.RawMessage `json:"id"`
 	Result json.RawMessage `json:"result"`
 	Error  *ErrorObject    `json:"error,omitempty"`
 }
 
+// NewID encodes the id of a request. The id can be a number or a string.
+func NewID(id interface{}) (json.RawMessage, error) {
+	switch id.(type) {
+	case string, int, int32, int64, uint, uint32, uint64:
+	default:
+		return nil, fmt.Errorf("request id must be a string or an integer but found %T", id)
+	}
+	return json.Marshal(id)
+}
+
+// IDKey returns the key used to match a response with its request
+func IDKey(id json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, id); err != nil {
+		return string(id)
+	}
+	return buf.String()
+}
+
+// HasID returns true if the response has an id. Subscription
+// notifications and some errors do not have one.
+func (r *Response) HasID() bool {
+	key := IDKey(r.ID)
+	return key != "" && key != "null"
+}
+
 // ErrorObject is a jsonrpc error
 type ErrorObject struct {
 	Code    int         `json:"code"`
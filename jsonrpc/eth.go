@@ -2,10 +2,12 @@ package jsonrpc
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math/big"
 
 	"github.com/boolw/go-web3"
+	"github.com/boolw/go-web3/abi"
 )
 
 // Eth is the eth namespace
@@ -29,11 +31,11 @@ func (e *Eth) Accounts() ([]web3.Address, error) {
 
 // BlockNumber returns the number of most recent block.
 func (e *Eth) BlockNumber() (uint64, error) {
-	var out string
+	var out quantity
 	if err := e.c.Call("eth_blockNumber", &out); err != nil {
 		return 0, err
 	}
-	return parseUint64orHex(out)
+	return parseUint64orHex(string(out))
 }
 
 // GetBlockByNumber returns information about a block by block number.
@@ -45,15 +47,60 @@ func (e *Eth) GetBlockByNumber(i web3.BlockNumber, full bool) (*web3.Block, erro
 	return b, nil
 }
 
-// GetTransactionByHash returns information about a block by hash.
-func (e *Eth) GetTransactionByHash(hash web3.Hash) (*web3.Transaction, error) {
-	b := new(web3.Transaction)
-	if err := e.c.Call("eth_getTransactionByHash", b, hash); err != nil {
+// LatestBlock returns the latest block without its full transactions. The
+// block is cached and only requested again when the block number of the node
+// advances, which saves the requests of the apps that poll faster than the
+// block time. The returned block is shared and must not be modified.
+func (e *Eth) LatestBlock() (*web3.Block, error) {
+	num, err := e.BlockNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	e.c.headLock.Lock()
+	defer e.c.headLock.Unlock()
+
+	if e.c.head != nil && e.c.head.Number == num {
+		return e.c.head, nil
+	}
+	// request the block by number in case the head advanced after BlockNumber
+	b, err := e.GetBlockByNumber(web3.BlockNumber(num), false)
+	if err != nil {
 		return nil, err
 	}
+	e.c.head = b
 	return b, nil
 }
 
+// SupportsEIP1559 returns true if the latest block has a base fee, which
+// means that the chain accepts dynamic fee (type 2) transactions. The result
+// is requested once and cached by the client.
+func (e *Eth) SupportsEIP1559() (bool, error) {
+	e.c.eip1559Lock.Lock()
+	defer e.c.eip1559Lock.Unlock()
+
+	if e.c.eip1559 == nil {
+		b, err := e.GetBlockByNumber(web3.Latest, false)
+		if err != nil {
+			return false, err
+		}
+		supported := b.BaseFeePerGas != nil
+		e.c.eip1559 = &supported
+	}
+	return *e.c.eip1559, nil
+}
+
+// GetTransactionByHash returns information about a transaction by hash.
+// It returns nil if the transaction is not found.
+func (e *Eth) GetTransactionByHash(hash web3.Hash) (*web3.Transaction, error) {
+	// the node returns null if the transaction is not found
+	var txn *web3.Transaction
+	if err := e.c.Call("eth_getTransactionByHash", &txn, hash); err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
 // GetBlockByHash returns information about a block by hash.
 func (e *Eth) GetBlockByHash(hash web3.Hash, full bool) (*web3.Block, error) {
 	b := new(web3.Block)
@@ -63,79 +110,304 @@ func (e *Eth) GetBlockByHash(hash web3.Hash, full bool) (*web3.Block, error) {
 	return b, nil
 }
 
+// GetUncleByBlockHashAndIndex returns the header of the uncle at the index
+// of the block with the hash. The uncle does not include the transactions.
+// It returns nil if the uncle is not found.
+func (e *Eth) GetUncleByBlockHashAndIndex(hash web3.Hash, index uint64) (*web3.Block, error) {
+	// the node returns null if the uncle is not found
+	var b *web3.Block
+	if err := e.c.Call("eth_getUncleByBlockHashAndIndex", &b, hash, encodeUintToHex(index)); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetUncleByBlockNumberAndIndex returns the header of the uncle at the index
+// of the block with the number. The uncle does not include the transactions.
+// It returns nil if the uncle is not found.
+func (e *Eth) GetUncleByBlockNumberAndIndex(i web3.BlockNumber, index uint64) (*web3.Block, error) {
+	var b *web3.Block
+	if err := e.c.Call("eth_getUncleByBlockNumberAndIndex", &b, i.String(), encodeUintToHex(index)); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
 // SendTransaction creates new message call transaction or a contract creation.
-func (e *Eth) SendTransaction(txn *web3.Transaction) (web3.Hash, error) {
+// The transaction is signed by the node. A zero gas or fees are set before
+// sending it with the estimated gas and the suggested fees (see
+// PrepareTransaction). The nonce is only set to the pending nonce with
+// WithPendingNonce. The transaction is not modified.
+func (e *Eth) SendTransaction(txn *web3.Transaction, opts ...SendOption) (web3.Hash, error) {
+	config := &sendConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	filled := *txn
+	if err := e.fillTransaction(&filled, config.pendingNonce); err != nil {
+		return web3.Hash{}, err
+	}
 	var hash web3.Hash
-	err := e.c.Call("eth_sendTransaction", &hash, txn)
+	err := e.c.Call("eth_sendTransaction", &hash, &filled)
 	return hash, err
 }
 
 // GetTransactionReceipt returns the receipt of a transaction by transaction hash.
+// It returns nil if the transaction is not found or not mined yet.
 func (e *Eth) GetTransactionReceipt(hash web3.Hash) (*web3.Receipt, error) {
-	receipt := new(web3.Receipt)
-	err := e.c.Call("eth_getTransactionReceipt", receipt, hash)
-	return receipt, err
+	var receipt *web3.Receipt
+	if err := e.c.Call("eth_getTransactionReceipt", &receipt, hash); err != nil {
+		return nil, err
+	}
+	return receipt, nil
 }
 
-// GetNonce returns the nonce of the account
+// GetTransactionReceipts returns the receipts of the transactions using a single
+// batch request. The receipts are aligned with the hashes and they are nil for
+// the transactions that are not found or are still pending. If some of the
+// requests fail the error is a BatchErrors with the error for each index.
+func (e *Eth) GetTransactionReceipts(hashes []web3.Hash) ([]*web3.Receipt, error) {
+	receipts := make([]*web3.Receipt, len(hashes))
+	batch := make([]*BatchElem, len(hashes))
+	for indx, hash := range hashes {
+		batch[indx] = &BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Params: []interface{}{hash},
+			Result: &receipts[indx],
+		}
+	}
+	if err := e.c.BatchCall(batch); err != nil {
+		return nil, err
+	}
+	return receipts, batchErrors(batch)
+}
+
+// GetTransactionCount returns the number of transactions sent by the account
+// (eth_getTransactionCount), which is the nonce of its next transaction.
+// With web3.Latest the count only includes the mined transactions, so it is
+// the nonce to replace the first pending transaction. With web3.Pending it
+// also includes the transactions of the account in the pool of the node,
+// so it is the nonce of a new transaction (see PendingNonce).
+func (e *Eth) GetTransactionCount(addr web3.Address, blockNumber web3.BlockNumber) (uint64, error) {
+	return e.GetNonceAt(addr, web3.BlockAtNumber(blockNumber))
+}
+
+// GetNonce returns the nonce of the account. It is an alias of
+// GetTransactionCount.
 func (e *Eth) GetNonce(addr web3.Address, blockNumber web3.BlockNumber) (uint64, error) {
-	var nonce string
-	if err := e.c.Call("eth_getTransactionCount", &nonce, addr, blockNumber.String()); err != nil {
+	return e.GetTransactionCount(addr, blockNumber)
+}
+
+// PendingNonce returns the nonce of the next transaction of the account
+// including its transactions in the pool of the node
+func (e *Eth) PendingNonce(addr web3.Address) (uint64, error) {
+	return e.GetTransactionCount(addr, web3.Pending)
+}
+
+// GetNonceAt returns the nonce of the account at the block selected by number or hash
+func (e *Eth) GetNonceAt(addr web3.Address, block web3.BlockNumberOrHash) (uint64, error) {
+	var nonce quantity
+	if err := e.c.Call("eth_getTransactionCount", &nonce, addr, block); err != nil {
 		return 0, err
 	}
-	return parseUint64orHex(nonce)
+	return parseUint64orHex(string(nonce))
 }
 
 // GetBalance returns the balance of the account of given address.
 func (e *Eth) GetBalance(addr web3.Address, blockNumber web3.BlockNumber) (*big.Int, error) {
-	var out string
-	if err := e.c.Call("eth_getBalance", &out, addr, blockNumber.String()); err != nil {
+	return e.GetBalanceAt(addr, web3.BlockAtNumber(blockNumber))
+}
+
+// GetBalanceAt returns the balance of the account at the block selected by number or hash
+func (e *Eth) GetBalanceAt(addr web3.Address, block web3.BlockNumberOrHash) (*big.Int, error) {
+	var out quantity
+	if err := e.c.Call("eth_getBalance", &out, addr, block); err != nil {
 		return nil, err
 	}
-	b, ok := new(big.Int).SetString(out[2:], 16)
-	if !ok {
-		return nil, fmt.Errorf("failed to convert to big.int")
+	return parseBalance(out)
+}
+
+// GetBalances returns the balances of the accounts at the same block using a single
+// batch request. The balances are aligned with the addresses, if some of the requests
+// fail the error is a BatchErrors with the error for each index.
+func (e *Eth) GetBalances(addrs []web3.Address, blockNumber web3.BlockNumber) ([]*big.Int, error) {
+	outs := make([]quantity, len(addrs))
+	batch := make([]*BatchElem, len(addrs))
+	for indx, addr := range addrs {
+		batch[indx] = &BatchElem{
+			Method: "eth_getBalance",
+			Params: []interface{}{addr, blockNumber.String()},
+			Result: &outs[indx],
+		}
+	}
+	if err := e.c.BatchCall(batch); err != nil {
+		return nil, err
+	}
+
+	res := make([]*big.Int, len(addrs))
+	for indx, elem := range batch {
+		if elem.Error != nil {
+			continue
+		}
+		res[indx], elem.Error = parseBalance(outs[indx])
+	}
+	return res, batchErrors(batch)
+}
+
+func parseBalance(out quantity) (*big.Int, error) {
+	b, err := parseBigInt(string(out))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the balance: %v", err)
 	}
 	return b, nil
 }
 
 // GasPrice returns the current price per gas in wei.
 func (e *Eth) GasPrice() (uint64, error) {
-	var out string
+	var out quantity
 	if err := e.c.Call("eth_gasPrice", &out); err != nil {
 		return 0, err
 	}
-	return parseUint64orHex(out)
+	return parseUint64orHex(string(out))
 }
 
 // Call executes a new message call immediately without creating a transaction on the block chain.
 func (e *Eth) Call(msg *web3.CallMsg, block web3.BlockNumber) (string, error) {
+	return e.CallAt(msg, web3.BlockAtNumber(block))
+}
+
+// CallAt executes a new message call on the state of the block selected by number or hash.
+// If the call reverts with a reason the error is a RevertError.
+func (e *Eth) CallAt(msg *web3.CallMsg, block web3.BlockNumberOrHash) (string, error) {
 	var out string
-	if err := e.c.Call("eth_call", &out, msg, block.String()); err != nil {
-		return "", err
+	if err := e.c.Call("eth_call", &out, msg, block); err != nil {
+		return "", wrapRevertError(err)
 	}
 	return out, nil
 }
 
+// ErrEmptyCallResult happens when a call to a method with outputs returns
+// nothing, i.e. the address has no code or the call reverted without data
+var ErrEmptyCallResult = fmt.Errorf("the call returned no data")
+
+// CallMethod encodes the call to the method of the contract, executes it on the
+// state of the block and decodes the result with the outputs of the method.
+// If the method has outputs but the call returns nothing the error is
+// ErrEmptyCallResult.
+func (e *Eth) CallMethod(contract web3.Address, method *abi.Method, block web3.BlockNumber, args ...interface{}) (map[string]interface{}, error) {
+	data, err := method.Encode(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode arguments: %v", err)
+	}
+	msg := &web3.CallMsg{
+		To:   contract,
+		Data: append(append([]byte{}, method.ID()...), data...),
+	}
+	out, err := e.Call(msg, block)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := parseHexBytes(out)
+	if err != nil {
+		return nil, err
+	}
+
+	if method.Outputs == nil || len(method.Outputs.TupleElems()) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	if len(raw) == 0 {
+		return nil, ErrEmptyCallResult
+	}
+	val, err := abi.Decode(method.Outputs, raw)
+	if err != nil {
+		return nil, err
+	}
+	res, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to decode the outputs of %s", method.Name)
+	}
+	return res, nil
+}
+
+// CallBatch executes the message calls on the state of the same block using a single
+// batch request. A block hash guarantees that all the calls read the same state even
+// if there is a reorg. The outputs are aligned with the messages, if some of the calls
+// fail the error is a BatchErrors with the error for each index.
+func (e *Eth) CallBatch(msgs []*web3.CallMsg, block web3.BlockNumberOrHash) ([]string, error) {
+	outs := make([]string, len(msgs))
+	batch := make([]*BatchElem, len(msgs))
+	for indx, msg := range msgs {
+		batch[indx] = &BatchElem{
+			Method: "eth_call",
+			Params: []interface{}{msg, block},
+			Result: &outs[indx],
+		}
+	}
+	if err := e.c.BatchCall(batch); err != nil {
+		return nil, err
+	}
+	for _, elem := range batch {
+		if elem.Error != nil {
+			elem.Error = wrapRevertError(elem.Error)
+		}
+	}
+	return outs, batchErrors(batch)
+}
+
 // EstimateGasContract estimates the gas to deploy a contract
 func (e *Eth) EstimateGasContract(bin []byte) (uint64, error) {
-	var out string
+	var out quantity
 	msg := map[string]interface{}{
 		"data": "0x" + hex.EncodeToString(bin),
 	}
 	if err := e.c.Call("eth_estimateGas", &out, msg); err != nil {
 		return 0, err
 	}
-	return parseUint64orHex(out)
+	return parseUint64orHex(string(out))
 }
 
 // EstimateGas generates and returns an estimate of how much gas is necessary to allow the transaction to complete.
 func (e *Eth) EstimateGas(msg *web3.CallMsg) (uint64, error) {
-	var out string
+	var out quantity
 	if err := e.c.Call("eth_estimateGas", &out, msg); err != nil {
 		return 0, err
 	}
-	return parseUint64orHex(out)
+	return parseUint64orHex(string(out))
+}
+
+// EstimateGasBounded estimates the gas of the message plus 1/63 of the estimate,
+// since a call only forwards 63/64 of the available gas to the nested calls (eip-150),
+// and clamps the result between the floor and the ceil. A zero floor or ceil is not
+// applied. It returns an error if the ceil is lower than the estimate of the node
+// since the transaction would likely run out of gas.
+func (e *Eth) EstimateGasBounded(msg *web3.CallMsg, floor, ceil uint64) (uint64, error) {
+	if ceil != 0 && floor > ceil {
+		return 0, fmt.Errorf("gas floor %d higher than the ceil %d", floor, ceil)
+	}
+	estimate, err := e.EstimateGas(msg)
+	if err != nil {
+		return 0, err
+	}
+	if ceil != 0 && estimate > ceil {
+		return 0, fmt.Errorf("gas estimate %d higher than the ceil %d", estimate, ceil)
+	}
+
+	extra := estimate / 63
+	if estimate%63 != 0 {
+		extra++
+	}
+	gas := estimate + extra
+	if gas < estimate {
+		// overflow
+		gas = estimate
+	}
+	if gas < floor {
+		gas = floor
+	}
+	if ceil != 0 && gas > ceil {
+		gas = ceil
+	}
+	return gas, nil
 }
 
 // GetLogs returns an array of all logs matching a given filter object
@@ -147,13 +419,9 @@ func (e *Eth) GetLogs(filter *web3.LogFilter) ([]*web3.Log, error) {
 	return out, nil
 }
 
-// ChainID returns the id of the chain
+// ChainID returns the id of the chain. It is cached by the client (see Client.ChainID).
 func (e *Eth) ChainID() (*big.Int, error) {
-	var out string
-	if err := e.c.Call("eth_chainId", &out); err != nil {
-		return nil, err
-	}
-	return parseBigInt(out), nil
+	return e.c.ChainID()
 }
 
 func (e *Eth) GetStorageAt(addr web3.Address, hash web3.Hash, blockNumber web3.BlockNumber) (string, error) {
@@ -163,3 +431,90 @@ func (e *Eth) GetStorageAt(addr web3.Address, hash web3.Hash, blockNumber web3.B
 	}
 	return out, nil
 }
+
+// GetStorageValue reads the storage slot and decodes it as a value of the type.
+// The type must be a single word value (i.e. uint256, address, bool or bytes32)
+// that fills the slot, packed values are not split. Fixed bytes are stored in
+// the low-order bytes of the slot and are realigned before decoding.
+func (e *Eth) GetStorageValue(addr web3.Address, slot web3.Hash, t *abi.Type, blockNumber web3.BlockNumber) (interface{}, error) {
+	switch t.Kind() {
+	case abi.KindBool, abi.KindInt, abi.KindUInt, abi.KindAddress, abi.KindFixedBytes:
+	default:
+		return nil, fmt.Errorf("type %s does not fit in a storage slot", t.String())
+	}
+
+	out, err := e.GetStorageAt(addr, slot, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := parseHexBytes(out)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) > 32 {
+		return nil, fmt.Errorf("expected 32 bytes but found %d", len(buf))
+	}
+	word := make([]byte, 32)
+	copy(word[32-len(buf):], buf)
+	if t.Kind() == abi.KindFixedBytes {
+		aligned := make([]byte, 32)
+		copy(aligned, word[32-t.Size():])
+		word = aligned
+	}
+	return abi.Decode(t, word)
+}
+
+// FeeHistory is the fee market history of a range of blocks
+type FeeHistory struct {
+	OldestBlock uint64
+	// BaseFeePerGas includes the base fee of the block after the newest one
+	BaseFeePerGas []*big.Int
+	GasUsedRatio  []float64
+	// Reward are the priority fees at the requested percentiles for each block
+	Reward [][]*big.Int
+}
+
+// UnmarshalJSON implements the unmarshal interface
+func (f *FeeHistory) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		OldestBlock   quantity     `json:"oldestBlock"`
+		BaseFeePerGas []quantity   `json:"baseFeePerGas"`
+		GasUsedRatio  []float64    `json:"gasUsedRatio"`
+		Reward        [][]quantity `json:"reward"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var err error
+	if f.OldestBlock, err = parseUint64orHex(string(raw.OldestBlock)); err != nil {
+		return err
+	}
+	f.BaseFeePerGas = make([]*big.Int, len(raw.BaseFeePerGas))
+	for indx, fee := range raw.BaseFeePerGas {
+		if f.BaseFeePerGas[indx], err = parseBigInt(string(fee)); err != nil {
+			return err
+		}
+	}
+	f.GasUsedRatio = raw.GasUsedRatio
+	f.Reward = make([][]*big.Int, len(raw.Reward))
+	for indx, rewards := range raw.Reward {
+		f.Reward[indx] = make([]*big.Int, len(rewards))
+		for i, reward := range rewards {
+			if f.Reward[indx][i], err = parseBigInt(string(reward)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FeeHistory returns the base fee and the priority fees at the given percentiles
+// of the blockCount blocks up to newest.
+func (e *Eth) FeeHistory(blockCount uint64, newest web3.BlockNumber, rewardPercentiles []float64) (*FeeHistory, error) {
+	out := new(FeeHistory)
+	if err := e.c.Call("eth_feeHistory", out, encodeUintToHex(blockCount), newest.String(), rewardPercentiles); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -2,11 +2,17 @@ package jsonrpc
 
 import (
 	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/boolw/go-web3"
+	"github.com/boolw/go-web3/abi"
+	"github.com/boolw/go-web3/jsonrpc/codec"
 	"github.com/boolw/go-web3/testutil"
 )
 
@@ -173,6 +179,37 @@ func TestEthEstimateGas(t *testing.T) {
 	assert.NotEqual(t, gas, 0)
 }
 
+func TestEthEstimateGasBounded(t *testing.T) {
+	m := NewMockTransport()
+	m.Respond("eth_estimateGas", nil, "0x7e00") // 32256
+	c := NewMockClient(m)
+
+	msg := &web3.CallMsg{To: addr0}
+
+	// the estimate plus 1/63
+	gas, err := c.Eth().EstimateGasBounded(msg, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(32256+512), gas)
+
+	// floor
+	gas, err = c.Eth().EstimateGasBounded(msg, 50000, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(50000), gas)
+
+	// the ceil clamps the extra gas
+	gas, err = c.Eth().EstimateGasBounded(msg, 0, 32500)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(32500), gas)
+
+	// the ceil is lower than the estimate
+	_, err = c.Eth().EstimateGasBounded(msg, 0, 30000)
+	assert.Error(t, err)
+
+	// the floor is higher than the ceil
+	_, err = c.Eth().EstimateGasBounded(msg, 40000, 35000)
+	assert.Error(t, err)
+}
+
 func TestEthGetLogs(t *testing.T) {
 	s := testutil.NewTestServer(t, nil)
 	defer s.Close()
@@ -239,6 +276,28 @@ func TestEthGetNonce(t *testing.T) {
 	assert.Equal(t, num, uint64(1))
 }
 
+func TestEthGetTransactionCount(t *testing.T) {
+	addr := web3.Address{0x1}
+
+	m := NewMockTransport()
+	m.Respond("eth_getTransactionCount", []interface{}{addr, "latest"}, "0x2")
+	m.Respond("eth_getTransactionCount", []interface{}{addr, "pending"}, "0x4")
+
+	c := NewMockClient(m)
+
+	num, err := c.Eth().GetTransactionCount(addr, web3.Latest)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), num)
+
+	num, err = c.Eth().GetNonce(addr, web3.Latest)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), num)
+
+	num, err = c.Eth().PendingNonce(addr)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), num)
+}
+
 func TestEthTransactionsInBlock(t *testing.T) {
 	s := testutil.NewTestServer(t, nil)
 	defer s.Close()
@@ -267,3 +326,413 @@ func TestEthTransactionsInBlock(t *testing.T) {
 
 	assert.Equal(t, block0.TransactionsHashes[0], block1.Transactions[0].Hash)
 }
+
+func TestEthGetBalances(t *testing.T) {
+	s := testutil.NewTestServer(t, nil)
+	defer s.Close()
+
+	c, _ := NewClient(s.HTTPAddr())
+
+	addrs := []web3.Address{s.Account(0), addr0}
+	balances, err := c.Eth().GetBalances(addrs, web3.Latest)
+	assert.NoError(t, err)
+	assert.Len(t, balances, 2)
+
+	for indx, addr := range addrs {
+		balance, err := c.Eth().GetBalance(addr, web3.Latest)
+		assert.NoError(t, err)
+		assert.Equal(t, balance, balances[indx])
+	}
+}
+
+func TestEthTransactionNotFound(t *testing.T) {
+	m := NewMockTransport()
+	m.Respond("eth_getTransactionByHash", nil, nil)
+	m.Respond("eth_getTransactionReceipt", nil, nil)
+
+	c := NewMockClient(m)
+
+	txn, err := c.Eth().GetTransactionByHash(web3.Hash{0x1})
+	assert.NoError(t, err)
+	assert.Nil(t, txn)
+
+	receipt, err := c.Eth().GetTransactionReceipt(web3.Hash{0x1})
+	assert.NoError(t, err)
+	assert.Nil(t, receipt)
+}
+
+func TestEthGetTransactionReceipts(t *testing.T) {
+	receiptJSON := func(hash web3.Hash) json.RawMessage {
+		return json.RawMessage(`{
+			"transactionHash": "` + hash.String() + `",
+			"transactionIndex": "0x0",
+			"blockHash": "` + web3.Hash{0xa}.String() + `",
+			"blockNumber": "0x1",
+			"from": "` + web3.Address{0x1}.String() + `",
+			"gasUsed": "0x5208",
+			"cumulativeGasUsed": "0x5208",
+			"status": "0x1",
+			"logsBloom": "0x` + strings.Repeat("00", 256) + `",
+			"logs": []
+		}`)
+	}
+	hashes := []web3.Hash{{0x1}, {0x2}, {0x3}}
+
+	m := NewMockTransport()
+	m.Respond("eth_getTransactionReceipt", []interface{}{hashes[0]}, receiptJSON(hashes[0]))
+	m.Respond("eth_getTransactionReceipt", []interface{}{hashes[1]}, nil)
+	m.RespondError("eth_getTransactionReceipt", []interface{}{hashes[2]}, &codec.ErrorObject{Code: -32000, Message: "failed"})
+
+	c := NewMockClient(m)
+
+	receipts, err := c.Eth().GetTransactionReceipts(hashes)
+	assert.Len(t, receipts, 3)
+	assert.Equal(t, hashes[0], receipts[0].TransactionHash)
+	assert.Nil(t, receipts[1])
+	assert.Nil(t, receipts[2])
+
+	errs, ok := err.(BatchErrors)
+	assert.True(t, ok)
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[1])
+	assert.Error(t, errs[2])
+	assert.Equal(t, 3, m.Calls("eth_getTransactionReceipt"))
+}
+
+func TestEthGetBalanceAtHash(t *testing.T) {
+	addr := web3.Address{0x1}
+	hash := web3.Hash{0x2}
+
+	m := NewMockTransport()
+	m.Respond("eth_getBalance", []interface{}{addr, map[string]interface{}{"blockHash": hash}}, "0x10")
+	m.Respond("eth_getBalance", []interface{}{addr, "latest"}, "0x20")
+	m.Respond("eth_getTransactionCount", []interface{}{addr, map[string]interface{}{"blockHash": hash, "requireCanonical": true}}, "0x1")
+
+	c := NewMockClient(m)
+
+	balance, err := c.Eth().GetBalanceAt(addr, web3.BlockAtHash(hash, false))
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(16), balance)
+
+	balance, err = c.Eth().GetBalance(addr, web3.Latest)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(32), balance)
+
+	nonce, err := c.Eth().GetNonceAt(addr, web3.BlockAtHash(hash, true))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), nonce)
+}
+
+func TestEthGetBalanceFormats(t *testing.T) {
+	large, _ := new(big.Int).SetString("100000000000000000000", 10)
+
+	cases := []struct {
+		out     interface{}
+		balance *big.Int
+	}{
+		{"0x", big.NewInt(0)},
+		{"0x0", big.NewInt(0)},
+		{"0X0", big.NewInt(0)},
+		{"0xAbC", big.NewInt(0xabc)},
+		{"", nil},
+		{"0", nil},
+		{"x1", nil},
+		{"0xg", nil},
+		// json numbers are decimal
+		{json.RawMessage(`4096`), big.NewInt(4096)},
+		{json.RawMessage(`100000000000000000000`), large},
+		{json.RawMessage(`1.5`), nil},
+	}
+	for _, c := range cases {
+		m := NewMockTransport()
+		m.Respond("eth_getBalance", nil, c.out)
+		client := NewMockClient(m)
+
+		balance, err := client.Eth().GetBalance(addr0, web3.Latest)
+		if c.balance == nil {
+			assert.Error(t, err, c.out)
+		} else {
+			assert.NoError(t, err, c.out)
+			assert.Equal(t, c.balance.String(), balance.String(), c.out)
+		}
+	}
+
+	m := NewMockTransport()
+	m.Respond("eth_getBalance", nil, json.RawMessage(`4096`))
+	balances, err := NewMockClient(m).Eth().GetBalances([]web3.Address{addr0, {0x1}}, web3.Latest)
+	assert.NoError(t, err)
+	assert.Equal(t, []*big.Int{big.NewInt(4096), big.NewInt(4096)}, balances)
+}
+
+func TestEthEstimateGasFrom(t *testing.T) {
+	owner := web3.Address{0x1}
+
+	// the node only allows the owner to call the method
+	m := NewMockTransport()
+	m.Handle("eth_estimateGas", nil, func(params []interface{}) (interface{}, error) {
+		raw, err := json.Marshal(params[0])
+		if err != nil {
+			return nil, err
+		}
+		var msg struct {
+			From  string
+			Value string
+			Gas   string
+		}
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, err
+		}
+		if msg.From != owner.String() {
+			return nil, fmt.Errorf("execution reverted: caller is not the owner")
+		}
+		if msg.Value != "0x1" || msg.Gas != "0x5208" {
+			return nil, fmt.Errorf("bad value or gas")
+		}
+		return "0x100", nil
+	})
+	c := NewMockClient(m)
+
+	msg := &web3.CallMsg{
+		To:    web3.Address{0x2},
+		Value: big.NewInt(1),
+		Gas:   21000,
+	}
+	_, err := c.Eth().EstimateGas(msg)
+	assert.Error(t, err)
+
+	msg.From = owner
+	gas, err := c.Eth().EstimateGas(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(256), gas)
+}
+
+func TestEthCallBatch(t *testing.T) {
+	block := web3.BlockAtHash(web3.Hash{0x1}, true)
+
+	m := NewMockTransport()
+	m.Handle("eth_call", nil, func(params []interface{}) (interface{}, error) {
+		// all the calls are at the same block
+		if params[1] != block {
+			return nil, fmt.Errorf("bad block %v", params[1])
+		}
+		msg := params[0].(*web3.CallMsg)
+		if msg.To == (web3.Address{0x3}) {
+			return nil, fmt.Errorf("failed")
+		}
+		return "0x" + hex.EncodeToString(msg.To[:1]), nil
+	})
+	c := NewMockClient(m)
+
+	msgs := []*web3.CallMsg{
+		{To: web3.Address{0x1}},
+		{To: web3.Address{0x2}},
+	}
+	outs, err := c.Eth().CallBatch(msgs, block)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0x01", "0x02"}, outs)
+	assert.Equal(t, 2, m.Calls("eth_call"))
+
+	msgs = append(msgs, &web3.CallMsg{To: web3.Address{0x3}})
+	outs, err = c.Eth().CallBatch(msgs, block)
+	assert.Error(t, err)
+
+	errs, ok := err.(BatchErrors)
+	assert.True(t, ok)
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[1])
+	assert.Error(t, errs[2])
+	assert.Equal(t, "0x02", outs[1])
+}
+
+func TestEthGetUncle(t *testing.T) {
+	uncle := json.RawMessage(`{
+		"hash": "0x0100000000000000000000000000000000000000000000000000000000000000",
+		"parentHash": "0x0200000000000000000000000000000000000000000000000000000000000000",
+		"sha3Uncles": "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",
+		"transactionsRoot": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"stateRoot": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"receiptsRoot": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"miner": "0x0300000000000000000000000000000000000000",
+		"number": "0xa",
+		"gasLimit": "0x10",
+		"gasUsed": "0x0",
+		"timestamp": "0x5",
+		"difficulty": "0x20",
+		"extraData": "0x",
+		"uncles": []
+	}`)
+
+	m := NewMockTransport()
+	m.Respond("eth_getUncleByBlockHashAndIndex", []interface{}{web3.Hash{0x5}, "0x1"}, uncle)
+	m.Respond("eth_getUncleByBlockNumberAndIndex", []interface{}{"0xb", "0x0"}, uncle)
+	m.Respond("eth_getUncleByBlockNumberAndIndex", []interface{}{"0xb", "0x1"}, nil)
+	c := NewMockClient(m)
+
+	block, err := c.Eth().GetUncleByBlockHashAndIndex(web3.Hash{0x5}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, web3.Hash{0x1}, block.Hash)
+	assert.Equal(t, web3.Address{0x3}, block.Miner)
+	assert.Equal(t, uint64(10), block.Number)
+	assert.Equal(t, big.NewInt(0x20), block.Difficulty)
+	assert.Empty(t, block.Transactions)
+
+	block, err = c.Eth().GetUncleByBlockNumberAndIndex(11, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), block.Number)
+
+	// not found
+	block, err = c.Eth().GetUncleByBlockNumberAndIndex(11, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, block)
+}
+
+func TestEthLatestBlock(t *testing.T) {
+	head := "0xa"
+
+	m := NewMockTransport()
+	m.Handle("eth_blockNumber", nil, func(params []interface{}) (interface{}, error) {
+		return head, nil
+	})
+	m.Respond("eth_getBlockByNumber", []interface{}{"0xa", false}, testBlockJSON(1, "0xa", ""))
+	m.Respond("eth_getBlockByNumber", []interface{}{"0xb", false}, testBlockJSON(2, "0xb", ""))
+	c := NewMockClient(m)
+
+	for i := 0; i < 3; i++ {
+		block, err := c.Eth().LatestBlock()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(10), block.Number)
+	}
+	assert.Equal(t, 1, m.Calls("eth_getBlockByNumber"))
+
+	// the block is requested again when the head advances
+	head = "0xb"
+	block, err := c.Eth().LatestBlock()
+	assert.NoError(t, err)
+	assert.Equal(t, web3.Hash{0x2}, block.Hash)
+	assert.Equal(t, 2, m.Calls("eth_getBlockByNumber"))
+}
+
+func TestEthGetStorageValue(t *testing.T) {
+	addr := web3.Address{0x1}
+	word := func(s string) string {
+		return "0x" + strings.Repeat("0", 64-len(s)) + s
+	}
+
+	m := NewMockTransport()
+	m.Respond("eth_getStorageAt", []interface{}{addr, web3.Hash{0x1}, "latest"}, word("64"))
+	m.Respond("eth_getStorageAt", []interface{}{addr, web3.Hash{0x2}, "latest"}, word("0200000000000000000000000000000000000003"))
+	m.Respond("eth_getStorageAt", []interface{}{addr, web3.Hash{0x3}, "latest"}, word("1"))
+	m.Respond("eth_getStorageAt", []interface{}{addr, web3.Hash{0x4}, "latest"}, word("12345678"))
+	c := NewMockClient(m)
+
+	val, err := c.Eth().GetStorageValue(addr, web3.Hash{0x1}, abi.MustNewType("uint256"), web3.Latest)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(100), val)
+
+	val, err = c.Eth().GetStorageValue(addr, web3.Hash{0x2}, abi.MustNewType("address"), web3.Latest)
+	assert.NoError(t, err)
+	assert.Equal(t, web3.HexToAddress("0x0200000000000000000000000000000000000003"), val)
+
+	val, err = c.Eth().GetStorageValue(addr, web3.Hash{0x3}, abi.MustNewType("bool"), web3.Latest)
+	assert.NoError(t, err)
+	assert.Equal(t, true, val)
+
+	// fixed bytes are stored in the low-order bytes
+	val, err = c.Eth().GetStorageValue(addr, web3.Hash{0x4}, abi.MustNewType("bytes4"), web3.Latest)
+	assert.NoError(t, err)
+	assert.Equal(t, [4]byte{0x12, 0x34, 0x56, 0x78}, val)
+
+	_, err = c.Eth().GetStorageValue(addr, web3.Hash{0x1}, abi.MustNewType("string"), web3.Latest)
+	assert.Error(t, err)
+}
+
+// testBlockJSON returns the json of a block without transactions. The base
+// fee is only included if it is set.
+func testBlockJSON(hash int, number string, baseFee string) json.RawMessage {
+	extra := ""
+	if baseFee != "" {
+		extra = fmt.Sprintf(`"baseFeePerGas": "%s",`, baseFee)
+	}
+	return json.RawMessage(fmt.Sprintf(`{
+		"hash": "0x0%d00000000000000000000000000000000000000000000000000000000000000",
+		"parentHash": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"sha3Uncles": "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",
+		"transactionsRoot": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"stateRoot": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"receiptsRoot": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"miner": "0x0000000000000000000000000000000000000000",
+		"number": "%s",
+		"gasLimit": "0x10",
+		"gasUsed": "0x0",
+		"timestamp": "0x5",
+		"difficulty": "0x20",
+		"extraData": "0x",
+		%s
+		"transactions": [],
+		"uncles": []
+	}`, hash, number, extra))
+}
+
+func TestEthSupportsEIP1559(t *testing.T) {
+	m := NewMockTransport()
+	m.Respond("eth_getBlockByNumber", []interface{}{"latest", false}, testBlockJSON(1, "0xa", "0x3b9aca00"))
+	c := NewMockClient(m)
+
+	for i := 0; i < 2; i++ {
+		ok, err := c.Eth().SupportsEIP1559()
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, 1, m.Calls("eth_getBlockByNumber"))
+
+	// legacy chain
+	m = NewMockTransport()
+	m.Respond("eth_getBlockByNumber", []interface{}{"latest", false}, testBlockJSON(1, "0xa", ""))
+	c = NewMockClient(m)
+
+	ok, err := c.Eth().SupportsEIP1559()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestEthCallMethod(t *testing.T) {
+	a := abi.MustNewABI(`[
+		{"type": "function", "name": "balanceOf", "inputs": [{"name": "owner", "type": "address"}], "outputs": [{"name": "balance", "type": "uint256"}]},
+		{"type": "function", "name": "ping", "inputs": [], "outputs": []}
+	]`)
+	contract := web3.Address{0x5}
+	owner := web3.Address{0x1}
+
+	calldata, err := a.Methods["balanceOf"].Encode(owner)
+	assert.NoError(t, err)
+	calldata = append(a.Methods["balanceOf"].ID(), calldata...)
+
+	m := NewMockTransport()
+	m.Handle("eth_call", nil, func(params []interface{}) (interface{}, error) {
+		msg := params[0].(*web3.CallMsg)
+		if msg.To != contract {
+			return "0x", nil
+		}
+		if bytes.Equal(msg.Data, calldata) {
+			return "0x" + strings.Repeat("0", 62) + "64", nil
+		}
+		return "0x", nil
+	})
+	c := NewMockClient(m)
+
+	res, err := c.Eth().CallMethod(contract, a.Methods["balanceOf"], web3.Latest, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(100), res["balance"])
+
+	// no data returned
+	_, err = c.Eth().CallMethod(web3.Address{0x6}, a.Methods["balanceOf"], web3.Latest, owner)
+	assert.Equal(t, ErrEmptyCallResult, err)
+
+	// methods without outputs return nothing
+	res, err = c.Eth().CallMethod(contract, a.Methods["ping"], web3.Latest)
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+	_, err = c.Eth().CallMethod(contract, a.Methods["balanceOf"], web3.Latest)
+	assert.Error(t, err)
+}
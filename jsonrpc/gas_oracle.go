@@ -0,0 +1,154 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/boolw/go-web3"
+)
+
+const (
+	// GasSlow is a fee likely to be included in the next minutes
+	GasSlow = "slow"
+
+	// GasStandard is a fee likely to be included in the next blocks
+	GasStandard = "standard"
+
+	// GasFast is a fee likely to be included in the next block
+	GasFast = "fast"
+)
+
+const (
+	defaultOracleBlocks        = 20
+	defaultOracleCacheDuration = 3 * time.Second
+)
+
+// percentiles of the priority fees for each of the speeds
+var oraclePercentiles = map[string]int{
+	GasSlow:     0,
+	GasStandard: 1,
+	GasFast:     2,
+}
+
+var oracleRewardPercentiles = []float64{10, 50, 90}
+
+// Fees are the suggested fees for a transaction. On chains without
+// eip-1559 only GasPrice is set.
+type Fees struct {
+	GasPrice             *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+}
+
+// IsLegacy returns true if the fees are for a legacy transaction
+func (f *Fees) IsLegacy() bool {
+	return f.MaxFeePerGas == nil
+}
+
+// GasOracle suggests fees for new transactions using the fee history
+// of the recent blocks or the gas price on legacy chains.
+type GasOracle struct {
+	c *Client
+
+	// Blocks is the number of blocks used to compute the priority fee
+	Blocks uint64
+
+	// CacheDuration is the time the fee history is reused between suggestions
+	CacheDuration time.Duration
+
+	lock      sync.Mutex
+	history   *FeeHistory
+	gasPrice  *big.Int
+	fetchedAt time.Time
+}
+
+// NewGasOracle creates a new gas oracle
+func NewGasOracle(c *Client) *GasOracle {
+	return &GasOracle{
+		c:             c,
+		Blocks:        defaultOracleBlocks,
+		CacheDuration: defaultOracleCacheDuration,
+	}
+}
+
+// Suggest returns the fees for the speed (slow, standard or fast)
+func (g *GasOracle) Suggest(speed string) (*Fees, error) {
+	indx, ok := oraclePercentiles[speed]
+	if !ok {
+		return nil, fmt.Errorf("unknown gas speed '%s'", speed)
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.fetchedAt.IsZero() || time.Since(g.fetchedAt) > g.CacheDuration {
+		if err := g.fetch(); err != nil {
+			return nil, err
+		}
+	}
+
+	if g.history == nil {
+		// legacy chain
+		return &Fees{GasPrice: new(big.Int).Set(g.gasPrice)}, nil
+	}
+
+	rewards := []*big.Int{}
+	for _, reward := range g.history.Reward {
+		if len(reward) > indx {
+			rewards = append(rewards, reward[indx])
+		}
+	}
+	priorityFee := median(rewards)
+
+	// the last base fee is the one for the pending block. Double it
+	// so that the transaction is valid for several full blocks.
+	baseFee := g.history.BaseFeePerGas[len(g.history.BaseFeePerGas)-1]
+	maxFee := new(big.Int).Mul(baseFee, big.NewInt(2))
+	maxFee.Add(maxFee, priorityFee)
+
+	fees := &Fees{
+		MaxFeePerGas:         maxFee,
+		MaxPriorityFeePerGas: priorityFee,
+	}
+	return fees, nil
+}
+
+func (g *GasOracle) fetch() error {
+	history, err := g.c.Eth().FeeHistory(g.Blocks, web3.Latest, oracleRewardPercentiles)
+	if err == nil && isLondonHistory(history) {
+		g.history = history
+		g.gasPrice = nil
+	} else {
+		// eth_feeHistory is not available or the chain has no base fee
+		gasPrice, err := g.c.Eth().GasPrice()
+		if err != nil {
+			return err
+		}
+		g.history = nil
+		g.gasPrice = new(big.Int).SetUint64(gasPrice)
+	}
+	g.fetchedAt = time.Now()
+	return nil
+}
+
+func isLondonHistory(history *FeeHistory) bool {
+	if len(history.BaseFeePerGas) == 0 {
+		return false
+	}
+	return history.BaseFeePerGas[len(history.BaseFeePerGas)-1].Sign() != 0
+}
+
+func median(nums []*big.Int) *big.Int {
+	if len(nums) == 0 {
+		return big.NewInt(0)
+	}
+	sorted := make([]*big.Int, len(nums))
+	copy(sorted, nums)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+	return new(big.Int).Set(sorted[len(sorted)/2])
+}
@@ -0,0 +1,47 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGasOracleLondon(t *testing.T) {
+	tr := NewMockTransport()
+	tr.Respond("eth_feeHistory", nil, json.RawMessage(`{
+		"oldestBlock": "0x1",
+		"baseFeePerGas": ["0x10", "0x20", "0x30"],
+		"gasUsedRatio": [0.5, 0.5],
+		"reward": [["0x1", "0x2", "0x3"], ["0x3", "0x4", "0x5"]]
+	}`))
+	oracle := NewGasOracle(NewMockClient(tr))
+
+	fees, err := oracle.Suggest(GasFast)
+	assert.NoError(t, err)
+	assert.False(t, fees.IsLegacy())
+	assert.Equal(t, fees.MaxPriorityFeePerGas, big.NewInt(5))
+	assert.Equal(t, fees.MaxFeePerGas, big.NewInt(2*0x30+5))
+
+	fees, err = oracle.Suggest(GasSlow)
+	assert.NoError(t, err)
+	assert.Equal(t, fees.MaxPriorityFeePerGas, big.NewInt(3))
+
+	// the fee history is cached between suggestions
+	assert.Equal(t, tr.Calls("eth_feeHistory"), 1)
+
+	_, err = oracle.Suggest("unknown")
+	assert.Error(t, err)
+}
+
+func TestGasOracleLegacy(t *testing.T) {
+	tr := NewMockTransport()
+	tr.Respond("eth_gasPrice", nil, "0x100")
+	oracle := NewGasOracle(NewMockClient(tr))
+
+	fees, err := oracle.Suggest(GasStandard)
+	assert.NoError(t, err)
+	assert.True(t, fees.IsLegacy())
+	assert.Equal(t, fees.GasPrice, big.NewInt(0x100))
+}
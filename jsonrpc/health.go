@@ -0,0 +1,100 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// HealthStatus is the status of the node returned by Client.Health
+type HealthStatus struct {
+	// BlockNumber is the latest block of the node
+	BlockNumber uint64
+
+	// PeerCount is the number of peers connected to the node
+	PeerCount uint64
+
+	// Syncing is true if the node is still syncing the chain. HighestBlock
+	// is the highest block known by the node while it syncs.
+	Syncing      bool
+	HighestBlock uint64
+
+	// Latency is the time to complete all the checks
+	Latency time.Duration
+}
+
+// Health checks the latest block (eth_blockNumber), the peers (net_peerCount)
+// and the sync status (eth_syncing) of the node concurrently (i.e. as a
+// readiness probe). It fails if any of the checks fails or if the context
+// is done before they complete.
+func (c *Client) Health(ctx context.Context) (*HealthStatus, error) {
+	status := &HealthStatus{}
+	start := time.Now()
+
+	checks := []struct {
+		name string
+		run  func() error
+	}{
+		{"eth_blockNumber", func() (err error) {
+			status.BlockNumber, err = c.Eth().BlockNumber()
+			return
+		}},
+		{"net_peerCount", func() (err error) {
+			status.PeerCount, err = c.Net().PeerCount()
+			return
+		}},
+		{"eth_syncing", func() (err error) {
+			status.Syncing, status.HighestBlock, err = c.syncing()
+			return
+		}},
+	}
+
+	// buffered so that the checks do not block if the context is done
+	errCh := make(chan error, len(checks))
+	for _, check := range checks {
+		go func(name string, run func() error) {
+			if err := run(); err != nil {
+				errCh <- fmt.Errorf("health check %s failed: %v", name, err)
+				return
+			}
+			errCh <- nil
+		}(check.name, check.run)
+	}
+
+	for range checks {
+		select {
+		case err := <-errCh:
+			if err != nil {
+				return nil, err
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	status.Latency = time.Since(start)
+	return status, nil
+}
+
+// syncing returns whether the node is syncing and the highest block it knows
+func (c *Client) syncing() (bool, uint64, error) {
+	var out json.RawMessage
+	if err := c.Call("eth_syncing", &out); err != nil {
+		return false, 0, err
+	}
+	if bytes.Equal(out, []byte("false")) {
+		return false, 0, nil
+	}
+	var progress struct {
+		HighestBlock quantity `json:"highestBlock"`
+	}
+	if err := json.Unmarshal(out, &progress); err != nil {
+		return false, 0, fmt.Errorf("failed to decode the sync status: %v", err)
+	}
+	highest, err := parseUint64orHex(string(progress.HighestBlock))
+	if err != nil {
+		return false, 0, err
+	}
+	return true, highest, nil
+}
@@ -0,0 +1,60 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientHealth(t *testing.T) {
+	m := NewMockTransport()
+	m.Respond("eth_blockNumber", nil, "0x10")
+	m.Respond("net_peerCount", nil, "0x5")
+	m.Respond("eth_syncing", nil, false)
+
+	c := NewMockClient(m)
+
+	status, err := c.Health(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(16), status.BlockNumber)
+	assert.Equal(t, uint64(5), status.PeerCount)
+	assert.False(t, status.Syncing)
+	assert.True(t, status.Latency > 0)
+
+	// a syncing node
+	m.Respond("eth_syncing", nil, json.RawMessage(`{"startingBlock": "0x1", "currentBlock": "0x10", "highestBlock": "0x20"}`))
+
+	status, err = c.Health(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, status.Syncing)
+	assert.Equal(t, uint64(32), status.HighestBlock)
+
+	// any failed check fails the probe
+	m.RespondError("net_peerCount", nil, fmt.Errorf("failed"))
+
+	_, err = c.Health(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "net_peerCount")
+}
+
+func TestClientHealthDeadline(t *testing.T) {
+	m := NewMockTransport()
+	m.Respond("eth_blockNumber", nil, "0x10")
+	m.Respond("eth_syncing", nil, false)
+	m.Handle("net_peerCount", nil, func(params []interface{}) (interface{}, error) {
+		time.Sleep(100 * time.Millisecond)
+		return "0x1", nil
+	})
+
+	c := NewMockClient(m)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := c.Health(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
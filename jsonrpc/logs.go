@@ -0,0 +1,250 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/boolw/go-web3"
+)
+
+// defaultLogsBlockRange is the default number of blocks of each query in GetLogsRange
+const defaultLogsBlockRange = 1000
+
+// defaultRangeSplitErrors are the messages of the errors of the nodes
+// (i.e. geth, Infura and Alchemy) for queries with too many blocks or logs
+var defaultRangeSplitErrors = []string{
+	"query returned more than",
+	"response size exceeded",
+	"response size is larger than",
+	"block range too",
+	"block range is too",
+	"range too large",
+	"range is too large",
+	"exceed maximum block range",
+	"exceeds the range allowed",
+}
+
+type logsRangeConfig struct {
+	blockRange   uint64
+	maxTopics    int
+	maxAddresses int
+	splitErrors  []string
+}
+
+// LogsRangeOption is an option of GetLogsRange
+type LogsRangeOption func(*logsRangeConfig)
+
+// WithBlockRange sets the maximum number of blocks of each eth_getLogs query
+func WithBlockRange(blocks uint64) LogsRangeOption {
+	return func(c *logsRangeConfig) {
+		c.blockRange = blocks
+	}
+}
+
+// WithMaxTopics sets the maximum number of event ids (topic0) of each
+// eth_getLogs query. The filters with more event ids are split in several
+// queries for the nodes that reject large sets of topics.
+func WithMaxTopics(topics int) LogsRangeOption {
+	return func(c *logsRangeConfig) {
+		c.maxTopics = topics
+	}
+}
+
+// WithMaxAddresses sets the maximum number of addresses of each eth_getLogs
+// query. The filters with more addresses are split in several queries over
+// the same range of blocks for the nodes that limit the list of addresses.
+func WithMaxAddresses(addresses int) LogsRangeOption {
+	return func(c *logsRangeConfig) {
+		c.maxAddresses = addresses
+	}
+}
+
+// WithRangeSplitErrors adds messages to the errors that make GetLogsRange
+// split the range of a query in half (i.e. the phrasing of the limit of
+// the results of a provider). The messages are matched case insensitive
+// as substrings of the errors.
+func WithRangeSplitErrors(msgs []string) LogsRangeOption {
+	return func(c *logsRangeConfig) {
+		c.splitErrors = append(c.splitErrors, msgs...)
+	}
+}
+
+// GetLogsRange returns the logs of the filter between the from and to blocks
+// (both included). The range is split in eth_getLogs queries of at most
+// 1000 blocks (see WithBlockRange), the event ids in queries of at most
+// the number of WithMaxTopics and the addresses in queries of at most the
+// number of WithMaxAddresses. The logs are sorted by block and index and
+// the duplicates of the split queries are removed. The queries that fail
+// because the range is too large for the node (see WithRangeSplitErrors)
+// are retried as two queries with half of the range each. The block hash
+// and the range of the filter are ignored.
+func (e *Eth) GetLogsRange(filter *web3.LogFilter, from, to uint64, opts ...LogsRangeOption) ([]*web3.Log, error) {
+	if from > to {
+		return nil, fmt.Errorf("from (%d) higher than to (%d)", from, to)
+	}
+	config := &logsRangeConfig{
+		blockRange:  defaultLogsBlockRange,
+		splitErrors: append([]string{}, defaultRangeSplitErrors...),
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.blockRange == 0 {
+		return nil, fmt.Errorf("the block range cannot be zero")
+	}
+
+	topics := splitTopics(filter.Topics, config.maxTopics)
+	addresses := splitAddresses(filter.Address, config.maxAddresses)
+
+	logs := []*web3.Log{}
+	for i := from; ; {
+		dst := to
+		if to-i >= config.blockRange {
+			dst = i + config.blockRange - 1
+		}
+
+		for _, addrs := range addresses {
+			for _, t := range topics {
+				res, err := e.getLogsSplit(addrs, t, i, dst, config.splitErrors)
+				if err != nil {
+					return nil, err
+				}
+				logs = append(logs, res...)
+			}
+		}
+
+		if dst == to {
+			break
+		}
+		i = dst + 1
+	}
+
+	if len(topics) > 1 || len(addresses) > 1 {
+		sort.SliceStable(logs, func(i, j int) bool {
+			if logs[i].BlockNumber != logs[j].BlockNumber {
+				return logs[i].BlockNumber < logs[j].BlockNumber
+			}
+			return logs[i].LogIndex < logs[j].LogIndex
+		})
+		logs = dedupLogs(logs)
+	}
+	return logs, nil
+}
+
+// getLogsSplit queries the logs between the from and to blocks. If the node
+// fails because the range is too large the range is split in two halves.
+func (e *Eth) getLogsSplit(addrs []web3.Address, topics [][]web3.Hash, from, to uint64, splitErrors []string) ([]*web3.Log, error) {
+	query := &web3.LogFilter{
+		Address: addrs,
+		Topics:  topics,
+	}
+	query.SetFromUint64(from)
+	query.SetToUint64(to)
+
+	logs, err := e.GetLogs(query)
+	if err == nil {
+		return logs, nil
+	}
+	if from == to || !isRangeSplitError(err, splitErrors) {
+		return nil, err
+	}
+
+	mid := from + (to-from)/2
+	logs, err = e.getLogsSplit(addrs, topics, from, mid, splitErrors)
+	if err != nil {
+		return nil, err
+	}
+	res, err := e.getLogsSplit(addrs, topics, mid+1, to, splitErrors)
+	if err != nil {
+		return nil, err
+	}
+	return append(logs, res...), nil
+}
+
+func isRangeSplitError(err error, msgs []string) bool {
+	str := strings.ToLower(err.Error())
+	for _, msg := range msgs {
+		if msg != "" && strings.Contains(str, strings.ToLower(msg)) {
+			return true
+		}
+	}
+	return false
+}
+
+// dedupLogs removes the logs returned by more than one query
+func dedupLogs(logs []*web3.Log) []*web3.Log {
+	type logKey struct {
+		block web3.Hash
+		num   uint64
+		index uint64
+	}
+	seen := map[logKey]bool{}
+	res := logs[:0]
+	for _, log := range logs {
+		key := logKey{log.BlockHash, log.BlockNumber, log.LogIndex}
+		if !seen[key] {
+			seen[key] = true
+			res = append(res, log)
+		}
+	}
+	return res
+}
+
+// splitAddresses splits the addresses in groups of at most max addresses
+func splitAddresses(addrs []web3.Address, max int) [][]web3.Address {
+	if max <= 0 || len(addrs) <= max {
+		return [][]web3.Address{addrs}
+	}
+
+	// the same address twice would return its logs twice
+	unique := []web3.Address{}
+	seen := map[web3.Address]bool{}
+	for _, addr := range addrs {
+		if !seen[addr] {
+			seen[addr] = true
+			unique = append(unique, addr)
+		}
+	}
+
+	res := [][]web3.Address{}
+	for len(unique) > 0 {
+		n := max
+		if n > len(unique) {
+			n = len(unique)
+		}
+		res = append(res, unique[:n])
+		unique = unique[n:]
+	}
+	return res
+}
+
+// splitTopics splits the event ids (topic0) of the topics in groups of at
+// most max ids. The other positions are the same in all the groups.
+func splitTopics(topics [][]web3.Hash, max int) [][][]web3.Hash {
+	if max <= 0 || len(topics) == 0 || len(topics[0]) <= max {
+		return [][][]web3.Hash{topics}
+	}
+
+	// the same event id twice would return its logs twice
+	ids := []web3.Hash{}
+	seen := map[web3.Hash]bool{}
+	for _, id := range topics[0] {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	res := [][][]web3.Hash{}
+	for len(ids) > 0 {
+		n := max
+		if n > len(ids) {
+			n = len(ids)
+		}
+		group := append([][]web3.Hash{ids[:n]}, topics[1:]...)
+		res = append(res, group)
+		ids = ids[n:]
+	}
+	return res
+}
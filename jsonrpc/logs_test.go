@@ -0,0 +1,198 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/boolw/go-web3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthGetLogsRange(t *testing.T) {
+	var ranges [][2]uint64
+
+	m := NewMockTransport()
+	m.Handle("eth_getLogs", nil, func(params []interface{}) (interface{}, error) {
+		filter := params[0].(*web3.LogFilter)
+		from, to := uint64(*filter.From), uint64(*filter.To)
+		ranges = append(ranges, [2]uint64{from, to})
+
+		// one log per block
+		logs := []*web3.Log{}
+		for i := from; i <= to; i++ {
+			logs = append(logs, &web3.Log{
+				Address:     filter.Address[0],
+				BlockNumber: i,
+			})
+		}
+		return logs, nil
+	})
+	c := NewMockClient(m)
+
+	filter := &web3.LogFilter{
+		Address: []web3.Address{{0x1}},
+	}
+	logs, err := c.Eth().GetLogsRange(filter, 5, 29, WithBlockRange(10))
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]uint64{{5, 14}, {15, 24}, {25, 29}}, ranges)
+
+	assert.Len(t, logs, 25)
+	for indx, log := range logs {
+		assert.Equal(t, uint64(indx+5), log.BlockNumber)
+	}
+
+	// the filter is not modified
+	assert.Nil(t, filter.From)
+	assert.Nil(t, filter.To)
+
+	// a single block
+	ranges = nil
+	logs, err = c.Eth().GetLogsRange(filter, 7, 7)
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, [][2]uint64{{7, 7}}, ranges)
+
+	_, err = c.Eth().GetLogsRange(filter, 8, 7)
+	assert.Error(t, err)
+}
+
+func TestEthGetLogsRangeMaxTopics(t *testing.T) {
+	ids := []web3.Hash{{0x1}, {0x2}, {0x3}, {0x1}}
+	var queries [][]web3.Hash
+
+	m := NewMockTransport()
+	m.Handle("eth_getLogs", nil, func(params []interface{}) (interface{}, error) {
+		filter := params[0].(*web3.LogFilter)
+		queries = append(queries, filter.Topics[0])
+
+		// the second position is kept in all the queries
+		assert.Equal(t, []web3.Hash{{0x5}}, filter.Topics[1])
+
+		// one log per event id and block in reverse order of index
+		logs := []*web3.Log{}
+		for i := uint64(*filter.From); i <= uint64(*filter.To); i++ {
+			for _, id := range filter.Topics[0] {
+				logs = append(logs, &web3.Log{
+					BlockNumber: i,
+					LogIndex:    uint64(10 - id[0]),
+					Topics:      []web3.Hash{id},
+				})
+			}
+		}
+		return logs, nil
+	})
+	c := NewMockClient(m)
+
+	filter := &web3.LogFilter{
+		Topics: [][]web3.Hash{ids, {{0x5}}},
+	}
+	logs, err := c.Eth().GetLogsRange(filter, 1, 2, WithMaxTopics(2))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]web3.Hash{{{0x1}, {0x2}}, {{0x3}}}, queries)
+
+	// the logs are sorted by block and index
+	assert.Len(t, logs, 6)
+	expected := []byte{0x3, 0x2, 0x1, 0x3, 0x2, 0x1}
+	for indx, log := range logs {
+		assert.Equal(t, uint64(indx/3+1), log.BlockNumber)
+		assert.Equal(t, expected[indx], log.Topics[0][0])
+	}
+
+	// the filter is not split without the option
+	queries = nil
+	_, err = c.Eth().GetLogsRange(filter, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]web3.Hash{ids}, queries)
+}
+
+func TestEthGetLogsRangeMaxAddresses(t *testing.T) {
+	addrs := []web3.Address{{0x1}, {0x2}, {0x3}, {0x1}}
+	var queries [][]web3.Address
+
+	m := NewMockTransport()
+	m.Handle("eth_getLogs", nil, func(params []interface{}) (interface{}, error) {
+		filter := params[0].(*web3.LogFilter)
+		queries = append(queries, filter.Address)
+
+		// one log per address and block in reverse order of index and
+		// a log returned by all the queries
+		logs := []*web3.Log{}
+		for i := uint64(*filter.From); i <= uint64(*filter.To); i++ {
+			for _, addr := range filter.Address {
+				logs = append(logs, &web3.Log{
+					BlockNumber: i,
+					LogIndex:    uint64(10 - addr[0]),
+					Address:     addr,
+				})
+			}
+			logs = append(logs, &web3.Log{BlockNumber: i, LogIndex: 20})
+		}
+		return logs, nil
+	})
+	c := NewMockClient(m)
+
+	filter := &web3.LogFilter{
+		Address: addrs,
+	}
+	logs, err := c.Eth().GetLogsRange(filter, 1, 2, WithMaxAddresses(2), WithBlockRange(1))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]web3.Address{
+		{{0x1}, {0x2}}, {{0x3}},
+		{{0x1}, {0x2}}, {{0x3}},
+	}, queries)
+
+	// the logs are sorted by block and index without duplicates
+	assert.Len(t, logs, 8)
+	expected := []byte{0x3, 0x2, 0x1, 0x0, 0x3, 0x2, 0x1, 0x0}
+	for indx, log := range logs {
+		assert.Equal(t, uint64(indx/4+1), log.BlockNumber)
+		assert.Equal(t, expected[indx], log.Address[0])
+	}
+}
+
+func TestEthGetLogsRangeSplitErrors(t *testing.T) {
+	var ranges [][2]uint64
+
+	m := NewMockTransport()
+	m.Handle("eth_getLogs", nil, func(params []interface{}) (interface{}, error) {
+		filter := params[0].(*web3.LogFilter)
+		from, to := uint64(*filter.From), uint64(*filter.To)
+		ranges = append(ranges, [2]uint64{from, to})
+
+		// the node only accepts ranges of at most 3 blocks
+		if to-from >= 3 {
+			return nil, fmt.Errorf("Query returned more than 10000 results")
+		}
+		logs := []*web3.Log{}
+		for i := from; i <= to; i++ {
+			logs = append(logs, &web3.Log{BlockNumber: i})
+		}
+		return logs, nil
+	})
+	c := NewMockClient(m)
+
+	filter := &web3.LogFilter{}
+	logs, err := c.Eth().GetLogsRange(filter, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]uint64{{1, 10}, {1, 5}, {1, 3}, {4, 5}, {6, 10}, {6, 8}, {9, 10}}, ranges)
+
+	assert.Len(t, logs, 10)
+	for indx, log := range logs {
+		assert.Equal(t, uint64(indx+1), log.BlockNumber)
+	}
+
+	// the other errors are not retried
+	m.RespondError("eth_getLogs", nil, fmt.Errorf("custom limit reached"))
+
+	calls := m.Calls("eth_getLogs")
+	_, err = c.Eth().GetLogsRange(filter, 1, 10)
+	assert.Error(t, err)
+	assert.Equal(t, 1, m.Calls("eth_getLogs")-calls)
+
+	// unless they are added to the split errors. The range is split
+	// down to a single block before failing.
+	calls = m.Calls("eth_getLogs")
+	_, err = c.Eth().GetLogsRange(filter, 1, 10, WithRangeSplitErrors([]string{"Custom Limit"}))
+	assert.Error(t, err)
+	assert.Equal(t, 5, m.Calls("eth_getLogs")-calls)
+}
@@ -0,0 +1,203 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/boolw/go-web3/jsonrpc/transport"
+)
+
+// MockRequest is a request received by the mock transport
+type MockRequest struct {
+	Method string
+	Params []interface{}
+}
+
+// MockHandler computes the response of a mock request
+type MockHandler func(params []interface{}) (interface{}, error)
+
+// MockTransport is an in-process transport with canned responses to test
+// code that uses the client without a node. The responses are matched by
+// method and params, a response registered without params matches any params.
+type MockTransport struct {
+	lock     sync.Mutex
+	handlers map[string]MockHandler
+	requests []*MockRequest
+
+	// subscriptions by method
+	subs  map[string]map[int]func(b []byte)
+	subID int
+}
+
+// NewMockTransport creates a new mock transport
+func NewMockTransport() *MockTransport {
+	return &MockTransport{
+		handlers: map[string]MockHandler{},
+		subs:     map[string]map[int]func(b []byte){},
+	}
+}
+
+// NewMockClient creates a client that uses the mock transport
+func NewMockClient(m *MockTransport, opts ...ClientOption) *Client {
+	return NewClientWithTransport(m, opts...)
+}
+
+// Respond registers the result for the method and params. The result is
+// encoded in json, use a json.RawMessage to respond with raw json.
+func (m *MockTransport) Respond(method string, params []interface{}, result interface{}) {
+	m.Handle(method, params, func([]interface{}) (interface{}, error) {
+		return result, nil
+	})
+}
+
+// RespondError registers an error for the method and params
+func (m *MockTransport) RespondError(method string, params []interface{}, err error) {
+	m.Handle(method, params, func([]interface{}) (interface{}, error) {
+		return nil, err
+	})
+}
+
+// Handle registers a handler for the method and params
+func (m *MockTransport) Handle(method string, params []interface{}, handler MockHandler) {
+	key := mockKey(method, params)
+
+	m.lock.Lock()
+	m.handlers[key] = handler
+	m.lock.Unlock()
+}
+
+// Requests returns the requests received in order
+func (m *MockTransport) Requests() []*MockRequest {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	res := make([]*MockRequest, len(m.requests))
+	copy(res, m.requests)
+	return res
+}
+
+// Calls returns the number of requests received for the method
+func (m *MockTransport) Calls(method string) int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	count := 0
+	for _, req := range m.requests {
+		if req.Method == method {
+			count++
+		}
+	}
+	return count
+}
+
+// Call implements the transport interface
+func (m *MockTransport) Call(method string, out interface{}, params ...interface{}) error {
+	m.lock.Lock()
+	m.requests = append(m.requests, &MockRequest{Method: method, Params: params})
+
+	handler, ok := m.handlers[method+mockParams(params)]
+	if !ok {
+		handler, ok = m.handlers[mockKey(method, nil)]
+	}
+	m.lock.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no mock response for method %s with params %s", method, mockParams(params))
+	}
+	result, err := handler(params)
+	if err != nil {
+		return err
+	}
+
+	// encode the result so that it is decoded as if it came from a node
+	raw, ok := result.(json.RawMessage)
+	if !ok {
+		if raw, err = json.Marshal(result); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// BatchCall implements the BatchTransport interface
+func (m *MockTransport) BatchCall(batch []*transport.BatchElem) error {
+	for _, elem := range batch {
+		elem.Error = m.Call(elem.Method, elem.Result, elem.Params...)
+	}
+	return nil
+}
+
+// Subscribe implements the PubSubTransport interface. The subscription
+// is recorded as an eth_subscribe request.
+func (m *MockTransport) Subscribe(method string, callback func(b []byte), params ...interface{}) (func() error, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.requests = append(m.requests, &MockRequest{Method: "eth_subscribe", Params: append([]interface{}{method}, params...)})
+
+	m.subID++
+	id := m.subID
+	if _, ok := m.subs[method]; !ok {
+		m.subs[method] = map[int]func(b []byte){}
+	}
+	m.subs[method][id] = callback
+
+	cancel := func() error {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		if _, ok := m.subs[method][id]; !ok {
+			return fmt.Errorf("subscription %d not found", id)
+		}
+		delete(m.subs[method], id)
+		return nil
+	}
+	return cancel, nil
+}
+
+// Notify sends the result encoded in json to the subscriptions of the method
+func (m *MockTransport) Notify(method string, result interface{}) error {
+	raw, ok := result.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(result); err != nil {
+			return err
+		}
+	}
+
+	m.lock.Lock()
+	callbacks := []func(b []byte){}
+	for _, callback := range m.subs[method] {
+		callbacks = append(callbacks, callback)
+	}
+	m.lock.Unlock()
+
+	for _, callback := range callbacks {
+		callback(raw)
+	}
+	return nil
+}
+
+// Close implements the transport interface
+func (m *MockTransport) Close() error {
+	return nil
+}
+
+func mockKey(method string, params []interface{}) string {
+	if params == nil {
+		return method
+	}
+	return method + mockParams(params)
+}
+
+func mockParams(params []interface{}) string {
+	if len(params) == 0 {
+		return "[]"
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Sprintf("%v", params)
+	}
+	return string(data)
+}
@@ -0,0 +1,48 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/boolw/go-web3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockTransport(t *testing.T) {
+	addr0 := web3.Address{0x1}
+	addr1 := web3.Address{0x2}
+
+	m := NewMockTransport()
+	m.Respond("eth_getBalance", []interface{}{addr0, "latest"}, "0x10")
+	m.RespondError("eth_getBalance", []interface{}{addr1, "latest"}, fmt.Errorf("failed"))
+	m.Respond("eth_blockNumber", nil, "0x5")
+
+	c := NewMockClient(m)
+
+	balance, err := c.Eth().GetBalance(addr0, web3.Latest)
+	assert.NoError(t, err)
+	assert.Equal(t, balance, big.NewInt(0x10))
+
+	_, err = c.Eth().GetBalance(addr1, web3.Latest)
+	assert.Error(t, err)
+
+	// no response for these params
+	_, err = c.Eth().GetBalance(web3.Address{0x3}, web3.Latest)
+	assert.Error(t, err)
+
+	num, err := c.Eth().BlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, num, uint64(5))
+
+	// batches use the same responses
+	balances, err := c.Eth().GetBalances([]web3.Address{addr0, addr0}, web3.Latest)
+	assert.NoError(t, err)
+	assert.Len(t, balances, 2)
+
+	assert.Equal(t, m.Calls("eth_getBalance"), 5)
+	reqs := m.Requests()
+	assert.Len(t, reqs, 6)
+	assert.Equal(t, reqs[3].Method, "eth_blockNumber")
+	assert.Equal(t, reqs[0].Params[0], addr0)
+}
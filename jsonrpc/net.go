@@ -12,11 +12,11 @@ func (c *Client) Net() *Net {
 
 // Version returns the current network id
 func (n *Net) Version() (uint64, error) {
-	var out string
+	var out quantity
 	if err := n.c.Call("net_version", &out); err != nil {
 		return 0, err
 	}
-	return parseUint64orHex(out)
+	return parseUint64orHex(string(out))
 }
 
 // Listening returns true if client is actively listening for network connections
@@ -28,9 +28,9 @@ func (n *Net) Listening() (bool, error) {
 
 // PeerCount returns number of peers currently connected to the client
 func (n *Net) PeerCount() (uint64, error) {
-	var out string
+	var out quantity
 	if err := n.c.Call("net_peerCount", &out); err != nil {
 		return 0, err
 	}
-	return parseUint64orHex(out)
+	return parseUint64orHex(string(out))
 }
@@ -0,0 +1,151 @@
+package jsonrpc
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/boolw/go-web3"
+)
+
+// prepareGasBuffer is the percentage added to the estimated gas since
+// the estimation can fall short if the state changes before the inclusion
+const prepareGasBuffer = 20
+
+// PrepareTransaction returns a transaction ready to be signed with the nonce
+// (including the pending transactions), the fees suggested by the gas oracle,
+// the estimated gas plus a buffer and the chain id. A nil to creates a contract.
+// The context is checked between the requests to the node.
+func (e *Eth) PrepareTransaction(ctx context.Context, from web3.Address, to *web3.Address, value *big.Int, data []byte) (*web3.Transaction, error) {
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	txn := &web3.Transaction{
+		From:  from,
+		Value: new(big.Int).Set(value),
+		Input: data,
+	}
+	if to != nil {
+		txn.To = to.String()
+	}
+
+	var err error
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	if txn.Nonce, err = e.PendingNonce(from); err != nil {
+		return nil, err
+	}
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	if txn.ChainID, err = e.ChainID(); err != nil {
+		return nil, err
+	}
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err = e.setSuggestedFees(txn); err != nil {
+		return nil, err
+	}
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err = e.setEstimatedGas(txn); err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+type sendConfig struct {
+	pendingNonce bool
+}
+
+// SendOption is an option of SendTransaction
+type SendOption func(*sendConfig)
+
+// WithPendingNonce sets the nonce of the transaction to the pending nonce of
+// the sender (see PendingNonce) instead of its Nonce field. Without it the
+// nonce is sent as it is, since a zero nonce is a valid explicit value
+// (i.e. to replace the first transaction of an account).
+func WithPendingNonce() SendOption {
+	return func(c *sendConfig) {
+		c.pendingNonce = true
+	}
+}
+
+// fillTransaction sets the fees and the gas of the transaction if they are
+// zero and the nonce (including the pending transactions) if fillNonce is
+// set. The explicit values are kept.
+func (e *Eth) fillTransaction(txn *web3.Transaction, fillNonce bool) error {
+	var err error
+	if fillNonce {
+		if txn.Nonce, err = e.PendingNonce(txn.From); err != nil {
+			return err
+		}
+	}
+	if txn.GasPrice == 0 && txn.MaxFeePerGas == nil && txn.MaxPriorityFeePerGas == nil {
+		if err = e.setSuggestedFees(txn); err != nil {
+			return err
+		}
+	}
+	if txn.Gas == 0 {
+		if err = e.setEstimatedGas(txn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setSuggestedFees sets the fees suggested by the gas oracle. On eip-1559
+// chains the transaction becomes a dynamic fee transaction.
+func (e *Eth) setSuggestedFees(txn *web3.Transaction) error {
+	fees, err := e.c.getGasOracle().Suggest(GasStandard)
+	if err != nil {
+		return err
+	}
+	if fees.IsLegacy() {
+		txn.GasPrice = fees.GasPrice.Uint64()
+	} else {
+		txn.Type = web3.TransactionDynamicFee
+		txn.MaxFeePerGas = fees.MaxFeePerGas
+		txn.MaxPriorityFeePerGas = fees.MaxPriorityFeePerGas
+	}
+	return nil
+}
+
+// setEstimatedGas sets the estimated gas plus a buffer
+func (e *Eth) setEstimatedGas(txn *web3.Transaction) error {
+	gas, err := e.estimateGasTxn(txn)
+	if err != nil {
+		return err
+	}
+	txn.Gas = gas + gas*prepareGasBuffer/100
+	return nil
+}
+
+// estimateGasTxn estimates the gas of the transaction. Unlike CallMsg it
+// can be a contract creation.
+func (e *Eth) estimateGasTxn(txn *web3.Transaction) (uint64, error) {
+	msg := map[string]interface{}{
+		"from":  txn.From,
+		"value": "0x0",
+	}
+	if txn.Value != nil {
+		msg["value"] = "0x" + txn.Value.Text(16)
+	}
+	if txn.To != "" {
+		msg["to"] = txn.To
+	}
+	if len(txn.Input) != 0 {
+		msg["data"] = encodeToHex(txn.Input)
+	}
+
+	var out quantity
+	if err := e.c.Call("eth_estimateGas", &out, msg); err != nil {
+		return 0, err
+	}
+	return parseUint64orHex(string(out))
+}
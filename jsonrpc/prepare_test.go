@@ -0,0 +1,205 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/boolw/go-web3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrepareTransaction(t *testing.T) {
+	from := web3.Address{0x1}
+	to := web3.Address{0x2}
+
+	m := NewMockTransport()
+	m.Respond("eth_getTransactionCount", []interface{}{from, "pending"}, "0x5")
+	m.Respond("eth_chainId", nil, "0x1")
+	m.Respond("eth_feeHistory", nil, json.RawMessage(`{
+		"oldestBlock": "0x1",
+		"baseFeePerGas": ["0x10", "0x20"],
+		"gasUsedRatio": [0.5],
+		"reward": [["0x1", "0x2", "0x3"]]
+	}`))
+
+	var estimated map[string]interface{}
+	m.Handle("eth_estimateGas", nil, func(params []interface{}) (interface{}, error) {
+		estimated = params[0].(map[string]interface{})
+		return "0x5208", nil
+	})
+
+	c := NewMockClient(m)
+
+	txn, err := c.Eth().PrepareTransaction(context.Background(), from, &to, big.NewInt(10), []byte{0x1})
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(5), txn.Nonce)
+	assert.Equal(t, big.NewInt(1), txn.ChainID)
+	assert.Equal(t, web3.TransactionDynamicFee, txn.Type)
+	assert.Equal(t, big.NewInt(2), txn.MaxPriorityFeePerGas)
+	assert.Equal(t, big.NewInt(2*0x20+2), txn.MaxFeePerGas)
+	assert.Equal(t, uint64(21000*120/100), txn.Gas)
+	assert.Equal(t, to.String(), txn.To)
+
+	assert.Equal(t, from, estimated["from"])
+	assert.Equal(t, to.String(), estimated["to"])
+	assert.Equal(t, "0xa", estimated["value"])
+	assert.Equal(t, "0x01", estimated["data"])
+
+	// the fee history is cached by the gas oracle of the client
+	_, err = c.Eth().PrepareTransaction(context.Background(), from, &to, big.NewInt(10), []byte{0x1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, m.Calls("eth_feeHistory"))
+
+	// contract creation in a legacy chain, setting the endpoint again
+	// resets the gas oracle
+	m.RespondError("eth_feeHistory", nil, fmt.Errorf("method not found"))
+	m.Respond("eth_gasPrice", nil, "0x100")
+	c.SetTransport(m)
+
+	txn, err = c.Eth().PrepareTransaction(context.Background(), from, nil, nil, []byte{0x1})
+	assert.NoError(t, err)
+	assert.Equal(t, web3.TransactionLegacy, txn.Type)
+	assert.Equal(t, uint64(0x100), txn.GasPrice)
+	assert.Equal(t, "", txn.To)
+
+	_, ok := estimated["to"]
+	assert.False(t, ok)
+	assert.Equal(t, "0x0", estimated["value"])
+}
+
+func TestPrepareTransactionCancel(t *testing.T) {
+	m := NewMockTransport()
+	c := NewMockClient(m)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.Eth().PrepareTransaction(ctx, web3.Address{0x1}, nil, nil, nil)
+	assert.Equal(t, context.Canceled, err)
+	assert.Len(t, m.Requests(), 0)
+}
+
+func TestEthSendTransactionFill(t *testing.T) {
+	from := web3.Address{0x1}
+
+	m := NewMockTransport()
+	m.Respond("eth_getTransactionCount", []interface{}{from, "pending"}, "0x5")
+	m.RespondError("eth_feeHistory", nil, fmt.Errorf("method not found"))
+	m.Respond("eth_gasPrice", nil, "0x100")
+	m.Respond("eth_estimateGas", nil, "0x5208")
+
+	var sent *web3.Transaction
+	m.Handle("eth_sendTransaction", nil, func(params []interface{}) (interface{}, error) {
+		sent = params[0].(*web3.Transaction)
+		return web3.Hash{0x1}, nil
+	})
+	c := NewMockClient(m)
+
+	// the missing fields are set
+	txn := &web3.Transaction{
+		From: from,
+		To:   web3.Address{0x2}.String(),
+	}
+	hash, err := c.Eth().SendTransaction(txn, WithPendingNonce())
+	assert.NoError(t, err)
+	assert.Equal(t, web3.Hash{0x1}, hash)
+
+	assert.Equal(t, uint64(5), sent.Nonce)
+	assert.Equal(t, uint64(0x100), sent.GasPrice)
+	assert.Equal(t, uint64(21000*120/100), sent.Gas)
+
+	// the transaction is not modified
+	assert.Equal(t, uint64(0), txn.Nonce)
+	assert.Equal(t, uint64(0), txn.Gas)
+
+	// the explicit values are kept
+	txn = &web3.Transaction{
+		From:     from,
+		To:       web3.Address{0x2}.String(),
+		Nonce:    7,
+		Gas:      50000,
+		GasPrice: 10,
+	}
+	_, err = c.Eth().SendTransaction(txn)
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(7), sent.Nonce)
+	assert.Equal(t, uint64(10), sent.GasPrice)
+	assert.Equal(t, uint64(50000), sent.Gas)
+	assert.Equal(t, 1, m.Calls("eth_getTransactionCount"))
+	assert.Equal(t, 1, m.Calls("eth_estimateGas"))
+
+	// explicit eip-1559 fees are kept
+	txn = &web3.Transaction{
+		From:                 from,
+		Nonce:                7,
+		Gas:                  50000,
+		Type:                 web3.TransactionDynamicFee,
+		MaxFeePerGas:         big.NewInt(20),
+		MaxPriorityFeePerGas: big.NewInt(2),
+	}
+	_, err = c.Eth().SendTransaction(txn)
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(0), sent.GasPrice)
+	assert.Equal(t, big.NewInt(20), sent.MaxFeePerGas)
+	assert.Equal(t, 1, m.Calls("eth_gasPrice"))
+
+	// a zero nonce is kept without WithPendingNonce
+	txn = &web3.Transaction{
+		From:     from,
+		Nonce:    0,
+		Gas:      50000,
+		GasPrice: 10,
+	}
+	_, err = c.Eth().SendTransaction(txn)
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(0), sent.Nonce)
+	assert.Equal(t, 1, m.Calls("eth_getTransactionCount"))
+}
+
+func TestEthSendTransactionDynamicFeeJSON(t *testing.T) {
+	from := web3.Address{0x1}
+
+	m := NewMockTransport()
+	m.Respond("eth_feeHistory", nil, json.RawMessage(`{
+		"oldestBlock": "0x1",
+		"baseFeePerGas": ["0x10", "0x20"],
+		"gasUsedRatio": [0.5],
+		"reward": [["0x1", "0x2", "0x3"]]
+	}`))
+
+	// the mock does not marshal the params, do it as the http transport does
+	var sent map[string]interface{}
+	m.Handle("eth_sendTransaction", nil, func(params []interface{}) (interface{}, error) {
+		data, err := json.Marshal(params[0])
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &sent); err != nil {
+			return nil, err
+		}
+		return web3.Hash{0x1}, nil
+	})
+	c := NewMockClient(m)
+
+	txn := &web3.Transaction{
+		From: from,
+		To:   web3.Address{0x2}.String(),
+		Gas:  21000,
+	}
+	_, err := c.Eth().SendTransaction(txn)
+	assert.NoError(t, err)
+
+	// the auto-filled eip-1559 fees are sent without a gas price
+	_, ok := sent["gasPrice"]
+	assert.False(t, ok)
+	assert.Equal(t, "0x2", sent["type"])
+	assert.Equal(t, "0x42", sent["maxFeePerGas"])
+	assert.Equal(t, "0x2", sent["maxPriorityFeePerGas"])
+}
@@ -0,0 +1,87 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/boolw/go-web3"
+)
+
+var (
+	// eip1967ImplementationSlot is keccak256("eip1967.proxy.implementation") - 1
+	eip1967ImplementationSlot = eip1967Slot("eip1967.proxy.implementation")
+
+	// eip1967BeaconSlot is keccak256("eip1967.proxy.beacon") - 1
+	eip1967BeaconSlot = eip1967Slot("eip1967.proxy.beacon")
+
+	// zeppelinOSImplementationSlot is the slot of the legacy OpenZeppelin proxies
+	zeppelinOSImplementationSlot = web3.Keccak256([]byte("org.zeppelinos.proxy.implementation"))
+
+	// beaconImplementationSig is the selector of implementation() in the beacon
+	beaconImplementationSig = []byte{0x5c, 0x60, 0xda, 0x1b}
+)
+
+func eip1967Slot(name string) web3.Hash {
+	hash := web3.Keccak256([]byte(name))
+	num := new(big.Int).SetBytes(hash[:])
+	num.Sub(num, big.NewInt(1))
+
+	var slot web3.Hash
+	b := num.Bytes()
+	copy(slot[32-len(b):], b)
+	return slot
+}
+
+// GetImplementation returns the implementation of a proxy contract. It reads
+// the EIP-1967 implementation slot, the EIP-1967 beacon slot (and calls the
+// beacon) and the slot of the legacy OpenZeppelin proxies in that order.
+func (e *Eth) GetImplementation(proxy web3.Address, block web3.BlockNumber) (web3.Address, error) {
+	addr, err := e.getStorageAddress(proxy, eip1967ImplementationSlot, block)
+	if err != nil || !addr.IsZero() {
+		return addr, err
+	}
+
+	beacon, err := e.getStorageAddress(proxy, eip1967BeaconSlot, block)
+	if err != nil {
+		return web3.Address{}, err
+	}
+	if !beacon.IsZero() {
+		msg := &web3.CallMsg{
+			To:   beacon,
+			Data: beaconImplementationSig,
+		}
+		out, err := e.Call(msg, block)
+		if err != nil {
+			return web3.Address{}, err
+		}
+		return parseAddressWord(out)
+	}
+
+	addr, err = e.getStorageAddress(proxy, zeppelinOSImplementationSlot, block)
+	if err != nil || !addr.IsZero() {
+		return addr, err
+	}
+	return web3.Address{}, fmt.Errorf("no implementation found for %s", proxy)
+}
+
+func (e *Eth) getStorageAddress(addr web3.Address, slot web3.Hash, block web3.BlockNumber) (web3.Address, error) {
+	out, err := e.GetStorageAt(addr, slot, block)
+	if err != nil {
+		return web3.Address{}, err
+	}
+	return parseAddressWord(out)
+}
+
+// parseAddressWord parses the address in the last 20 bytes of a 32 bytes word
+func parseAddressWord(str string) (web3.Address, error) {
+	buf, err := parseHexBytes(str)
+	if err != nil {
+		return web3.Address{}, err
+	}
+	if len(buf) != 32 {
+		return web3.Address{}, fmt.Errorf("expected 32 bytes but found %d", len(buf))
+	}
+	var addr web3.Address
+	copy(addr[:], buf[12:])
+	return addr, nil
+}
@@ -0,0 +1,63 @@
+package jsonrpc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/boolw/go-web3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxySlots(t *testing.T) {
+	assert.Equal(t, "0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc", eip1967ImplementationSlot.String())
+	assert.Equal(t, "0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50", eip1967BeaconSlot.String())
+	assert.Equal(t, "0x7050c9e0f4ca769c69bd3a8ef740bc37934f8e2c036e5a723fd8ee048ed3f8c3", zeppelinOSImplementationSlot.String())
+}
+
+func TestEthGetImplementation(t *testing.T) {
+	proxy := web3.Address{0x1}
+	impl := web3.Address{0x2}
+	beacon := web3.Address{0x3}
+
+	word := func(addr web3.Address) string {
+		return "0x" + strings.Repeat("00", 12) + strings.TrimPrefix(addr.String(), "0x")
+	}
+	empty := word(web3.Address{})
+
+	slots := func(implementation, beacon, zeppelin string) *MockTransport {
+		m := NewMockTransport()
+		m.Respond("eth_getStorageAt", []interface{}{proxy, eip1967ImplementationSlot, "latest"}, implementation)
+		m.Respond("eth_getStorageAt", []interface{}{proxy, eip1967BeaconSlot, "latest"}, beacon)
+		m.Respond("eth_getStorageAt", []interface{}{proxy, zeppelinOSImplementationSlot, "latest"}, zeppelin)
+		return m
+	}
+
+	// eip-1967
+	m := slots(word(impl), empty, empty)
+	addr, err := NewMockClient(m).Eth().GetImplementation(proxy, web3.Latest)
+	assert.NoError(t, err)
+	assert.Equal(t, impl, addr)
+
+	// eip-1967 beacon
+	m = slots(empty, word(beacon), empty)
+	m.Handle("eth_call", nil, func(params []interface{}) (interface{}, error) {
+		msg := params[0].(*web3.CallMsg)
+		assert.Equal(t, beacon, msg.To)
+		assert.Equal(t, beaconImplementationSig, msg.Data)
+		return word(impl), nil
+	})
+	addr, err = NewMockClient(m).Eth().GetImplementation(proxy, web3.Latest)
+	assert.NoError(t, err)
+	assert.Equal(t, impl, addr)
+
+	// legacy openzeppelin
+	m = slots(empty, empty, word(impl))
+	addr, err = NewMockClient(m).Eth().GetImplementation(proxy, web3.Latest)
+	assert.NoError(t, err)
+	assert.Equal(t, impl, addr)
+
+	// not a proxy
+	m = slots(empty, empty, empty)
+	_, err = NewMockClient(m).Eth().GetImplementation(proxy, web3.Latest)
+	assert.Error(t, err)
+}
@@ -0,0 +1,51 @@
+package jsonrpc
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket that refills rate tokens per second up to
+// burst tokens. The requests take the tokens in advance so that a batch
+// larger than the burst waits for all of its tokens instead of blocking.
+type rateLimiter struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rps, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:   float64(rps),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until n requests can be sent
+func (r *rateLimiter) wait(n int) {
+	r.lock.Lock()
+	now := time.Now()
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+	r.last = now
+	r.tokens -= float64(n)
+
+	var delay time.Duration
+	if r.tokens < 0 {
+		delay = time.Duration(-r.tokens / r.rate * float64(time.Second))
+	}
+	r.lock.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,131 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/boolw/go-web3"
+)
+
+// minReplacementBump is the minimum percentage the nodes require to bump
+// the fees of a pending transaction to replace it
+const minReplacementBump = 10
+
+// cancelGas is the gas of the 0-value self-transfer that cancels a transaction
+const cancelGas = 21000
+
+// ReplaceTransaction returns a copy of the pending transaction ready to be
+// signed with the same nonce and the fees (maxFeePerGas and
+// maxPriorityFeePerGas or gasPrice) bumped by the percentage. The percentage
+// is raised to the 10% minimum the nodes require to accept a replacement and
+// the fees are never lower than the ones suggested by the gas oracle.
+func (e *Eth) ReplaceTransaction(original *web3.Transaction, bumpPercent int) (*web3.Transaction, error) {
+	if original == nil {
+		return nil, fmt.Errorf("no transaction to replace")
+	}
+	if bumpPercent < 0 {
+		return nil, fmt.Errorf("negative bump percentage %d", bumpPercent)
+	}
+	if bumpPercent < minReplacementBump {
+		bumpPercent = minReplacementBump
+	}
+
+	txn := copyTransaction(original)
+	suggested, err := e.c.getGasOracle().Suggest(GasStandard)
+	if err != nil {
+		return nil, err
+	}
+
+	if txn.Type == web3.TransactionDynamicFee {
+		if txn.MaxFeePerGas == nil || txn.MaxPriorityFeePerGas == nil {
+			return nil, fmt.Errorf("the transaction does not have eip-1559 fees")
+		}
+		txn.MaxFeePerGas = bumpFee(txn.MaxFeePerGas, bumpPercent)
+		txn.MaxPriorityFeePerGas = bumpFee(txn.MaxPriorityFeePerGas, bumpPercent)
+
+		if suggested.IsLegacy() {
+			txn.MaxFeePerGas = maxBig(txn.MaxFeePerGas, suggested.GasPrice)
+		} else {
+			txn.MaxFeePerGas = maxBig(txn.MaxFeePerGas, suggested.MaxFeePerGas)
+			txn.MaxPriorityFeePerGas = maxBig(txn.MaxPriorityFeePerGas, suggested.MaxPriorityFeePerGas)
+		}
+		// the priority fee cannot be higher than the max fee
+		txn.MaxFeePerGas = maxBig(txn.MaxFeePerGas, txn.MaxPriorityFeePerGas)
+	} else {
+		if txn.GasPrice == 0 {
+			return nil, fmt.Errorf("the transaction does not have a gas price")
+		}
+		gasPrice := bumpFee(new(big.Int).SetUint64(txn.GasPrice), bumpPercent)
+		if suggested.IsLegacy() {
+			gasPrice = maxBig(gasPrice, suggested.GasPrice)
+		} else {
+			gasPrice = maxBig(gasPrice, suggested.MaxFeePerGas)
+		}
+		if !gasPrice.IsUint64() {
+			return nil, fmt.Errorf("gas price %s overflows", gasPrice)
+		}
+		txn.GasPrice = gasPrice.Uint64()
+	}
+	return txn, nil
+}
+
+// CancelTransaction returns a 0-value self-transfer ready to be signed that
+// replaces the pending transaction with the same nonce and the fees bumped
+// as in ReplaceTransaction.
+func (e *Eth) CancelTransaction(original *web3.Transaction, bumpPercent int) (*web3.Transaction, error) {
+	txn, err := e.ReplaceTransaction(original, bumpPercent)
+	if err != nil {
+		return nil, err
+	}
+	txn.To = txn.From.String()
+	txn.Value = big.NewInt(0)
+	txn.Input = nil
+	txn.AccessList = nil
+	txn.Gas = cancelGas
+	return txn, nil
+}
+
+// copyTransaction copies the fields of the transaction that are signed.
+// The hash, the signature and the fields of the block are not copied.
+func copyTransaction(t *web3.Transaction) *web3.Transaction {
+	txn := &web3.Transaction{
+		From:     t.From,
+		To:       t.To,
+		GasPrice: t.GasPrice,
+		Gas:      t.Gas,
+		Nonce:    t.Nonce,
+		Type:     t.Type,
+	}
+	if t.Input != nil {
+		txn.Input = append([]byte{}, t.Input...)
+	}
+	if t.AccessList != nil {
+		txn.AccessList = append(web3.AccessList{}, t.AccessList...)
+	}
+	txn.Value = copyBig(t.Value)
+	txn.ChainID = copyBig(t.ChainID)
+	txn.MaxFeePerGas = copyBig(t.MaxFeePerGas)
+	txn.MaxPriorityFeePerGas = copyBig(t.MaxPriorityFeePerGas)
+	return txn
+}
+
+// bumpFee increases the fee by the percentage rounding up
+func bumpFee(fee *big.Int, percent int) *big.Int {
+	res := new(big.Int).Mul(fee, big.NewInt(int64(100+percent)))
+	res.Add(res, big.NewInt(99))
+	return res.Div(res, big.NewInt(100))
+}
+
+func copyBig(i *big.Int) *big.Int {
+	if i == nil {
+		return nil
+	}
+	return new(big.Int).Set(i)
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if b != nil && b.Cmp(a) > 0 {
+		return new(big.Int).Set(b)
+	}
+	return a
+}
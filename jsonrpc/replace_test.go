@@ -0,0 +1,104 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/boolw/go-web3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthReplaceTransaction(t *testing.T) {
+	m := NewMockTransport()
+	m.Respond("eth_feeHistory", nil, json.RawMessage(`{
+		"oldestBlock": "0x1",
+		"baseFeePerGas": ["0x10", "0x20"],
+		"gasUsedRatio": [0.5],
+		"reward": [["0x1", "0x2", "0x3"]]
+	}`))
+	c := NewMockClient(m)
+
+	original := &web3.Transaction{
+		Hash:                 web3.Hash{0x1},
+		From:                 web3.Address{0x1},
+		To:                   web3.Address{0x2}.String(),
+		Input:                []byte{0x1, 0x2},
+		Value:                big.NewInt(10),
+		Gas:                  50000,
+		Nonce:                7,
+		Type:                 web3.TransactionDynamicFee,
+		ChainID:              big.NewInt(1),
+		MaxFeePerGas:         big.NewInt(1000),
+		MaxPriorityFeePerGas: big.NewInt(15),
+	}
+
+	// the bump is raised to 10%
+	txn, err := c.Eth().ReplaceTransaction(original, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), txn.Nonce)
+	assert.Equal(t, big.NewInt(1100), txn.MaxFeePerGas)
+	assert.Equal(t, big.NewInt(17), txn.MaxPriorityFeePerGas)
+	assert.Equal(t, original.To, txn.To)
+	assert.Equal(t, original.Input, txn.Input)
+	assert.Equal(t, uint64(50000), txn.Gas)
+	assert.Equal(t, web3.Hash{}, txn.Hash)
+
+	// the original is not modified
+	assert.Equal(t, big.NewInt(1000), original.MaxFeePerGas)
+	assert.Equal(t, big.NewInt(15), original.MaxPriorityFeePerGas)
+
+	// the fees are not lower than the suggested ones
+	original.MaxFeePerGas = big.NewInt(10)
+	original.MaxPriorityFeePerGas = big.NewInt(1)
+
+	txn, err = c.Eth().ReplaceTransaction(original, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(2*0x20+2), txn.MaxFeePerGas)
+	assert.Equal(t, big.NewInt(2), txn.MaxPriorityFeePerGas)
+
+	// cancel with a self-transfer
+	txn, err = c.Eth().CancelTransaction(original, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), txn.Nonce)
+	assert.Equal(t, original.From.String(), txn.To)
+	assert.Equal(t, big.NewInt(0), txn.Value)
+	assert.Nil(t, txn.Input)
+	assert.Equal(t, uint64(21000), txn.Gas)
+
+	_, err = c.Eth().ReplaceTransaction(original, -1)
+	assert.Error(t, err)
+}
+
+func TestEthReplaceTransactionLegacy(t *testing.T) {
+	m := NewMockTransport()
+	m.RespondError("eth_feeHistory", nil, fmt.Errorf("method not found"))
+	m.Respond("eth_gasPrice", nil, "0x64")
+	c := NewMockClient(m)
+
+	original := &web3.Transaction{
+		From:     web3.Address{0x1},
+		To:       web3.Address{0x2}.String(),
+		Value:    big.NewInt(0),
+		Gas:      21000,
+		Nonce:    3,
+		GasPrice: 1001,
+	}
+
+	txn, err := c.Eth().ReplaceTransaction(original, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), txn.Nonce)
+	// rounded up
+	assert.Equal(t, uint64(1102), txn.GasPrice)
+
+	// the suggested gas price is higher
+	original.GasPrice = 10
+	txn, err = c.Eth().ReplaceTransaction(original, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), txn.GasPrice)
+
+	original.GasPrice = 0
+	_, err = c.Eth().ReplaceTransaction(original, 10)
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boolw/go-web3/abi"
+	"github.com/boolw/go-web3/jsonrpc/codec"
+)
+
+// RevertError is the error of a call that reverted with a reason or
+// with a panic (i.e. a failed assert or an arithmetic overflow)
+type RevertError struct {
+	Err *codec.ErrorObject
+
+	// Reason is the decoded reason of the revert or of the panic code
+	Reason string
+
+	// Panic is true if the call reverted with the PanicCode
+	Panic     bool
+	PanicCode uint64
+}
+
+// Error implements the error interface
+func (e *RevertError) Error() string {
+	if e.Panic {
+		return fmt.Sprintf("%s: panic: %s (0x%x)", e.Err.Message, e.Reason, e.PanicCode)
+	}
+	if strings.HasSuffix(e.Err.Message, e.Reason) {
+		// the node already includes the reason (i.e. geth)
+		return e.Err.Message
+	}
+	return e.Err.Message + ": " + e.Reason
+}
+
+// wrapRevertError decodes the revert reason or the panic in the data of the error
+func wrapRevertError(err error) error {
+	obj, ok := err.(*codec.ErrorObject)
+	if !ok {
+		return err
+	}
+	data, ok := obj.Data.(string)
+	if !ok {
+		return err
+	}
+	// openethereum prefixes the revert data
+	data = strings.TrimPrefix(data, "Reverted ")
+
+	buf, decodeErr := parseHexBytes(data)
+	if decodeErr != nil {
+		return err
+	}
+	if reason, ok := abi.DecodeRevertReason(buf); ok {
+		return &RevertError{Err: obj, Reason: reason}
+	}
+	if code, reason, ok := abi.DecodePanic(buf); ok {
+		return &RevertError{Err: obj, Reason: reason, Panic: true, PanicCode: code}
+	}
+	return err
+}
@@ -0,0 +1,77 @@
+package jsonrpc
+
+import (
+	"testing"
+
+	"github.com/boolw/go-web3"
+	"github.com/boolw/go-web3/jsonrpc/codec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthCallRevertReason(t *testing.T) {
+	// revert("ERC20: insufficient allowance")
+	data := "0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"000000000000000000000000000000000000000000000000000000000000001d" +
+		"45524332303a20696e73756666696369656e7420616c6c6f77616e6365000000"
+
+	cases := []struct {
+		err      *codec.ErrorObject
+		expected string
+	}{
+		{
+			&codec.ErrorObject{Code: 3, Message: "execution reverted", Data: data},
+			"execution reverted: ERC20: insufficient allowance",
+		},
+		{
+			// the message already has the reason
+			&codec.ErrorObject{Code: 3, Message: "execution reverted: ERC20: insufficient allowance", Data: data},
+			"execution reverted: ERC20: insufficient allowance",
+		},
+		{
+			&codec.ErrorObject{Code: -32015, Message: "VM execution error.", Data: "Reverted " + data},
+			"VM execution error.: ERC20: insufficient allowance",
+		},
+	}
+	for _, c := range cases {
+		m := NewMockTransport()
+		m.RespondError("eth_call", nil, c.err)
+		cl := NewMockClient(m)
+
+		_, err := cl.Eth().Call(&web3.CallMsg{To: web3.Address{0x1}}, web3.Latest)
+		assert.Error(t, err)
+		assert.Equal(t, c.expected, err.Error())
+
+		revertErr, ok := err.(*RevertError)
+		assert.True(t, ok)
+		assert.Equal(t, "ERC20: insufficient allowance", revertErr.Reason)
+		assert.Equal(t, c.err, revertErr.Err)
+	}
+
+	// errors without a reason are not modified
+	obj := &codec.ErrorObject{Code: 3, Message: "execution reverted", Data: "0x"}
+	m := NewMockTransport()
+	m.RespondError("eth_call", nil, obj)
+	_, err := NewMockClient(m).Eth().Call(&web3.CallMsg{To: web3.Address{0x1}}, web3.Latest)
+	assert.Equal(t, obj, err)
+}
+
+func TestEthCallPanic(t *testing.T) {
+	// panic with a division by zero
+	obj := &codec.ErrorObject{
+		Code:    3,
+		Message: "execution reverted",
+		Data:    "0x4e487b71" + "0000000000000000000000000000000000000000000000000000000000000012",
+	}
+	m := NewMockTransport()
+	m.RespondError("eth_call", nil, obj)
+	_, err := NewMockClient(m).Eth().Call(&web3.CallMsg{To: web3.Address{0x1}}, web3.Latest)
+	assert.Error(t, err)
+	assert.Equal(t, "execution reverted: panic: division or modulo by zero (0x12)", err.Error())
+
+	revertErr, ok := err.(*RevertError)
+	assert.True(t, ok)
+	assert.True(t, revertErr.Panic)
+	assert.Equal(t, uint64(0x12), revertErr.PanicCode)
+	assert.Equal(t, "division or modulo by zero", revertErr.Reason)
+}
@@ -0,0 +1,149 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/boolw/go-web3"
+	"github.com/boolw/go-web3/jsonrpc/codec"
+)
+
+// OverrideAccount is the state of an account that replaces the one of the
+// block during a call. The nil fields are not replaced. State replaces the
+// whole storage of the account and StateDiff only the given slots.
+type OverrideAccount struct {
+	Nonce     *uint64
+	Code      []byte
+	Balance   *big.Int
+	State     map[web3.Hash]web3.Hash
+	StateDiff map[web3.Hash]web3.Hash
+}
+
+// MarshalJSON implements the Marshaler interface
+func (o OverrideAccount) MarshalJSON() ([]byte, error) {
+	obj := map[string]interface{}{}
+	if o.Nonce != nil {
+		obj["nonce"] = encodeUintToHex(*o.Nonce)
+	}
+	if o.Code != nil {
+		obj["code"] = encodeToHex(o.Code)
+	}
+	if o.Balance != nil {
+		obj["balance"] = "0x" + o.Balance.Text(16)
+	}
+	if o.State != nil {
+		obj["state"] = o.State
+	}
+	if o.StateDiff != nil {
+		obj["stateDiff"] = o.StateDiff
+	}
+	return json.Marshal(obj)
+}
+
+// StateOverride are the accounts replaced during a call
+type StateOverride map[web3.Address]OverrideAccount
+
+// CallWithOverride executes a new message call on the state of the block
+// with the accounts of the override replaced.
+// If the call reverts with a reason the error is a RevertError.
+func (e *Eth) CallWithOverride(msg *web3.CallMsg, block web3.BlockNumber, override StateOverride) (string, error) {
+	if len(override) == 0 {
+		return e.Call(msg, block)
+	}
+	var out string
+	if err := e.c.Call("eth_call", &out, msg, block.String(), override); err != nil {
+		return "", wrapRevertError(err)
+	}
+	return out, nil
+}
+
+// SimResult is the result of a call of a simulated bundle
+type SimResult struct {
+	// Output is the data returned by the call
+	Output []byte
+
+	// Err is the error of the call if it fails (i.e. a RevertError)
+	Err error
+}
+
+// SimulateBundle executes the calls one after the other on the state of the
+// block as if they were the transactions of a bundle. The nonce of the
+// sender of each call is increased and the value is moved from the sender to
+// the recipient for the next calls with state overrides, which start from the
+// given override. The changes of the storage and the gas fees are not
+// carried over. The calls that fail have the error in their result, the
+// error of the bundle is only returned if the node cannot be reached.
+func (e *Eth) SimulateBundle(msgs []*web3.CallMsg, block web3.BlockNumber, override StateOverride) ([]*SimResult, error) {
+	state := StateOverride{}
+	for addr, account := range override {
+		state[addr] = account
+	}
+
+	// account fills the balance and the nonce (if required) of the
+	// account with the state of the block
+	account := func(addr web3.Address, withNonce bool) (OverrideAccount, error) {
+		obj := state[addr]
+		if withNonce && obj.Nonce == nil {
+			nonce, err := e.GetNonce(addr, block)
+			if err != nil {
+				return obj, err
+			}
+			obj.Nonce = &nonce
+		}
+		if obj.Balance == nil {
+			balance, err := e.GetBalance(addr, block)
+			if err != nil {
+				return obj, err
+			}
+			obj.Balance = balance
+		}
+		return obj, nil
+	}
+
+	res := make([]*SimResult, len(msgs))
+	for indx, msg := range msgs {
+		out, err := e.CallWithOverride(msg, block, state)
+		if err != nil && !isCallError(err) {
+			return nil, err
+		}
+		res[indx] = &SimResult{Err: err}
+		if err == nil {
+			if res[indx].Output, err = parseHexBytes(out); err != nil {
+				return nil, err
+			}
+		}
+
+		// a failed transaction still uses the nonce
+		from, err := account(msg.From, true)
+		if err != nil {
+			return nil, err
+		}
+		nonce := *from.Nonce + 1
+		from.Nonce = &nonce
+
+		if res[indx].Err != nil || msg.Value == nil || msg.Value.Sign() == 0 {
+			state[msg.From] = from
+			continue
+		}
+		from.Balance = new(big.Int).Sub(from.Balance, msg.Value)
+		state[msg.From] = from
+
+		to, err := account(msg.To, false)
+		if err != nil {
+			return nil, err
+		}
+		to.Balance = new(big.Int).Add(to.Balance, msg.Value)
+		state[msg.To] = to
+	}
+	return res, nil
+}
+
+// isCallError returns true if the error is returned by the node
+// for the call and not by the transport
+func isCallError(err error) bool {
+	switch err.(type) {
+	case *RevertError, *codec.ErrorObject:
+		return true
+	}
+	return false
+}
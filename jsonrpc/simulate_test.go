@@ -0,0 +1,91 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/boolw/go-web3"
+	"github.com/boolw/go-web3/jsonrpc/codec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverrideAccountMarshal(t *testing.T) {
+	nonce := uint64(5)
+	override := StateOverride{
+		web3.Address{0x1}: {
+			Nonce:     &nonce,
+			Balance:   big.NewInt(16),
+			Code:      []byte{0x60},
+			StateDiff: map[web3.Hash]web3.Hash{{0x1}: {0x2}},
+		},
+	}
+	data, err := json.Marshal(override)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"0x0100000000000000000000000000000000000000": {
+		"nonce": "0x5",
+		"balance": "0x10",
+		"code": "0x60",
+		"stateDiff": {"0x0100000000000000000000000000000000000000000000000000000000000000": "0x0200000000000000000000000000000000000000000000000000000000000000"}
+	}}`, string(data))
+}
+
+func TestEthSimulateBundle(t *testing.T) {
+	sender, recipient := web3.Address{0x1}, web3.Address{0x2}
+
+	var overrides []StateOverride
+	m := NewMockTransport()
+	m.Respond("eth_getTransactionCount", []interface{}{sender, "0xa"}, "0x3")
+	m.Respond("eth_getBalance", []interface{}{sender, "0xa"}, "0x64")
+	m.Respond("eth_getBalance", []interface{}{recipient, "0xa"}, "0x0")
+	m.Handle("eth_call", nil, func(params []interface{}) (interface{}, error) {
+		msg := params[0].(*web3.CallMsg)
+		if len(params) > 2 {
+			// copy the override since the bundle keeps updating it
+			override := StateOverride{}
+			for addr, account := range params[2].(StateOverride) {
+				override[addr] = account
+			}
+			overrides = append(overrides, override)
+		} else {
+			overrides = append(overrides, nil)
+		}
+		if msg.Data != nil {
+			return nil, &codec.ErrorObject{Code: 3, Message: "execution reverted"}
+		}
+		return "0x01", nil
+	})
+	c := NewMockClient(m)
+
+	msgs := []*web3.CallMsg{
+		{From: sender, To: recipient, Value: big.NewInt(30)},
+		{From: sender, To: recipient, Data: []byte{0x1}, Value: big.NewInt(10)},
+		{From: sender, To: recipient, Value: big.NewInt(20)},
+	}
+	res, err := c.Eth().SimulateBundle(msgs, 10, nil)
+	assert.NoError(t, err)
+	assert.Len(t, res, 3)
+
+	assert.NoError(t, res[0].Err)
+	assert.Equal(t, []byte{0x1}, res[0].Output)
+	assert.Error(t, res[1].Err)
+	assert.NoError(t, res[2].Err)
+
+	// the first call runs on the state of the block
+	assert.Nil(t, overrides[0])
+
+	// the value of the first call is moved
+	assert.Equal(t, uint64(4), *overrides[1][sender].Nonce)
+	assert.Equal(t, "70", overrides[1][sender].Balance.String())
+	assert.Equal(t, "30", overrides[1][recipient].Balance.String())
+
+	// the reverted call only uses the nonce
+	assert.Equal(t, uint64(5), *overrides[2][sender].Nonce)
+	assert.Equal(t, "70", overrides[2][sender].Balance.String())
+	assert.Equal(t, "30", overrides[2][recipient].Balance.String())
+
+	// the transport errors fail the bundle
+	m.RespondError("eth_getBalance", []interface{}{sender, "0xa"}, assert.AnError)
+	_, err = c.Eth().SimulateBundle(msgs, 10, nil)
+	assert.Error(t, err)
+}
@@ -1,23 +1,281 @@
 package jsonrpc
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/boolw/go-web3/jsonrpc/transport"
 )
 
 // SubscriptionEnabled returns true if the subscription endpoints are enabled
 func (c *Client) SubscriptionEnabled() bool {
-	_, ok := c.transport.(transport.PubSubTransport)
+	_, ok := c.getTransport().(transport.PubSubTransport)
 	return ok
 }
 
-// Subscribe starts a new subscription
-func (c *Client) Subscribe(method string, callback func(b []byte)) (func() error, error) {
-	pub, ok := c.transport.(transport.PubSubTransport)
+// Subscribe starts a new subscription. The params are sent after the
+// method, i.e. Subscribe("logs", callback, filter).
+func (c *Client) Subscribe(method string, callback func(b []byte), params ...interface{}) (func() error, error) {
+	pub, ok := c.getTransport().(transport.PubSubTransport)
 	if !ok {
 		return nil, fmt.Errorf("Transport does not support the subscribe method")
 	}
-	close, err := pub.Subscribe(method, callback)
+	close, err := pub.Subscribe(method, callback, params...)
 	return close, err
 }
+
+// Subscription is a subscription started with SubscribeChannel
+type Subscription struct {
+	cancel    func() error
+	closeOnce sync.Once
+	closeCh   chan struct{}
+	errCh     chan error
+}
+
+// SubscribeChannel starts a subscription of any type (i.e. syncing or the
+// ones specific to a node) and sends the raw notifications to the channel.
+// The params are sent after the method. The subscription ends when it is
+// unsubscribed, when the context is done or when the connection is lost.
+func (c *Client) SubscribeChannel(ctx context.Context, method string, ch chan<- json.RawMessage, params ...interface{}) (*Subscription, error) {
+	t := c.getTransport()
+	pub, ok := t.(transport.PubSubTransport)
+	if !ok {
+		return nil, fmt.Errorf("Transport does not support the subscribe method")
+	}
+
+	sub := &Subscription{
+		closeCh: make(chan struct{}),
+		errCh:   make(chan error, 1),
+	}
+	callback := func(b []byte) {
+		select {
+		case ch <- json.RawMessage(b):
+		case <-sub.closeCh:
+		}
+	}
+	cancel, err := pub.Subscribe(method, callback, params...)
+	if err != nil {
+		return nil, err
+	}
+	sub.cancel = cancel
+
+	go sub.run(ctx, t)
+	return sub, nil
+}
+
+// Unsubscribe cancels the subscription and stops sending notifications
+func (s *Subscription) Unsubscribe() error {
+	return s.stop(nil, true)
+}
+
+// Err returns a channel that receives the error that ends the subscription
+// (i.e. the context is done or the connection is lost). The channel is
+// closed without errors after Unsubscribe.
+func (s *Subscription) Err() <-chan error {
+	return s.errCh
+}
+
+func (s *Subscription) run(ctx context.Context, t transport.Transport) {
+	// a nil channel blocks if the transport cannot notify a disconnect
+	var lost <-chan struct{}
+	if n, ok := t.(transport.ConnectionNotifier); ok {
+		lost = n.Done()
+	}
+
+	select {
+	case <-s.closeCh:
+	case <-ctx.Done():
+		s.stop(ctx.Err(), true)
+	case <-lost:
+		s.stop(fmt.Errorf("connection lost"), false)
+	}
+}
+
+func (s *Subscription) stop(err error, unsubscribe bool) error {
+	var res error
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+		if unsubscribe {
+			res = s.cancel()
+		}
+		if err != nil {
+			s.errCh <- err
+		}
+		close(s.errCh)
+	})
+	return res
+}
+
+var (
+	resubscribeMinBackoff = 1 * time.Second
+	resubscribeMaxBackoff = 30 * time.Second
+)
+
+// SubscriptionGap are the blocks a subscription may have missed while the
+// client was reconnecting
+type SubscriptionGap struct {
+	// LastBlock is the last block delivered before the disconnect
+	LastBlock uint64
+
+	// Head is the head of the chain after the reconnect
+	Head uint64
+}
+
+// Resubscribe starts a subscription that survives disconnects. When the
+// connection is lost the client reconnects to the same address and the
+// subscription is started again, the events keep being delivered to the
+// same callback. If onGap is set, it is called after a reconnect with the
+// blocks that may have been missed so that they can be backfilled
+// (i.e. with GetLogs). The params are sent after the method every time
+// the subscription is started, i.e. Resubscribe("logs", callback, onGap, filter).
+func (c *Client) Resubscribe(method string, callback func(b []byte), onGap func(gap *SubscriptionGap), params ...interface{}) (func() error, error) {
+	r := &resubscription{
+		c:        c,
+		method:   method,
+		params:   params,
+		callback: callback,
+		onGap:    onGap,
+		closeCh:  make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+
+	t := c.getTransport()
+	cancel, err := r.subscribe(t)
+	if err != nil {
+		return nil, err
+	}
+	go r.run(t, cancel)
+	return r.close, nil
+}
+
+type resubscription struct {
+	c         *Client
+	method    string
+	params    []interface{}
+	callback  func(b []byte)
+	onGap     func(gap *SubscriptionGap)
+	lastBlock uint64
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+	doneCh    chan struct{}
+	closeErr  error
+}
+
+func (r *resubscription) subscribe(t transport.Transport) (func() error, error) {
+	pub, ok := t.(transport.PubSubTransport)
+	if !ok {
+		return nil, fmt.Errorf("Transport does not support the subscribe method")
+	}
+	return pub.Subscribe(r.method, r.handle, r.params...)
+}
+
+func (r *resubscription) handle(b []byte) {
+	// both blocks (newHeads) and logs have the block number
+	var obj struct {
+		Number      quantity `json:"number"`
+		BlockNumber quantity `json:"blockNumber"`
+	}
+	if err := json.Unmarshal(b, &obj); err == nil {
+		num := obj.Number
+		if num == "" {
+			num = obj.BlockNumber
+		}
+		if n, err := parseUint64orHex(string(num)); err == nil && n > atomic.LoadUint64(&r.lastBlock) {
+			atomic.StoreUint64(&r.lastBlock, n)
+		}
+	}
+	r.callback(b)
+}
+
+func (r *resubscription) close() error {
+	select {
+	case <-r.doneCh:
+		return fmt.Errorf("subscription already closed")
+	default:
+	}
+	r.closeOnce.Do(func() {
+		close(r.closeCh)
+	})
+	<-r.doneCh
+	return r.closeErr
+}
+
+func (r *resubscription) run(t transport.Transport, cancel func() error) {
+	defer close(r.doneCh)
+
+	for {
+		// a nil channel blocks if the transport cannot notify a disconnect
+		var lost <-chan struct{}
+		if n, ok := t.(transport.ConnectionNotifier); ok {
+			lost = n.Done()
+		}
+
+		select {
+		case <-r.closeCh:
+			r.closeErr = cancel()
+			return
+		case <-lost:
+		}
+
+		if t, cancel = r.reconnect(t); t == nil {
+			return
+		}
+	}
+}
+
+// reconnect dials again with exponential backoff until the subscription is
+// started in the new connection. It returns a nil transport if the
+// subscription or the client are closed in the meantime.
+func (r *resubscription) reconnect(old transport.Transport) (transport.Transport, func() error) {
+	backoff := resubscribeMinBackoff
+	for {
+		t, err := r.c.reconnect(old)
+		if err == nil {
+			cancel, err := r.subscribe(t)
+			if err == nil {
+				r.notifyGap()
+				return t, cancel
+			}
+			old = t
+		} else {
+			r.c.lock.Lock()
+			closed := r.c.closed
+			r.c.lock.Unlock()
+			if closed {
+				return nil, nil
+			}
+		}
+
+		select {
+		case <-r.closeCh:
+			return nil, nil
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > resubscribeMaxBackoff {
+			backoff = resubscribeMaxBackoff
+		}
+	}
+}
+
+func (r *resubscription) notifyGap() {
+	if r.onGap == nil {
+		return
+	}
+	last := atomic.LoadUint64(&r.lastBlock)
+	if last == 0 {
+		// nothing delivered yet
+		return
+	}
+	head, err := r.c.Eth().BlockNumber()
+	if err != nil {
+		return
+	}
+	if head > last {
+		r.onGap(&SubscriptionGap{LastBlock: last, Head: head})
+	}
+}
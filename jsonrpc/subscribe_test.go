@@ -1,13 +1,21 @@
 package jsonrpc
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/boolw/go-web3"
+	"github.com/boolw/go-web3/jsonrpc/codec"
 	"github.com/boolw/go-web3/testutil"
+	"github.com/gorilla/websocket"
 )
 
 func TestSubscribeNewHead(t *testing.T) {
@@ -70,3 +78,239 @@ func TestSubscribeNewHead(t *testing.T) {
 		assert.Error(t, cancel())
 	})
 }
+
+// wsServer is a websocket node that only implements the subscriptions
+type wsServer struct {
+	t    *testing.T
+	lock sync.Mutex
+	conn *websocket.Conn
+	head uint64
+	srv  *httptest.Server
+
+	// subscriptions are the params of the eth_subscribe requests
+	subscriptions []json.RawMessage
+}
+
+func newWsServer(t *testing.T) *wsServer {
+	s := &wsServer{t: t}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *wsServer) addr() string {
+	return "ws://" + strings.TrimPrefix(s.srv.URL, "http://")
+}
+
+func (s *wsServer) serve(w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	s.lock.Lock()
+	s.conn = conn
+	s.lock.Unlock()
+
+	for {
+		_, buf, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req codec.Request
+		if err := json.Unmarshal(buf, &req); err != nil {
+			return
+		}
+
+		var result interface{}
+		switch req.Method {
+		case "eth_subscribe":
+			s.lock.Lock()
+			s.subscriptions = append(s.subscriptions, req.Params)
+			s.lock.Unlock()
+			result = "0x1"
+		case "eth_unsubscribe":
+			result = true
+		case "eth_blockNumber":
+			s.lock.Lock()
+			result = fmt.Sprintf("0x%x", s.head)
+			s.lock.Unlock()
+		}
+		raw, _ := json.Marshal(result)
+		s.write(conn, &codec.Response{ID: req.ID, Result: raw})
+	}
+}
+
+func (s *wsServer) write(conn *websocket.Conn, obj interface{}) {
+	raw, _ := json.Marshal(obj)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	conn.WriteMessage(websocket.TextMessage, raw)
+}
+
+// newHead sends a new head notification in the current connection
+func (s *wsServer) newHead(num uint64) {
+	s.lock.Lock()
+	s.head = num
+	conn := s.conn
+	s.lock.Unlock()
+
+	params, _ := json.Marshal(map[string]interface{}{
+		"subscription": "0x1",
+		"result":       map[string]string{"number": fmt.Sprintf("0x%x", num)},
+	})
+	s.write(conn, &codec.Request{Method: "eth_subscription", Params: params})
+}
+
+// drop closes the current connection
+func (s *wsServer) drop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.conn.Close()
+}
+
+func TestResubscribe(t *testing.T) {
+	resubscribeMinBackoff = 10 * time.Millisecond
+	defer func() {
+		resubscribeMinBackoff = 1 * time.Second
+	}()
+
+	s := newWsServer(t)
+	defer s.srv.Close()
+
+	c, err := NewClient(s.addr())
+	assert.NoError(t, err)
+	defer c.Close()
+
+	data := make(chan []byte, 10)
+	gaps := make(chan *SubscriptionGap, 10)
+
+	cancel, err := c.Resubscribe("newHeads", func(b []byte) {
+		data <- b
+	}, func(gap *SubscriptionGap) {
+		gaps <- gap
+	})
+	assert.NoError(t, err)
+
+	recv := func(num uint64) {
+		select {
+		case buf := <-data:
+			var obj struct{ Number string }
+			assert.NoError(t, json.Unmarshal(buf, &obj))
+			assert.Equal(t, fmt.Sprintf("0x%x", num), obj.Number)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout")
+		}
+	}
+
+	s.newHead(1)
+	recv(1)
+
+	// the node advances while the client is disconnected
+	s.lock.Lock()
+	oldConn := s.conn
+	s.head = 5
+	s.lock.Unlock()
+	s.drop()
+
+	select {
+	case gap := <-gaps:
+		assert.Equal(t, &SubscriptionGap{LastBlock: 1, Head: 5}, gap)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	s.lock.Lock()
+	assert.True(t, oldConn != s.conn)
+	s.lock.Unlock()
+
+	// the events are delivered in the same callback
+	s.newHead(6)
+	recv(6)
+
+	// the client works with the new connection
+	num, err := c.Eth().BlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), num)
+
+	assert.NoError(t, cancel())
+	assert.Error(t, cancel())
+}
+
+func TestResubscribeLogsFilter(t *testing.T) {
+	resubscribeMinBackoff = 10 * time.Millisecond
+	defer func() {
+		resubscribeMinBackoff = 1 * time.Second
+	}()
+
+	s := newWsServer(t)
+	defer s.srv.Close()
+
+	c, err := NewClient(s.addr())
+	assert.NoError(t, err)
+	defer c.Close()
+
+	filter := &web3.LogFilter{
+		Address: []web3.Address{{0x1}},
+		Topics:  [][]web3.Hash{{{0x2}}},
+	}
+	cancel, err := c.Resubscribe("logs", func(b []byte) {}, nil, filter)
+	assert.NoError(t, err)
+
+	subscriptions := func() []json.RawMessage {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		return append([]json.RawMessage{}, s.subscriptions...)
+	}
+	assert.Len(t, subscriptions(), 1)
+
+	s.drop()
+
+	// the subscription is started again with the same filter
+	timeout := time.After(2 * time.Second)
+	for len(subscriptions()) != 2 {
+		select {
+		case <-timeout:
+			t.Fatal("timeout")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	expected, err := json.Marshal([]interface{}{"logs", filter})
+	assert.NoError(t, err)
+	for _, params := range subscriptions() {
+		assert.JSONEq(t, string(expected), string(params))
+	}
+
+	assert.NoError(t, cancel())
+}
+
+func TestSubscribeChannel(t *testing.T) {
+	m := NewMockTransport()
+	c := NewMockClient(m)
+
+	ch := make(chan json.RawMessage, 1)
+	sub, err := c.SubscribeChannel(context.Background(), "syncing", ch)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"syncing"}, m.Requests()[0].Params)
+
+	assert.NoError(t, m.Notify("syncing", map[string]interface{}{"syncing": false}))
+	assert.JSONEq(t, `{"syncing": false}`, string(<-ch))
+
+	assert.NoError(t, sub.Unsubscribe())
+	_, ok := <-sub.Err()
+	assert.False(t, ok)
+
+	// the notifications are not sent after the unsubscribe
+	assert.NoError(t, m.Notify("syncing", true))
+	assert.Len(t, ch, 0)
+
+	// the subscription ends with the context
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err = c.SubscribeChannel(ctx, "syncing", ch)
+	assert.NoError(t, err)
+	cancel()
+
+	assert.Equal(t, context.Canceled, <-sub.Err())
+	assert.NoError(t, m.Notify("syncing", true))
+	assert.Len(t, ch, 0)
+}
@@ -1,25 +1,54 @@
 package transport
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
 
 	"github.com/boolw/go-web3/jsonrpc/codec"
 	"github.com/valyala/fasthttp"
 )
 
+// defaultHTTPClient is shared by the http transports so that the
+// keep-alive connections to a node are reused by all the clients
+var defaultHTTPClient = &fasthttp.Client{
+	MaxConnsPerHost:     512,
+	MaxIdleConnDuration: 90 * time.Second,
+	ReadTimeout:         2 * time.Minute,
+	WriteTimeout:        30 * time.Second,
+}
+
 // HTTP is an http transport
 type HTTP struct {
 	addr   string
 	client *fasthttp.Client
+	idGen  IDGenerator
+
+	// httpClient is used instead of the fasthttp client if set
+	httpClient *http.Client
 }
 
 func newHTTP(addr string) *HTTP {
 	return &HTTP{
 		addr:   addr,
-		client: &fasthttp.Client{},
+		client: defaultHTTPClient,
+		idGen:  NewSeqIDGenerator(),
 	}
 }
 
+// SetHTTPClient implements the HTTPClientSetter interface
+func (h *HTTP) SetHTTPClient(client *http.Client) {
+	h.httpClient = client
+}
+
+// SetIDGenerator implements the IDGeneratorSetter interface
+func (h *HTTP) SetIDGenerator(gen IDGenerator) {
+	h.idGen = gen
+}
+
 // Close implements the transport interface
 func (h *HTTP) Close() error {
 	return nil
@@ -27,8 +56,14 @@ func (h *HTTP) Close() error {
 
 // Call implements the transport interface
 func (h *HTTP) Call(method string, out interface{}, params ...interface{}) error {
+	id, err := codec.NewID(h.idGen())
+	if err != nil {
+		return err
+	}
+
 	// Encode json-rpc request
 	request := codec.Request{
+		ID:      id,
 		Method:  method,
 		Jsonrpc: "2.0",
 	}
@@ -43,6 +78,111 @@ func (h *HTTP) Call(method string, out interface{}, params ...interface{}) error
 	if err != nil {
 		return err
 	}
+	body, err := h.do(raw)
+	if err != nil {
+		return err
+	}
+
+	// Decode json-rpc response
+	var response codec.Response
+	if err := json.Unmarshal(body, &response); err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if codec.IDKey(response.ID) != codec.IDKey(id) {
+		return fmt.Errorf("response id %s does not match the request id %s", response.ID, id)
+	}
+
+	if err := json.Unmarshal(response.Result, out); err != nil {
+		return err
+	}
+	return nil
+}
+
+// BatchCall implements the BatchTransport interface
+func (h *HTTP) BatchCall(batch []*BatchElem) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	// Encode json-rpc requests, the responses are matched by id
+	requests := make([]codec.Request, len(batch))
+	indexes := make(map[string]int, len(batch))
+	for indx, elem := range batch {
+		id, err := codec.NewID(h.idGen())
+		if err != nil {
+			return err
+		}
+		key := codec.IDKey(id)
+		if _, ok := indexes[key]; ok {
+			return fmt.Errorf("duplicated request id %s in batch", id)
+		}
+		indexes[key] = indx
+
+		requests[indx] = codec.Request{
+			ID:      id,
+			Method:  elem.Method,
+			Jsonrpc: "2.0",
+		}
+		if len(elem.Params) > 0 {
+			data, err := json.Marshal(elem.Params)
+			if err != nil {
+				return err
+			}
+			requests[indx].Params = data
+		}
+	}
+	raw, err := json.Marshal(requests)
+	if err != nil {
+		return err
+	}
+	body, err := h.do(raw)
+	if err != nil {
+		return err
+	}
+
+	// Decode json-rpc responses, they may come in any order
+	var responses []codec.Response
+	if err := json.Unmarshal(body, &responses); err != nil {
+		// the node may reply with a single error for the whole batch
+		var response codec.Response
+		if json.Unmarshal(body, &response) == nil && response.Error != nil {
+			return response.Error
+		}
+		return err
+	}
+
+	found := make([]bool, len(batch))
+	for _, response := range responses {
+		indx, ok := indexes[codec.IDKey(response.ID)]
+		if !ok {
+			continue
+		}
+		found[indx] = true
+
+		elem := batch[indx]
+		if response.Error != nil {
+			elem.Error = response.Error
+			continue
+		}
+		if err := json.Unmarshal(response.Result, elem.Result); err != nil {
+			elem.Error = err
+		}
+	}
+	for indx, ok := range found {
+		if !ok {
+			batch[indx].Error = fmt.Errorf("response for batch request %d not found", indx)
+		}
+	}
+	return nil
+}
+
+func (h *HTTP) do(raw []byte) ([]byte, error) {
+	if h.httpClient != nil {
+		return h.doHTTPClient(raw)
+	}
 
 	req := fasthttp.AcquireRequest()
 	res := fasthttp.AcquireResponse()
@@ -56,20 +196,31 @@ func (h *HTTP) Call(method string, out interface{}, params ...interface{}) error
 	req.SetBody(raw)
 
 	if err := h.client.Do(req, res); err != nil {
-		return err
+		return nil, err
 	}
 
-	// Decode json-rpc response
-	var response codec.Response
-	if err := json.Unmarshal(res.Body(), &response); err != nil {
-		return err
+	// the response body is released with the response
+	body := append([]byte{}, res.Body()...)
+	return body, nil
+}
+
+func (h *HTTP) doHTTPClient(raw []byte) ([]byte, error) {
+	req, err := http.NewRequest("POST", h.addr, bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
 	}
-	if response.Error != nil {
-		return response.Error
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, err
 	}
+	defer res.Body.Close()
 
-	if err := json.Unmarshal(response.Result, out); err != nil {
-		return err
+	// the body is read to the end to reuse the connection
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return body, nil
 }
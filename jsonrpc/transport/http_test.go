@@ -0,0 +1,101 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boolw/go-web3/jsonrpc/codec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPBatchCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+
+		var requests []codec.Request
+		if err := json.Unmarshal(data, &requests); err != nil {
+			t.Fatal(err)
+		}
+
+		// reply in reverse order and fail the second request
+		responses := []*codec.Response{}
+		for i := len(requests) - 1; i >= 0; i-- {
+			req := requests[i]
+			resp := &codec.Response{ID: req.ID}
+			if i == 1 {
+				resp.Error = &codec.ErrorObject{Code: -32000, Message: "failed"}
+			} else {
+				resp.Result = json.RawMessage(`"` + req.Method + `"`)
+			}
+			responses = append(responses, resp)
+		}
+		raw, _ := json.Marshal(responses)
+		w.Write(raw)
+	}))
+	defer srv.Close()
+
+	h := newHTTP(srv.URL)
+
+	out := make([]string, 3)
+	batch := []*BatchElem{}
+	for indx, method := range []string{"a", "b", "c"} {
+		batch = append(batch, &BatchElem{
+			Method: method,
+			Params: []interface{}{indx},
+			Result: &out[indx],
+		})
+	}
+	assert.NoError(t, h.BatchCall(batch))
+
+	assert.NoError(t, batch[0].Error)
+	assert.Error(t, batch[1].Error)
+	assert.NoError(t, batch[2].Error)
+	assert.Equal(t, out, []string{"a", "", "c"})
+}
+
+func TestHTTPIDGenerator(t *testing.T) {
+	ids := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+
+		var req codec.Request
+		if err := json.Unmarshal(data, &req); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, string(req.ID))
+
+		id := req.ID
+		if req.Method == "wrong" {
+			id = json.RawMessage(`"other"`)
+		}
+		raw, _ := json.Marshal(&codec.Response{ID: id, Result: json.RawMessage(`"ok"`)})
+		w.Write(raw)
+	}))
+	defer srv.Close()
+
+	h := newHTTP(srv.URL)
+
+	seq := 0
+	h.SetIDGenerator(func() interface{} {
+		seq++
+		return fmt.Sprintf("req-%d", seq)
+	})
+
+	var out string
+	assert.NoError(t, h.Call("a", &out))
+	assert.Equal(t, out, "ok")
+
+	// the response must have the id of the request
+	assert.Error(t, h.Call("wrong", &out))
+	assert.Equal(t, ids, []string{`"req-1"`, `"req-2"`})
+
+	// the ids can only be strings or integers
+	h.SetIDGenerator(func() interface{} {
+		return 1.5
+	})
+	assert.Error(t, h.Call("a", &out))
+}
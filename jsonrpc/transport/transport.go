@@ -1,8 +1,11 @@
 package transport
 
 import (
+	"net/http"
 	"os"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // Transport is an inteface for transport methods to send jsonrpc requests
@@ -14,10 +17,65 @@ type Transport interface {
 	Close() error
 }
 
+// BatchTransport is a transport that allows sending several requests at once
+type BatchTransport interface {
+	// BatchCall makes a batch of jsonrpc requests
+	BatchCall(batch []*BatchElem) error
+}
+
+// BatchElem is a request in a batch call. The result of the request
+// is decoded into Result and any error specific to it is set on Error.
+type BatchElem struct {
+	Method string
+	Params []interface{}
+	Result interface{}
+	Error  error
+}
+
 // PubSubTransport is a transport that allows subscriptions
 type PubSubTransport interface {
-	// Subscribe starts a subscription to a new event
-	Subscribe(method string, callback func(b []byte)) (func() error, error)
+	// Subscribe starts a subscription to a new event. The params are
+	// sent after the method (i.e. the filter of a logs subscription).
+	Subscribe(method string, callback func(b []byte), params ...interface{}) (func() error, error)
+}
+
+// ConnectionNotifier is a transport with a persistent connection that
+// notifies when the connection is lost
+type ConnectionNotifier interface {
+	// Done returns a channel that is closed when the connection is lost
+	Done() <-chan struct{}
+}
+
+// IDGenerator returns the id of the next jsonrpc request.
+// The id must be a string or an integer.
+type IDGenerator func() interface{}
+
+// IDGeneratorSetter is a transport that allows to change the ids of the requests
+type IDGeneratorSetter interface {
+	// SetIDGenerator sets the generator of the request ids
+	SetIDGenerator(gen IDGenerator)
+}
+
+// HTTPClientSetter is a transport that allows to change the http client
+type HTTPClientSetter interface {
+	// SetHTTPClient sets the client used to send the requests
+	SetHTTPClient(client *http.Client)
+}
+
+// KeepAliveSetter is a transport with a persistent connection that can send
+// periodic pings to detect when the connection is lost
+type KeepAliveSetter interface {
+	// SetKeepAlive sets the interval of the pings. The connection is
+	// closed if there is no response in two intervals.
+	SetKeepAlive(interval time.Duration)
+}
+
+// NewSeqIDGenerator returns a generator of incrementing ids starting at 1
+func NewSeqIDGenerator() IDGenerator {
+	var seq uint64
+	return func() interface{} {
+		return atomic.AddUint64(&seq, 1)
+	}
 }
 
 const (
@@ -20,6 +20,7 @@ func newWebsocket(url string) (Transport, error) {
 	codec := &websocketCodec{
 		conn: wsConn,
 	}
+	wsConn.SetPongHandler(codec.handlePong)
 	return newStream(codec)
 }
 
@@ -34,26 +35,30 @@ type ackMessage struct {
 type callback func(b []byte, err error)
 
 type stream struct {
-	seq   uint64
+	idGen IDGenerator
 	codec Codec
 
-	// call handlers
+	// call handlers by request id
 	handlerLock sync.Mutex
-	handler     map[uint64]callback
+	handler     map[string]callback
 
 	// subscriptions
 	subsLock sync.Mutex
 	subs     map[string]func(b []byte)
 
 	closeCh chan struct{}
-	timer   *time.Timer
+
+	// closed when the connection is lost
+	doneCh chan struct{}
 }
 
 func newStream(codec Codec) (*stream, error) {
 	w := &stream{
+		idGen:   NewSeqIDGenerator(),
 		codec:   codec,
 		closeCh: make(chan struct{}),
-		handler: map[uint64]callback{},
+		doneCh:  make(chan struct{}),
+		handler: map[string]callback{},
 		subs:    map[string]func(b []byte){},
 	}
 
@@ -67,8 +72,22 @@ func (s *stream) Close() error {
 	return s.codec.Close()
 }
 
-func (s *stream) incSeq() uint64 {
-	return atomic.AddUint64(&s.seq, 1)
+// Done implements the ConnectionNotifier interface
+func (s *stream) Done() <-chan struct{} {
+	return s.doneCh
+}
+
+// SetKeepAlive implements the KeepAliveSetter interface. It is ignored
+// if the connection does not support pings.
+func (s *stream) SetKeepAlive(interval time.Duration) {
+	if k, ok := s.codec.(keepAliveCodec); ok {
+		k.keepAlive(interval, s.closeCh, s.doneCh)
+	}
+}
+
+// SetIDGenerator implements the IDGeneratorSetter interface
+func (s *stream) SetIDGenerator(gen IDGenerator) {
+	s.idGen = gen
 }
 
 func (s *stream) isClosed() bool {
@@ -81,6 +100,8 @@ func (s *stream) isClosed() bool {
 }
 
 func (s *stream) listen() {
+	defer close(s.doneCh)
+
 	buf := []byte{}
 
 	for {
@@ -98,7 +119,7 @@ func (s *stream) listen() {
 			return
 		}
 
-		if resp.ID != 0 {
+		if resp.HasID() {
 			go s.handleMsg(resp)
 		} else {
 			// handle subscription
@@ -133,15 +154,17 @@ func (s *stream) handleSubscription(response codec.Request) {
 }
 
 func (s *stream) handleMsg(response codec.Response) {
+	key := codec.IDKey(response.ID)
+
 	s.handlerLock.Lock()
-	callback, ok := s.handler[response.ID]
+	callback, ok := s.handler[key]
 	if !ok {
 		s.handlerLock.Unlock()
 		return
 	}
 
 	// delete handler
-	delete(s.handler, response.ID)
+	delete(s.handler, key)
 	s.handlerLock.Unlock()
 
 	if response.Error != nil {
@@ -151,7 +174,7 @@ func (s *stream) handleMsg(response codec.Response) {
 	}
 }
 
-func (s *stream) setHandler(id uint64, ack chan *ackMessage) {
+func (s *stream) setHandler(id string, ack chan *ackMessage) (*time.Timer, error) {
 	callback := func(b []byte, err error) {
 		select {
 		case ack <- &ackMessage{b, err}:
@@ -160,10 +183,15 @@ func (s *stream) setHandler(id uint64, ack chan *ackMessage) {
 	}
 
 	s.handlerLock.Lock()
+	if _, ok := s.handler[id]; ok {
+		s.handlerLock.Unlock()
+		return nil, fmt.Errorf("request with id %s already in progress", id)
+	}
 	s.handler[id] = callback
 	s.handlerLock.Unlock()
 
-	s.timer = time.AfterFunc(5*time.Second, func() {
+	// the timer is per request since the calls can be concurrent
+	timer := time.AfterFunc(5*time.Second, func() {
 		s.handlerLock.Lock()
 		delete(s.handler, id)
 		s.handlerLock.Unlock()
@@ -173,13 +201,17 @@ func (s *stream) setHandler(id uint64, ack chan *ackMessage) {
 		default:
 		}
 	})
+	return timer, nil
 }
 
 // Call implements the transport interface
 func (s *stream) Call(method string, out interface{}, params ...interface{}) error {
-	seq := s.incSeq()
+	id, err := codec.NewID(s.idGen())
+	if err != nil {
+		return err
+	}
 	request := codec.Request{
-		ID:      seq,
+		ID:      id,
 		Method:  method,
 		Jsonrpc: "2.0",
 	}
@@ -191,8 +223,13 @@ func (s *stream) Call(method string, out interface{}, params ...interface{}) err
 		request.Params = data
 	}
 
-	ack := make(chan *ackMessage)
-	s.setHandler(seq, ack)
+	// buffered so that a fast response is not dropped before it is read
+	ack := make(chan *ackMessage, 1)
+	timer, err := s.setHandler(codec.IDKey(id), ack)
+	if err != nil {
+		return err
+	}
+	defer timer.Stop()
 
 	raw, err := json.Marshal(request)
 	if err != nil {
@@ -239,9 +276,9 @@ func (s *stream) setSubscription(id string, callback func(b []byte)) {
 }
 
 // Subscribe implements the PubSubTransport interface
-func (s *stream) Subscribe(method string, callback func(b []byte)) (func() error, error) {
+func (s *stream) Subscribe(method string, callback func(b []byte), params ...interface{}) (func() error, error) {
 	var out string
-	if err := s.Call("eth_subscribe", &out, method); err != nil {
+	if err := s.Call("eth_subscribe", &out, append([]interface{}{method}, params...)...); err != nil {
 		return nil, err
 	}
 
@@ -252,10 +289,63 @@ func (s *stream) Subscribe(method string, callback func(b []byte)) (func() error
 	return cancel, nil
 }
 
+// keepAliveCodec is a codec that can ping the other side to keep the
+// connection alive
+type keepAliveCodec interface {
+	keepAlive(interval time.Duration, closeCh, doneCh <-chan struct{})
+}
+
 type websocketCodec struct {
+	// pongWait is the time to wait for a pong (or any message)
+	// before the connection is considered lost. It is first
+	// to be aligned for the atomic operations.
+	pongWait int64
+	keepOnce sync.Once
+
 	conn *websocket.Conn
 }
 
+// keepAlive sends a ping every interval. The connection is lost if
+// nothing is received in two intervals, which closes the stream.
+func (w *websocketCodec) keepAlive(interval time.Duration, closeCh, doneCh <-chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+	w.keepOnce.Do(func() {
+		atomic.StoreInt64(&w.pongWait, int64(2*interval))
+		w.extendDeadline()
+
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					if err := w.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(interval)); err != nil {
+						return
+					}
+				case <-closeCh:
+					return
+				case <-doneCh:
+					return
+				}
+			}
+		}()
+	})
+}
+
+func (w *websocketCodec) handlePong(string) error {
+	w.extendDeadline()
+	return nil
+}
+
+func (w *websocketCodec) extendDeadline() {
+	if wait := atomic.LoadInt64(&w.pongWait); wait > 0 {
+		w.conn.SetReadDeadline(time.Now().Add(time.Duration(wait)))
+	}
+}
+
 func (w *websocketCodec) Close() error {
 	return w.conn.Close()
 }
@@ -269,6 +359,7 @@ func (w *websocketCodec) Read(b []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	w.extendDeadline()
 	b = append(b, buf...)
 	return b, nil
 }
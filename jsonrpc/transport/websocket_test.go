@@ -0,0 +1,160 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/boolw/go-web3/jsonrpc/codec"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockCodec replies to the requests once all of them are received
+// and in reverse order
+type mockCodec struct {
+	num     int
+	lock    sync.Mutex
+	pending []*codec.Request
+	readCh  chan []byte
+	closeCh chan struct{}
+}
+
+func (m *mockCodec) Read(b []byte) ([]byte, error) {
+	select {
+	case buf := <-m.readCh:
+		return append(b, buf...), nil
+	case <-m.closeCh:
+		return nil, fmt.Errorf("closed")
+	}
+}
+
+func (m *mockCodec) Write(b []byte) error {
+	var req codec.Request
+	if err := json.Unmarshal(b, &req); err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.pending = append(m.pending, &req)
+	if len(m.pending) == m.num {
+		pending := m.pending
+		go func() {
+			for i := len(pending) - 1; i >= 0; i-- {
+				req := pending[i]
+				raw, _ := json.Marshal(&codec.Response{ID: req.ID, Result: json.RawMessage(`"` + req.Method + `"`)})
+				m.readCh <- raw
+			}
+		}()
+	}
+	return nil
+}
+
+func (m *mockCodec) Close() error {
+	close(m.closeCh)
+	return nil
+}
+
+func TestStreamOutOfOrderResponses(t *testing.T) {
+	c := &mockCodec{
+		num:     3,
+		readCh:  make(chan []byte),
+		closeCh: make(chan struct{}),
+	}
+	s, err := newStream(c)
+	assert.NoError(t, err)
+	defer s.Close()
+
+	var seq uint64
+	s.SetIDGenerator(func() interface{} {
+		return fmt.Sprintf("id-%d", atomic.AddUint64(&seq, 1))
+	})
+
+	methods := []string{"a", "b", "c"}
+	results := make(chan string, len(methods))
+	errs := make(chan error, len(methods))
+	for _, method := range methods {
+		method := method
+		go func() {
+			var out string
+			if err := s.Call(method, &out); err != nil {
+				errs <- err
+				return
+			}
+			if out != method {
+				errs <- fmt.Errorf("expected %s but found %s", method, out)
+				return
+			}
+			results <- out
+		}()
+	}
+
+	for range methods {
+		select {
+		case err := <-errs:
+			t.Fatal(err)
+		case <-results:
+		}
+	}
+}
+
+func TestWebsocketKeepAlive(t *testing.T) {
+	newServer := func(answerPings bool) *httptest.Server {
+		upgrader := websocket.Upgrader{}
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			if !answerPings {
+				conn.SetPingHandler(func(string) error { return nil })
+			}
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}))
+	}
+	dial := func(srv *httptest.Server) *stream {
+		tt, err := newWebsocket("ws" + strings.TrimPrefix(srv.URL, "http"))
+		assert.NoError(t, err)
+		s := tt.(*stream)
+		s.SetKeepAlive(20 * time.Millisecond)
+		return s
+	}
+
+	// the connection is alive while the pongs are received
+	srv := newServer(true)
+	defer srv.Close()
+
+	s := dial(srv)
+	defer s.Close()
+
+	select {
+	case <-s.Done():
+		t.Fatal("the connection should be alive")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// the connection is lost without pongs
+	srv2 := newServer(false)
+	defer srv2.Close()
+
+	s2 := dial(srv2)
+	defer s2.Close()
+
+	select {
+	case <-s2.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("the connection should be lost")
+	}
+}
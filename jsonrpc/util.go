@@ -2,6 +2,7 @@ package jsonrpc
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"strconv"
@@ -12,22 +13,82 @@ func encodeUintToHex(i uint64) string {
 	return fmt.Sprintf("0x%x", i)
 }
 
-func parseBigInt(str string) *big.Int {
-	if strings.HasPrefix(str, "0x") {
-		str = str[2:]
+// quantity is a number returned by the node either as a hex string
+// or as a JSON number. JSON numbers are kept as hex strings.
+type quantity string
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (q *quantity) UnmarshalJSON(data []byte) error {
+	if len(data) != 0 && data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		*q = quantity(str)
+		return nil
+	}
+	if !isDigits(string(data), 10) {
+		return fmt.Errorf("cannot decode '%s' as a quantity", data)
+	}
+	num, _ := new(big.Int).SetString(string(data), 10)
+	*q = quantity("0x" + num.Text(16))
+	return nil
+}
+
+// parseQuantity parses a hex number with 0x (or 0X) prefix. Leading zeros
+// are allowed and an empty hex number (0x) is zero. Numbers without prefix
+// are rejected since they could be either hex or decimal.
+func parseQuantity(str string) (*big.Int, error) {
+	if !strings.HasPrefix(str, "0x") && !strings.HasPrefix(str, "0X") {
+		return nil, fmt.Errorf("invalid quantity '%s', expected a hex number with 0x prefix", str)
+	}
+	str = str[2:]
+	if str == "" {
+		return new(big.Int), nil
+	}
+	if !isDigits(str, 16) {
+		return nil, fmt.Errorf("invalid hex quantity '0x%s'", str)
+	}
+	num, _ := new(big.Int).SetString(str, 16)
+	return num, nil
+}
+
+func isDigits(str string, base int) bool {
+	if str == "" {
+		return false
 	}
-	num := new(big.Int)
-	num.SetString(str, 16)
-	return num
+	for _, c := range str {
+		switch {
+		case '0' <= c && c <= '9':
+		case base == 16 && ('a' <= c && c <= 'f' || 'A' <= c && c <= 'F'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func parseBigInt(str string) (*big.Int, error) {
+	return parseQuantity(str)
 }
 
+// parseUint64orHex parses a hex quantity or a decimal number without prefix
+// (i.e. net_version)
 func parseUint64orHex(str string) (uint64, error) {
-	base := 10
-	if strings.HasPrefix(str, "0x") {
-		str = str[2:]
-		base = 16
+	if !strings.HasPrefix(str, "0x") && !strings.HasPrefix(str, "0X") {
+		if !isDigits(str, 10) {
+			return 0, fmt.Errorf("invalid quantity '%s', expected a hex number with 0x prefix or a decimal number", str)
+		}
+		return strconv.ParseUint(str, 10, 64)
+	}
+	num, err := parseQuantity(str)
+	if err != nil {
+		return 0, err
+	}
+	if !num.IsUint64() {
+		return 0, fmt.Errorf("quantity '%s' overflows uint64", str)
 	}
-	return strconv.ParseUint(str, base, 64)
+	return num.Uint64(), nil
 }
 
 func encodeToHex(b []byte) string {
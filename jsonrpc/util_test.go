@@ -0,0 +1,95 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuantity(t *testing.T) {
+	cases := []struct {
+		str string
+		num uint64
+	}{
+		{"0x0", 0},
+		{"0x", 0},
+		{"0x0a", 10},
+		{"0xFF", 255},
+		{"0X0", 0},
+		{"0XfF", 255},
+	}
+	for _, c := range cases {
+		num, err := parseUint64orHex(c.str)
+		assert.NoError(t, err, c.str)
+		assert.Equal(t, c.num, num, c.str)
+
+		big, err := parseBigInt(c.str)
+		assert.NoError(t, err, c.str)
+		assert.Equal(t, c.num, big.Uint64(), c.str)
+	}
+
+	for _, str := range []string{"", "0x-1", "-1", "0xg", "a", "1.5", "1e3", " 1", "0x1_0"} {
+		_, err := parseUint64orHex(str)
+		assert.Error(t, err, str)
+		_, err = parseBigInt(str)
+		assert.Error(t, err, str)
+	}
+
+	// numbers without prefix are not hex quantities, only the uint64
+	// values accept them as decimal numbers (i.e. net_version)
+	for _, str := range []string{"10", "0010", "ff"} {
+		_, err := parseBigInt(str)
+		assert.Error(t, err, str)
+	}
+	num, err := parseUint64orHex("0010")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), num)
+	_, err = parseUint64orHex("ff")
+	assert.Error(t, err)
+
+	// overflows uint64 but not big.Int
+	_, err = parseUint64orHex("0x10000000000000000")
+	assert.Error(t, err)
+	bigNum, err := parseBigInt("0x10000000000000000")
+	assert.NoError(t, err)
+	assert.Equal(t, new(big.Int).Lsh(big.NewInt(1), 64), bigNum)
+}
+
+func TestQuantityUnmarshalJSON(t *testing.T) {
+	var q quantity
+	assert.NoError(t, json.Unmarshal([]byte(`"0x10"`), &q))
+	assert.Equal(t, quantity("0x10"), q)
+
+	// json numbers are decimal
+	assert.NoError(t, json.Unmarshal([]byte(`16`), &q))
+	assert.Equal(t, quantity("0x10"), q)
+
+	assert.NoError(t, json.Unmarshal([]byte(`18446744073709551616`), &q))
+	assert.Equal(t, quantity("0x10000000000000000"), q)
+
+	for _, raw := range []string{`1.5`, `-1`, `1e3`, `null`, `true`} {
+		assert.Error(t, json.Unmarshal([]byte(raw), &q), raw)
+	}
+}
+
+func TestEthJSONNumbers(t *testing.T) {
+	m := NewMockTransport()
+	m.Respond("eth_blockNumber", nil, 100)
+	m.Respond("eth_gasPrice", nil, "0x")
+	m.Respond("eth_chainId", nil, "0x01")
+	c := NewMockClient(m)
+
+	num, err := c.Eth().BlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), num)
+
+	price, err := c.Eth().GasPrice()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), price)
+
+	chainID, err := c.Eth().ChainID()
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), chainID)
+}
@@ -0,0 +1,61 @@
+package web3
+
+import (
+	"hash"
+	"sync"
+
+	"golang.org/x/crypto/sha3"
+)
+
+var keccakPool = sync.Pool{
+	New: func() interface{} {
+		return sha3.NewLegacyKeccak256()
+	},
+}
+
+// Keccak256 returns the keccak256 hash of the concatenation of the data
+func Keccak256(data ...[]byte) Hash {
+	k := NewKeccakState()
+	for _, b := range data {
+		k.Write(b)
+	}
+	h := k.Hash()
+	k.Release()
+	return h
+}
+
+// KeccakState is an incremental keccak256 hasher for large inputs.
+// Release returns it to the pool once it is not used anymore.
+type KeccakState struct {
+	h hash.Hash
+}
+
+// NewKeccakState returns a keccak256 hasher from the pool
+func NewKeccakState() *KeccakState {
+	return &KeccakState{h: keccakPool.Get().(hash.Hash)}
+}
+
+// Write implements the io.Writer interface
+func (k *KeccakState) Write(b []byte) (int, error) {
+	return k.h.Write(b)
+}
+
+// Hash returns the hash of the data written so far
+func (k *KeccakState) Hash() Hash {
+	var h Hash
+	k.h.Sum(h[:0])
+	return h
+}
+
+// Reset resets the hasher to its initial state
+func (k *KeccakState) Reset() {
+	k.h.Reset()
+}
+
+// Release resets the hasher and returns it to the pool.
+// The state must not be used after it is released.
+func (k *KeccakState) Release() {
+	k.h.Reset()
+	keccakPool.Put(k.h)
+	k.h = nil
+}
@@ -0,0 +1,27 @@
+package web3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeccak256(t *testing.T) {
+	// keccak256 of the empty input
+	empty := HexToHash("0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470")
+	assert.Equal(t, Keccak256(), empty)
+	assert.Equal(t, Keccak256(nil), empty)
+
+	// the input is concatenated
+	expected := Keccak256([]byte("hello world"))
+	assert.Equal(t, Keccak256([]byte("hello"), []byte(" "), []byte("world")), expected)
+
+	k := NewKeccakState()
+	k.Write([]byte("hello "))
+	k.Write([]byte("world"))
+	assert.Equal(t, k.Hash(), expected)
+
+	k.Reset()
+	assert.Equal(t, k.Hash(), empty)
+	k.Release()
+}
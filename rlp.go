@@ -0,0 +1,62 @@
+package web3
+
+import (
+	"encoding/binary"
+	"math/big"
+)
+
+// minimal rlp encoder used to compute the hash of the transactions
+
+func rlpEncodeBytes(b []byte) []byte {
+	if len(b) == 1 && b[0] < 0x80 {
+		return []byte{b[0]}
+	}
+	return append(rlpHeader(0x80, len(b)), b...)
+}
+
+func rlpEncodeUint(i uint64) []byte {
+	if i == 0 {
+		return rlpEncodeBytes(nil)
+	}
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, i)
+	return rlpEncodeBytes(trimLeftZeros(buf))
+}
+
+func rlpEncodeBigInt(i *big.Int) []byte {
+	if i == nil {
+		return rlpEncodeBytes(nil)
+	}
+	return rlpEncodeBytes(i.Bytes())
+}
+
+func rlpEncodeList(items ...[]byte) []byte {
+	size := 0
+	for _, item := range items {
+		size += len(item)
+	}
+	res := rlpHeader(0xC0, size)
+	for _, item := range items {
+		res = append(res, item...)
+	}
+	return res
+}
+
+func rlpHeader(offset byte, size int) []byte {
+	if size < 56 {
+		return []byte{offset + byte(size)}
+	}
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(size))
+	buf = trimLeftZeros(buf)
+	return append([]byte{offset + 55 + byte(len(buf))}, buf...)
+}
+
+func trimLeftZeros(b []byte) []byte {
+	for i := range b {
+		if b[i] != 0 {
+			return b[i:]
+		}
+	}
+	return nil
+}
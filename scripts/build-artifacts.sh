@@ -11,3 +11,13 @@ echo "--> Build ERC20"
 
 ERC20_ARTIFACTS=./contract/builtin/erc20/artifacts
 go run abigen/*.go --source ${ERC20_ARTIFACTS}/ERC20.abi --output ./contract/builtin/erc20 --package erc20
+
+echo "--> Build ERC721"
+
+ERC721_ARTIFACTS=./contract/builtin/erc721/artifacts
+go run abigen/*.go --source ${ERC721_ARTIFACTS}/ERC721.abi --output ./contract/builtin/erc721 --package erc721
+
+echo "--> Build ERC1155"
+
+ERC1155_ARTIFACTS=./contract/builtin/erc1155/artifacts
+go run abigen/*.go --source ${ERC1155_ARTIFACTS}/ERC1155.abi --output ./contract/builtin/erc1155 --package erc1155
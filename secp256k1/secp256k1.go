@@ -0,0 +1,59 @@
+// Package secp256k1 contains the key and signature helpers for the secp256k1
+// curve. The curve arithmetic is done by github.com/decred/dcrd/dcrec/secp256k1.
+package secp256k1
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// S256 returns the secp256k1 curve
+func S256() elliptic.Curve {
+	return secp.S256()
+}
+
+// ToECDSA returns the private key with the given 32 bytes scalar
+func ToECDSA(d []byte) (*ecdsa.PrivateKey, error) {
+	if len(d) != 32 {
+		return nil, fmt.Errorf("invalid private key length %d, expected 32", len(d))
+	}
+	var k secp.ModNScalar
+	if overflow := k.SetByteSlice(d); overflow || k.IsZero() {
+		return nil, fmt.Errorf("invalid private key, out of range")
+	}
+	return secp.NewPrivateKey(&k).ToECDSA(), nil
+}
+
+// FromECDSA returns the 32 bytes scalar of the private key
+func FromECDSA(priv *ecdsa.PrivateKey) []byte {
+	return padTo32(priv.D.Bytes())
+}
+
+// MarshalPubkey returns the 65 bytes uncompressed form (0x04 || x || y) of the public key
+func MarshalPubkey(pub *ecdsa.PublicKey) []byte {
+	buf := make([]byte, 65)
+	buf[0] = 0x4
+	copy(buf[1:33], padTo32(pub.X.Bytes()))
+	copy(buf[33:], padTo32(pub.Y.Bytes()))
+	return buf
+}
+
+// CompressPubkey returns the 33 bytes compressed form (0x02/0x03 || x) of the public key
+func CompressPubkey(pub *ecdsa.PublicKey) []byte {
+	buf := make([]byte, 33)
+	buf[0] = 0x2 | byte(pub.Y.Bit(0))
+	copy(buf[1:], padTo32(pub.X.Bytes()))
+	return buf
+}
+
+func padTo32(b []byte) []byte {
+	if len(b) >= 32 {
+		return b
+	}
+	buf := make([]byte, 32)
+	copy(buf[32-len(b):], b)
+	return buf
+}
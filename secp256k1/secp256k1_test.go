@@ -0,0 +1,172 @@
+package secp256k1
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestCurveArithmetic(t *testing.T) {
+	c := S256()
+	gx, gy := c.Params().Gx, c.Params().Gy
+
+	if !c.IsOnCurve(gx, gy) {
+		t.Fatal("generator is not on the curve")
+	}
+
+	// 2G
+	x2, y2 := c.Double(gx, gy)
+	if hex.EncodeToString(x2.Bytes()) != "c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5" {
+		t.Fatal("bad 2G")
+	}
+	if !c.IsOnCurve(x2, y2) {
+		t.Fatal("2G is not on the curve")
+	}
+
+	// G + 2G == 3G
+	x3, y3 := c.Add(gx, gy, x2, y2)
+	xx, yy := c.ScalarBaseMult([]byte{3})
+	if x3.Cmp(xx) != 0 || y3.Cmp(yy) != 0 {
+		t.Fatal("G + 2G != 3G")
+	}
+
+	// G + G == 2G
+	xx, yy = c.Add(gx, gy, gx, gy)
+	if x2.Cmp(xx) != 0 || y2.Cmp(yy) != 0 {
+		t.Fatal("G + G != 2G")
+	}
+
+	// nG is the point at infinity
+	xx, yy = c.ScalarBaseMult(c.Params().N.Bytes())
+	if xx.Sign() != 0 || yy.Sign() != 0 {
+		t.Fatal("nG is not the point at infinity")
+	}
+
+	// (n-1)G == -G
+	nm1 := new(big.Int).Sub(c.Params().N, big.NewInt(1))
+	xx, yy = c.ScalarBaseMult(nm1.Bytes())
+	if xx.Cmp(gx) != 0 || new(big.Int).Add(yy, gy).Cmp(c.Params().P) != 0 {
+		t.Fatal("(n-1)G != -G")
+	}
+}
+
+func TestToECDSA(t *testing.T) {
+	if _, err := ToECDSA(make([]byte, 32)); err == nil {
+		t.Fatal("zero key should fail")
+	}
+	if _, err := ToECDSA(S256().Params().N.Bytes()); err == nil {
+		t.Fatal("key out of range should fail")
+	}
+
+	priv, err := ToECDSA(append(make([]byte, 31), 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv.X.Cmp(S256().Params().Gx) != 0 {
+		t.Fatal("bad public key")
+	}
+	if CompressPubkey(&priv.PublicKey)[0] != 0x2 {
+		t.Fatal("G has an even y")
+	}
+	if len(FromECDSA(priv)) != 32 || len(MarshalPubkey(&priv.PublicKey)) != 65 {
+		t.Fatal("bad lengths")
+	}
+}
+
+func TestNormalizeSignature(t *testing.T) {
+	priv, err := ToECDSA(append(make([]byte, 31), 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := append([]byte{0x1}, make([]byte, 31)...)
+
+	// high-s signature of the hash with the key 0x07
+	sig, _ := hex.DecodeString("6e870ab3675c58b9a3148892ce80a9ec67016575f9da6c41809a2d8f9c35d8b4" +
+		"9f1ac17b7d4b34f42e1ce77fc64fd3574f299341ed5a9ff78d7bdf3a79a0563f" + "1b")
+
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+	if IsLowS(s) {
+		t.Fatal("expected a high-s signature")
+	}
+	if !ecdsa.Verify(&priv.PublicKey, hash, r, s) {
+		t.Fatal("bad signature")
+	}
+
+	norm := NormalizeSignature(sig)
+	lowS := new(big.Int).SetBytes(norm[32:64])
+	if !IsLowS(lowS) || lowS.Cmp(new(big.Int).Sub(S256().Params().N, s)) != 0 {
+		t.Fatal("s not normalized")
+	}
+	if !bytes.Equal(norm[:32], sig[:32]) || norm[64] != 0x1c {
+		t.Fatal("bad r or recovery id")
+	}
+	if !ecdsa.Verify(&priv.PublicKey, hash, r, lowS) {
+		t.Fatal("the normalized signature does not verify")
+	}
+
+	// low-s signatures do not change and the input is not modified
+	if !bytes.Equal(NormalizeSignature(norm), norm) || sig[64] != 0x1b {
+		t.Fatal("bad")
+	}
+
+	// s values out of range are not low-s and are not normalized
+	if IsLowS(S256().Params().N) {
+		t.Fatal("n is not a low-s value")
+	}
+	outOfRange := append([]byte{}, sig...)
+	copy(outOfRange[32:64], S256().Params().N.Bytes())
+	if !bytes.Equal(NormalizeSignature(outOfRange), outOfRange) {
+		t.Fatal("expected the signature to be unchanged")
+	}
+}
+
+func TestRecoverPubkey(t *testing.T) {
+	priv, err := ToECDSA(append(make([]byte, 31), 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := append([]byte{0x1}, make([]byte, 31)...)
+	expected := MarshalPubkey(&priv.PublicKey)
+
+	sig, _ := hex.DecodeString("6e870ab3675c58b9a3148892ce80a9ec67016575f9da6c41809a2d8f9c35d8b4" +
+		"9f1ac17b7d4b34f42e1ce77fc64fd3574f299341ed5a9ff78d7bdf3a79a0563f" + "00")
+
+	// only one of the recovery ids recovers the key
+	recovered := -1
+	for id := byte(0); id < 2; id++ {
+		sig[64] = id
+		pub, err := RecoverPubkey(hash, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(MarshalPubkey(pub), expected) {
+			recovered = int(id)
+		}
+	}
+	if recovered == -1 {
+		t.Fatal("the key is not recovered")
+	}
+
+	// the normalized signature recovers the same key
+	sig[64] = byte(recovered)
+	pub, err := RecoverPubkey(hash, NormalizeSignature(sig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(MarshalPubkey(pub), expected) {
+		t.Fatal("the normalized signature does not recover the key")
+	}
+
+	sig[64] = 2
+	if _, err := RecoverPubkey(hash, sig); err == nil {
+		t.Fatal("bad recovery id should fail")
+	}
+
+	sig[64] = 0
+	copy(sig[:32], make([]byte, 32))
+	if _, err := RecoverPubkey(hash, sig); err == nil {
+		t.Fatal("zero r should fail")
+	}
+}
@@ -0,0 +1,68 @@
+package secp256k1
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secpecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// SignatureLength is the length of the [R || S || V] signatures
+const SignatureLength = 65
+
+// NormalizeSignature returns a copy of the [R || S || V] signature in the
+// low-s form required by eip-2. If s is higher than n/2 it is replaced with
+// n - s and the parity of the recovery id (0/1 or 27/28) is flipped, since
+// the negated signature recovers the same public key from the mirrored R
+// point. Signatures of another length are returned unchanged.
+func NormalizeSignature(sig []byte) []byte {
+	res := append([]byte{}, sig...)
+	if len(res) != SignatureLength {
+		return res
+	}
+	var s secp.ModNScalar
+	if overflow := s.SetByteSlice(res[32:64]); overflow || !s.IsOverHalfOrder() {
+		return res
+	}
+	s.Negate().PutBytesUnchecked(res[32:64])
+	if res[64] >= 27 {
+		res[64] = 27 + ((res[64] - 27) ^ 1)
+	} else {
+		res[64] ^= 1
+	}
+	return res
+}
+
+// IsLowS returns true if s is not higher than n/2
+func IsLowS(s *big.Int) bool {
+	if s.Sign() < 0 || s.BitLen() > 256 {
+		return false
+	}
+	var k secp.ModNScalar
+	overflow := k.SetByteSlice(s.Bytes())
+	return !overflow && !k.IsOverHalfOrder()
+}
+
+// RecoverPubkey returns the public key that produced the [R || S || V]
+// signature of the hash. The recovery id V is 0 or 1.
+func RecoverPubkey(hash []byte, sig []byte) (*ecdsa.PublicKey, error) {
+	if len(sig) != SignatureLength {
+		return nil, fmt.Errorf("invalid signature length %d, expected %d", len(sig), SignatureLength)
+	}
+	if sig[64] > 1 {
+		return nil, fmt.Errorf("invalid recovery id %d", sig[64])
+	}
+
+	// the compact form is [27 + V || R || S]
+	compact := make([]byte, SignatureLength)
+	compact[0] = 27 + sig[64]
+	copy(compact[1:], sig[:64])
+
+	pub, _, err := secpecdsa.RecoverCompact(compact, hash)
+	if err != nil {
+		return nil, err
+	}
+	return pub.ToECDSA(), nil
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/big"
+	"strconv"
+	"strings"
 )
 
 // Address is an Ethereum address
@@ -30,6 +32,11 @@ func (a Address) String() string {
 	return "0x" + hex.EncodeToString(a[:])
 }
 
+// IsZero returns true if it is the zero address
+func (a Address) IsZero() bool {
+	return a == Address{}
+}
+
 // Hash is an Ethereum hash
 type Hash [32]byte
 
@@ -54,6 +61,11 @@ func (h Hash) String() string {
 	return "0x" + hex.EncodeToString(h[:])
 }
 
+// IsZero returns true if it is the zero hash
+func (h Hash) IsZero() bool {
+	return h == Hash{}
+}
+
 type Block struct {
 	Number             uint64
 	Hash               Hash
@@ -68,11 +80,32 @@ type Block struct {
 	GasLimit           uint64
 	GasUsed            uint64
 	Timestamp          uint64
+	BaseFeePerGas      *big.Int
 	Transactions       []*Transaction
 	TransactionsHashes []Hash
 	Uncles             []Hash
 }
 
+// IsPending returns true if the block is the pending block. The pending
+// block does not have a hash yet.
+func (b *Block) IsPending() bool {
+	return b.Hash.IsZero()
+}
+
+// TransactionType is the eip-2718 type of a transaction
+type TransactionType uint8
+
+const (
+	// TransactionLegacy is a transaction without an envelope
+	TransactionLegacy TransactionType = 0
+
+	// TransactionAccessList is an eip-2930 transaction
+	TransactionAccessList TransactionType = 1
+
+	// TransactionDynamicFee is an eip-1559 transaction
+	TransactionDynamicFee TransactionType = 2
+)
+
 type Transaction struct {
 	Hash     Hash
 	From     Address
@@ -82,27 +115,48 @@ type Transaction struct {
 	Gas      uint64
 	Value    *big.Int
 
+	/*Typed*/
+	Type                 TransactionType
+	ChainID              *big.Int
+	MaxPriorityFeePerGas *big.Int
+	MaxFeePerGas         *big.Int
+	AccessList           AccessList
+
 	/*Exta*/
 	BlockHash        Hash
 	BlockNumber      uint64
 	Nonce            uint64
 	TransactionIndex uint64
-	//V                *big.Int
-	//R                *big.Int
-	//S                *big.Int
+	V                *big.Int
+	R                *big.Int
+	S                *big.Int
+}
+
+// AccessList is the list of addresses and storage slots
+// accessed by a typed transaction
+type AccessList []AccessEntry
+
+// AccessEntry is an address and its storage slots in an access list
+type AccessEntry struct {
+	Address Address
+	Storage []Hash
 }
 
 type CallMsg struct {
 	From     Address
 	To       Address
 	Data     []byte
+	Gas      uint64
 	GasPrice uint64
 	Value    *big.Int
 }
 
 type LogFilter struct {
-	Address   []Address
-	Topics    []*Hash
+	// Address matches the logs of any of the addresses
+	Address []Address
+	// Topics are matched by position, any of the hashes in a position
+	// matches and an empty position matches everything
+	Topics    [][]Hash
 	BlockHash *Hash
 	From      *BlockNumber
 	To        *BlockNumber
@@ -122,6 +176,12 @@ func (l *LogFilter) SetTo(b BlockNumber) {
 	l.To = &b
 }
 
+// SetBlockHash queries the logs of the block with the hash (eip-234). It
+// cannot be used with the block range.
+func (l *LogFilter) SetBlockHash(hash Hash) {
+	l.BlockHash = &hash
+}
+
 type Receipt struct {
 	TransactionHash   Hash
 	TransactionIndex  uint64
@@ -132,8 +192,49 @@ type Receipt struct {
 	Status            uint64
 	GasUsed           uint64
 	CumulativeGasUsed uint64
+	EffectiveGasPrice *big.Int
+	Type              TransactionType
 	LogsBloom         []byte
 	Logs              []*Log
+
+	// Root is the state root of the pre-byzantium receipts, they do not
+	// have a status
+	Root *Hash
+
+	// L1Fee, L1GasPrice and L1GasUsed are the cost of posting the transaction
+	// to L1 in the optimism rollups. They are nil in other chains.
+	L1Fee      *big.Int
+	L1GasPrice *big.Int
+	L1GasUsed  *big.Int
+
+	// GasUsedForL1 is the part of the gas used that pays for L1 in arbitrum.
+	// It is nil in other chains.
+	GasUsedForL1 *big.Int
+}
+
+const (
+	// ReceiptStatusFailed is the status of a reverted transaction
+	ReceiptStatusFailed uint64 = 0
+	// ReceiptStatusSuccessful is the status of a successful transaction
+	ReceiptStatusSuccessful uint64 = 1
+)
+
+// HasStatus returns false for the pre-byzantium receipts, the success
+// of those transactions is unknown
+func (r *Receipt) HasStatus() bool {
+	return r.Root == nil
+}
+
+// Successful returns true if the transaction succeeded. It is always false
+// for the pre-byzantium receipts.
+func (r *Receipt) Successful() bool {
+	return r.HasStatus() && r.Status == ReceiptStatusSuccessful
+}
+
+// Failed returns true if the transaction reverted. It is always false
+// for the pre-byzantium receipts.
+func (r *Receipt) Failed() bool {
+	return r.HasStatus() && r.Status == ReceiptStatusFailed
 }
 
 type Log struct {
@@ -171,6 +272,76 @@ func (b BlockNumber) String() string {
 	return fmt.Sprintf("0x%x", uint64(b))
 }
 
+// ParseBlockNumber parses a block number from the tags (latest, earliest and
+// pending), a hex number with the 0x prefix or a decimal number. Hex numbers
+// without the prefix are rejected since they cannot be told apart from the
+// decimal ones.
+func ParseBlockNumber(str string) (BlockNumber, error) {
+	switch strings.ToLower(str) {
+	case "latest":
+		return Latest, nil
+	case "earliest":
+		return Earliest, nil
+	case "pending":
+		return Pending, nil
+	}
+
+	var num uint64
+	var err error
+	if strings.HasPrefix(str, "0x") || strings.HasPrefix(str, "0X") {
+		num, err = strconv.ParseUint(str[2:], 16, strconv.IntSize-1)
+	} else {
+		num, err = strconv.ParseUint(str, 10, strconv.IntSize-1)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid block number '%s', expected a tag, a hex number with 0x or a decimal number", str)
+	}
+	return BlockNumber(num), nil
+}
+
+// BlockNumberOrHash selects the block of a state query either by number or
+// by hash (EIP-1898). A query by hash is pinned to that exact block and it
+// cannot change because of a reorg.
+type BlockNumberOrHash struct {
+	number           BlockNumber
+	hash             *Hash
+	requireCanonical bool
+}
+
+// BlockAtNumber selects the block by number
+func BlockAtNumber(b BlockNumber) BlockNumberOrHash {
+	return BlockNumberOrHash{number: b}
+}
+
+// BlockAtHash selects the block by hash. If requireCanonical is set the
+// node fails if the block is not in the canonical chain.
+func BlockAtHash(hash Hash, requireCanonical bool) BlockNumberOrHash {
+	return BlockNumberOrHash{hash: &hash, requireCanonical: requireCanonical}
+}
+
+// Hash returns the hash of the block if it is selected by hash
+func (b BlockNumberOrHash) Hash() (Hash, bool) {
+	if b.hash == nil {
+		return Hash{}, false
+	}
+	return *b.hash, true
+}
+
+// Number returns the number of the block if it is selected by number
+func (b BlockNumberOrHash) Number() (BlockNumber, bool) {
+	if b.hash != nil {
+		return 0, false
+	}
+	return b.number, true
+}
+
+func (b BlockNumberOrHash) String() string {
+	if b.hash != nil {
+		return b.hash.String()
+	}
+	return b.number.String()
+}
+
 func EncodeBlock(block ...BlockNumber) BlockNumber {
 	if len(block) != 1 {
 		return Latest
@@ -55,6 +55,9 @@ func (t *Block) MarshalJSON() ([]byte, error) {
 	o.Set("timestamp", a.NewString(fmt.Sprintf("0x%x", t.Timestamp)))
 	o.Set("difficulty", a.NewString(fmt.Sprintf("0x%x", t.Difficulty)))
 	o.Set("extraData", a.NewString("0x"+hex.EncodeToString(t.ExtraData)))
+	if t.BaseFeePerGas != nil {
+		o.Set("baseFeePerGas", a.NewString(fmt.Sprintf("0x%x", t.BaseFeePerGas)))
+	}
 
 	res := o.MarshalTo(nil)
 	defaultArena.Put(a)
@@ -74,7 +77,10 @@ func (t *Transaction) MarshalJSON() ([]byte, error) {
 	if len(t.Input) != 0 {
 		o.Set("input", a.NewString("0x"+hex.EncodeToString(t.Input)))
 	}
-	o.Set("gasPrice", a.NewString(fmt.Sprintf("0x%x", t.GasPrice)))
+	// the nodes reject a gas price with the eip-1559 fees
+	if t.Type != TransactionDynamicFee && t.MaxFeePerGas == nil && t.MaxPriorityFeePerGas == nil {
+		o.Set("gasPrice", a.NewString(fmt.Sprintf("0x%x", t.GasPrice)))
+	}
 	o.Set("gas", a.NewString(fmt.Sprintf("0x%x", t.Gas)))
 	if t.Value != nil {
 		o.Set("value", a.NewString(fmt.Sprintf("0x%x", t.Value)))
@@ -83,15 +89,40 @@ func (t *Transaction) MarshalJSON() ([]byte, error) {
 	o.Set("blockNumber", a.NewString(fmt.Sprintf("0x%x", t.BlockNumber)))
 	o.Set("nonce", a.NewString(fmt.Sprintf("0x%x", t.Nonce)))
 	o.Set("transactionIndex", a.NewString(fmt.Sprintf("0x%x", t.TransactionIndex)))
-	//if t.V != nil {
-	//	o.Set("v", a.NewString(fmt.Sprintf("0x%x", t.V)))
-	//}
-	//if t.R != nil {
-	//	o.Set("r", a.NewString(fmt.Sprintf("0x%x", t.R)))
-	//}
-	//if t.S != nil {
-	//	o.Set("s", a.NewString(fmt.Sprintf("0x%x", t.S)))
-	//}
+	if t.Type != TransactionLegacy {
+		o.Set("type", a.NewString(fmt.Sprintf("0x%x", t.Type)))
+
+		list := a.NewArray()
+		for indx, entry := range t.AccessList {
+			e := a.NewObject()
+			e.Set("address", a.NewString(entry.Address.String()))
+			keys := a.NewArray()
+			for i, key := range entry.Storage {
+				keys.SetArrayItem(i, a.NewString(key.String()))
+			}
+			e.Set("storageKeys", keys)
+			list.SetArrayItem(indx, e)
+		}
+		o.Set("accessList", list)
+	}
+	if t.ChainID != nil {
+		o.Set("chainId", a.NewString(fmt.Sprintf("0x%x", t.ChainID)))
+	}
+	if t.MaxPriorityFeePerGas != nil {
+		o.Set("maxPriorityFeePerGas", a.NewString(fmt.Sprintf("0x%x", t.MaxPriorityFeePerGas)))
+	}
+	if t.MaxFeePerGas != nil {
+		o.Set("maxFeePerGas", a.NewString(fmt.Sprintf("0x%x", t.MaxFeePerGas)))
+	}
+	if t.V != nil {
+		o.Set("v", a.NewString(fmt.Sprintf("0x%x", t.V)))
+	}
+	if t.R != nil {
+		o.Set("r", a.NewString(fmt.Sprintf("0x%x", t.R)))
+	}
+	if t.S != nil {
+		o.Set("s", a.NewString(fmt.Sprintf("0x%x", t.S)))
+	}
 
 	res := o.MarshalTo(nil)
 	defaultArena.Put(a)
@@ -108,6 +139,9 @@ func (c *CallMsg) MarshalJSON() ([]byte, error) {
 	if len(c.Data) != 0 {
 		o.Set("data", a.NewString("0x"+hex.EncodeToString(c.Data)))
 	}
+	if c.Gas != 0 {
+		o.Set("gas", a.NewString(fmt.Sprintf("0x%x", c.Gas)))
+	}
 	if c.GasPrice != 0 {
 		o.Set("gasPrice", a.NewString(fmt.Sprintf("0x%x", c.GasPrice)))
 	}
@@ -120,8 +154,31 @@ func (c *CallMsg) MarshalJSON() ([]byte, error) {
 	return res, nil
 }
 
+// MarshalJSON implements the Marshal interface. A block number is encoded as
+// a string and a block hash as an EIP-1898 object.
+func (b BlockNumberOrHash) MarshalJSON() ([]byte, error) {
+	if b.hash == nil {
+		return []byte(`"` + b.number.String() + `"`), nil
+	}
+	a := defaultArena.Get()
+
+	o := a.NewObject()
+	o.Set("blockHash", a.NewString(b.hash.String()))
+	if b.requireCanonical {
+		o.Set("requireCanonical", a.NewTrue())
+	}
+
+	res := o.MarshalTo(nil)
+	defaultArena.Put(a)
+	return res, nil
+}
+
 // MarshalJSON implements the Marshal interface.
 func (l *LogFilter) MarshalJSON() ([]byte, error) {
+	if l.BlockHash != nil && (l.From != nil || l.To != nil) {
+		// eip-234
+		return nil, fmt.Errorf("the block hash and the block range of the filter are exclusive")
+	}
 	a := defaultArena.Get()
 
 	o := a.NewObject()
@@ -132,20 +189,29 @@ func (l *LogFilter) MarshalJSON() ([]byte, error) {
 		for indx, addr := range l.Address {
 			v.SetArrayItem(indx, a.NewString(addr.String()))
 		}
+		o.Set("address", v)
 	}
 
 	v := a.NewArray()
-	for indx, topic := range l.Topics {
-		if topic == nil {
+	for indx, topics := range l.Topics {
+		switch len(topics) {
+		case 0:
+			// wildcard
 			v.SetArrayItem(indx, a.NewNull())
-		} else {
-			v.SetArrayItem(indx, a.NewString(topic.String()))
+		case 1:
+			v.SetArrayItem(indx, a.NewString(topics[0].String()))
+		default:
+			vv := a.NewArray()
+			for i, topic := range topics {
+				vv.SetArrayItem(i, a.NewString(topic.String()))
+			}
+			v.SetArrayItem(indx, vv)
 		}
 	}
 	o.Set("topics", v)
 
 	if l.BlockHash != nil {
-		o.Set("blockhash", a.NewString((*l.BlockHash).String()))
+		o.Set("blockHash", a.NewString((*l.BlockHash).String()))
 	}
 	if l.From != nil {
 		o.Set("fromBlock", a.NewString((*l.From).String()))
@@ -64,3 +64,137 @@ func TestMarshal(t *testing.T) {
 		assert.Equal(t, string(raw), cleanStr(c.Result))
 	}
 }
+
+func TestMarshalTransactionDynamicFee(t *testing.T) {
+	cases := []*Transaction{
+		{Type: TransactionDynamicFee, MaxFeePerGas: big.NewInt(10), MaxPriorityFeePerGas: big.NewInt(1)},
+		{Type: TransactionDynamicFee},
+		// the fees without the type
+		{MaxFeePerGas: big.NewInt(10)},
+	}
+	for _, txn := range cases {
+		raw, err := txn.MarshalJSON()
+		assert.NoError(t, err)
+
+		var obj map[string]interface{}
+		assert.NoError(t, json.Unmarshal(raw, &obj))
+
+		// the nodes reject the gas price with the eip-1559 fees
+		_, ok := obj["gasPrice"]
+		assert.False(t, ok, string(raw))
+	}
+}
+
+func TestMarshalLogFilter(t *testing.T) {
+	addr1 := "0x0000000000000000000000000000000000000001"
+	hash1 := "0x0000000000000000000000000000000000000000000000000000000000000001"
+	hash2 := "0x0000000000000000000000000000000000000000000000000000000000000002"
+
+	from := BlockNumber(1)
+	blockHash := HexToHash(hash1)
+
+	cases := []struct {
+		Filter *LogFilter
+		Result string
+	}{
+		{
+			&LogFilter{},
+			`{"topics": []}`,
+		},
+		{
+			&LogFilter{
+				Address: []Address{HexToAddress(addr0)},
+				Topics:  [][]Hash{{HexToHash(hash1)}},
+				From:    &from,
+			},
+			`{
+				"address": "` + addr0 + `",
+				"topics": ["` + hash1 + `"],
+				"fromBlock": "0x1"
+			}`,
+		},
+		{
+			// (hash1 OR hash2) AND any AND hash1 from addr0 OR addr1
+			&LogFilter{
+				Address:   []Address{HexToAddress(addr0), HexToAddress(addr1)},
+				Topics:    [][]Hash{{HexToHash(hash1), HexToHash(hash2)}, nil, {HexToHash(hash1)}},
+				BlockHash: &blockHash,
+			},
+			`{
+				"address": ["` + addr0 + `", "` + addr1 + `"],
+				"topics": [["` + hash1 + `", "` + hash2 + `"], null, "` + hash1 + `"],
+				"blockHash": "` + hash1 + `"
+			}`,
+		},
+	}
+
+	for _, c := range cases {
+		raw, err := c.Filter.MarshalJSON()
+		assert.NoError(t, err)
+		assert.Equal(t, cleanStr(c.Result), string(raw))
+	}
+
+	// the block hash and the range are exclusive
+	filter := &LogFilter{}
+	filter.SetBlockHash(blockHash)
+	filter.SetToUint64(10)
+	_, err := filter.MarshalJSON()
+	assert.Error(t, err)
+
+	filter.To = nil
+	raw, err := filter.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, cleanStr(`{"topics": [], "blockHash": "`+hash1+`"}`), string(raw))
+}
+
+func TestMarshalBlockNumberOrHash(t *testing.T) {
+	hash := "0x0000000000000000000000000000000000000000000000000000000000000001"
+
+	cases := []struct {
+		Block  BlockNumberOrHash
+		Result string
+	}{
+		{BlockAtNumber(Latest), `"latest"`},
+		{BlockAtNumber(BlockNumber(10)), `"0xa"`},
+		{BlockAtHash(HexToHash(hash), false), `{"blockHash": "` + hash + `"}`},
+		{BlockAtHash(HexToHash(hash), true), `{"blockHash": "` + hash + `", "requireCanonical": true}`},
+	}
+	for _, c := range cases {
+		raw, err := c.Block.MarshalJSON()
+		assert.NoError(t, err)
+		assert.Equal(t, cleanStr(c.Result), string(raw))
+	}
+
+	num, ok := BlockAtNumber(Latest).Number()
+	assert.True(t, ok)
+	assert.Equal(t, Latest, num)
+
+	_, ok = BlockAtHash(HexToHash(hash), false).Number()
+	assert.False(t, ok)
+}
+
+func TestMarshalCallMsg(t *testing.T) {
+	from := "0x0000000000000000000000000000000000000001"
+	to := "0x0000000000000000000000000000000000000002"
+
+	msg := &CallMsg{
+		From:     HexToAddress(from),
+		To:       HexToAddress(to),
+		Data:     []byte{0x1, 0x2},
+		Gas:      100,
+		GasPrice: 10,
+		Value:    big.NewInt(1),
+	}
+	raw, err := msg.MarshalJSON()
+	assert.NoError(t, err)
+
+	expected := `{
+		"from": "` + from + `",
+		"to": "` + to + `",
+		"data": "0x0102",
+		"gas": "0x64",
+		"gasPrice": "0xa",
+		"value": "0x1"
+	}`
+	assert.Equal(t, cleanStr(expected), string(raw))
+}
@@ -0,0 +1,45 @@
+package web3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsZero(t *testing.T) {
+	assert.True(t, Address{}.IsZero())
+	assert.False(t, Address{0x1}.IsZero())
+	assert.True(t, Hash{}.IsZero())
+	assert.False(t, HexToHash("0x0000000000000000000000000000000000000000000000000000000000000001").IsZero())
+}
+
+func TestParseBlockNumber(t *testing.T) {
+	cases := []struct {
+		str string
+		num BlockNumber
+	}{
+		{"latest", Latest},
+		{"Pending", Pending},
+		{"earliest", Earliest},
+		{"0x1b4", 436},
+		{"0X1B4", 436},
+		{"12345", 12345},
+		{"0", 0},
+	}
+	for _, c := range cases {
+		num, err := ParseBlockNumber(c.str)
+		assert.NoError(t, err)
+		assert.Equal(t, c.num, num)
+	}
+
+	// the parsed numbers are encoded again as the same block
+	num, err := ParseBlockNumber(BlockNumber(436).String())
+	assert.NoError(t, err)
+	assert.Equal(t, BlockNumber(436), num)
+
+	invalid := []string{"", "0x", "1b4", "-1", "+1", "0x-1", " 1", "safe", "0xffffffffffffffff"}
+	for _, str := range invalid {
+		_, err := ParseBlockNumber(str)
+		assert.Error(t, err, str)
+	}
+}
@@ -22,8 +22,13 @@ func (b *Block) UnmarshalJSON(buf []byte) error {
 		return err
 	}
 
-	if err := decodeHash(&b.Hash, v, "hash"); err != nil {
-		return err
+	// the hash, miner and number are null in the pending block
+	if fieldNotFull(v, "hash") {
+		if err := decodeHash(&b.Hash, v, "hash"); err != nil {
+			return err
+		}
+	} else {
+		b.Hash = Hash{}
 	}
 	if err := decodeHash(&b.ParentHash, v, "parentHash"); err != nil {
 		return err
@@ -40,11 +45,19 @@ func (b *Block) UnmarshalJSON(buf []byte) error {
 	if err := decodeHash(&b.ReceiptsRoot, v, "receiptsRoot"); err != nil {
 		return err
 	}
-	if err := decodeAddr(&b.Miner, v, "miner"); err != nil {
-		return err
+	if fieldNotFull(v, "miner") {
+		if err := decodeAddr(&b.Miner, v, "miner"); err != nil {
+			return err
+		}
+	} else {
+		b.Miner = Address{}
 	}
-	if b.Number, err = decodeUint(v, "number"); err != nil {
-		return err
+	if fieldNotFull(v, "number") {
+		if b.Number, err = decodeUint(v, "number"); err != nil {
+			return err
+		}
+	} else {
+		b.Number = 0
 	}
 	if b.GasLimit, err = decodeUint(v, "gasLimit"); err != nil {
 		return err
@@ -61,6 +74,14 @@ func (b *Block) UnmarshalJSON(buf []byte) error {
 	if b.ExtraData, err = decodeBytes(b.ExtraData[:0], v, "extraData"); err != nil {
 		return err
 	}
+	if fieldNotFull(v, "baseFeePerGas") {
+		// only available after london (eip-1559)
+		if b.BaseFeePerGas, err = decodeBigInt(b.BaseFeePerGas, v, "baseFeePerGas"); err != nil {
+			return err
+		}
+	} else {
+		b.BaseFeePerGas = nil
+	}
 
 	b.TransactionsHashes = b.TransactionsHashes[:0]
 	b.Transactions = b.Transactions[:0]
@@ -115,6 +136,15 @@ func (t *Transaction) UnmarshalJSON(buf []byte) error {
 
 func (t *Transaction) unmarshalJSON(v *fastjson.Value) error {
 	var err error
+	// the typed transaction fields and the signature are optional, reset
+	// them so that a reused transaction does not keep the previous values
+	t.Type = TransactionLegacy
+	t.ChainID = nil
+	t.MaxPriorityFeePerGas = nil
+	t.MaxFeePerGas = nil
+	t.AccessList = nil
+	t.V, t.R, t.S = nil, nil, nil
+
 	if err := decodeHash(&t.Hash, v, "hash"); err != nil {
 		return err
 	}
@@ -136,30 +166,110 @@ func (t *Transaction) unmarshalJSON(v *fastjson.Value) error {
 	if t.Value, err = decodeBigInt(t.Value, v, "value"); err != nil {
 		return err
 	}
-	if err = decodeHash(&t.BlockHash, v, "blockHash"); err != nil {
-		return err
+	// the block fields are null for pending transactions
+	if fieldNotFull(v, "blockHash") {
+		if err = decodeHash(&t.BlockHash, v, "blockHash"); err != nil {
+			return err
+		}
+	} else {
+		t.BlockHash = Hash{}
 	}
-	if t.BlockNumber, err = decodeUint(v, "blockNumber"); err != nil {
-		return err
+	if fieldNotFull(v, "blockNumber") {
+		if t.BlockNumber, err = decodeUint(v, "blockNumber"); err != nil {
+			return err
+		}
+	} else {
+		t.BlockNumber = 0
 	}
 	if t.Nonce, err = decodeUint(v, "nonce"); err != nil {
 		return err
 	}
-	if t.TransactionIndex, err = decodeUint(v, "transactionIndex"); err != nil {
-		return err
+	if fieldNotFull(v, "transactionIndex") {
+		if t.TransactionIndex, err = decodeUint(v, "transactionIndex"); err != nil {
+			return err
+		}
+	} else {
+		t.TransactionIndex = 0
+	}
+
+	// typed transaction fields
+	if fieldNotFull(v, "type") {
+		typ, err := decodeUint(v, "type")
+		if err != nil {
+			return err
+		}
+		t.Type = TransactionType(typ)
+	}
+	if fieldNotFull(v, "chainId") {
+		if t.ChainID, err = decodeBigInt(t.ChainID, v, "chainId"); err != nil {
+			return err
+		}
+	}
+	if fieldNotFull(v, "maxPriorityFeePerGas") {
+		if t.MaxPriorityFeePerGas, err = decodeBigInt(t.MaxPriorityFeePerGas, v, "maxPriorityFeePerGas"); err != nil {
+			return err
+		}
+	}
+	if fieldNotFull(v, "maxFeePerGas") {
+		if t.MaxFeePerGas, err = decodeBigInt(t.MaxFeePerGas, v, "maxFeePerGas"); err != nil {
+			return err
+		}
+	}
+	if fieldNotFull(v, "accessList") {
+		if t.AccessList, err = decodeAccessList(v, "accessList"); err != nil {
+			return err
+		}
+	}
+
+	// signature
+	if fieldNotFull(v, "v") {
+		if t.V, err = decodeBigInt(t.V, v, "v"); err != nil {
+			return err
+		}
+	}
+	if fieldNotFull(v, "r") {
+		if t.R, err = decodeBigInt(t.R, v, "r"); err != nil {
+			return err
+		}
+	}
+	if fieldNotFull(v, "s") {
+		if t.S, err = decodeBigInt(t.S, v, "s"); err != nil {
+			return err
+		}
 	}
-	//if t.V, err = decodeBigInt(t.V, v, "v"); err != nil {
-	//	return err
-	//}
-	//if t.R, err = decodeBigInt(t.R, v, "r"); err != nil {
-	//	return err
-	//}
-	//if t.S, err = decodeBigInt(t.S, v, "s"); err != nil {
-	//	return err
-	//}
 	return nil
 }
 
+func decodeAccessList(v *fastjson.Value, key string) (AccessList, error) {
+	elems, err := v.Get(key).Array()
+	if err != nil {
+		return nil, fmt.Errorf("field '%s' is not an array: %v", key, err)
+	}
+	list := AccessList{}
+	for _, elem := range elems {
+		entry := AccessEntry{}
+		if err := decodeAddr(&entry.Address, elem, "address"); err != nil {
+			return nil, err
+		}
+		keys, err := elem.Get("storageKeys").Array()
+		if err != nil {
+			return nil, fmt.Errorf("field 'storageKeys' is not an array: %v", err)
+		}
+		entry.Storage = make([]Hash, len(keys))
+		for i, key := range keys {
+			b, err := key.StringBytes()
+			if err != nil {
+				return nil, err
+			}
+			if err := entry.Storage[i].UnmarshalText(b); err != nil {
+				return nil, err
+			}
+		}
+		list = append(list, entry)
+	}
+	return list, nil
+}
+
 // UnmarshalJSON implements the unmarshal interface
 func (r *Receipt) UnmarshalJSON(buf []byte) error {
 	p := defaultPool.Get()
@@ -190,8 +300,21 @@ func (r *Receipt) UnmarshalJSON(buf []byte) error {
 	if r.BlockNumber, err = decodeUint(v, "blockNumber"); err != nil {
 		return err
 	}
-	if r.Status, err = decodeUint(v, "status"); err != nil {
-		return err
+	if fieldNotFull(v, "status") {
+		// only available after byzantium
+		if r.Status, err = decodeUint(v, "status"); err != nil {
+			return err
+		}
+		r.Root = nil
+	} else {
+		r.Status = 0
+		r.Root = nil
+		if fieldNotFull(v, "root") {
+			r.Root = new(Hash)
+			if err := decodeHash(r.Root, v, "root"); err != nil {
+				return err
+			}
+		}
 	}
 	if r.GasUsed, err = decodeUint(v, "gasUsed"); err != nil {
 		return err
@@ -199,10 +322,41 @@ func (r *Receipt) UnmarshalJSON(buf []byte) error {
 	if r.CumulativeGasUsed, err = decodeUint(v, "cumulativeGasUsed"); err != nil {
 		return err
 	}
+	if fieldNotFull(v, "effectiveGasPrice") {
+		// only available after london
+		if r.EffectiveGasPrice, err = decodeBigInt(r.EffectiveGasPrice, v, "effectiveGasPrice"); err != nil {
+			return err
+		}
+	} else {
+		r.EffectiveGasPrice = nil
+	}
+	if fieldNotFull(v, "type") {
+		typ, err := decodeUint(v, "type")
+		if err != nil {
+			return err
+		}
+		r.Type = TransactionType(typ)
+	} else {
+		r.Type = TransactionLegacy
+	}
 	if r.LogsBloom, err = decodeBytes(r.LogsBloom[:0], v, "logsBloom", 256); err != nil {
 		return err
 	}
 
+	// fields of the l2 chains
+	if r.L1Fee, err = decodeOptionalBigInt(r.L1Fee, v, "l1Fee"); err != nil {
+		return err
+	}
+	if r.L1GasPrice, err = decodeOptionalBigInt(r.L1GasPrice, v, "l1GasPrice"); err != nil {
+		return err
+	}
+	if r.L1GasUsed, err = decodeOptionalBigInt(r.L1GasUsed, v, "l1GasUsed"); err != nil {
+		return err
+	}
+	if r.GasUsedForL1, err = decodeOptionalBigInt(r.GasUsedForL1, v, "gasUsedForL1"); err != nil {
+		return err
+	}
+
 	// logs
 	r.Logs = r.Logs[:0]
 	for _, elem := range v.GetArray("logs") {
@@ -281,19 +435,45 @@ func fieldNotFull(v *fastjson.Value, key string) bool {
 	return true
 }
 
+// decodeOptionalBigInt decodes the big int if the field is set or returns nil
+func decodeOptionalBigInt(b *big.Int, v *fastjson.Value, key string) (*big.Int, error) {
+	if !fieldNotFull(v, key) {
+		return nil, nil
+	}
+	return decodeBigInt(b, v, key)
+}
+
 func decodeBigInt(b *big.Int, v *fastjson.Value, key string) (*big.Int, error) {
 	vv := v.Get(key)
 	if vv == nil {
 		return nil, fmt.Errorf("field '%s' not found", key)
 	}
+	if b == nil {
+		b = new(big.Int)
+	}
+
+	// some nodes return the quantities as json numbers
+	if vv.Type() == fastjson.TypeNumber {
+		str := vv.String()
+		if strings.Trim(str, "0123456789") != "" {
+			return nil, fmt.Errorf("field '%s' with content '%s' cannot be decoded as a number", key, str)
+		}
+		b.SetString(str, 10)
+		return b, nil
+	}
+
 	str := vv.String()
 	str = strings.Trim(str, "\"")
 
 	if !strings.HasPrefix(str, "0x") {
 		return nil, fmt.Errorf("field %s does not have 0x prefix", str)
 	}
-	if b == nil {
-		b = new(big.Int)
+	if str == "0x" {
+		// empty quantity
+		return b.SetUint64(0), nil
+	}
+	if strings.ContainsAny(str[2:], "+-_") {
+		return nil, fmt.Errorf("field '%s' with content '%s' is not a hex number", key, str)
 	}
 
 	var ok bool
@@ -332,12 +512,26 @@ func decodeUint(v *fastjson.Value, key string) (uint64, error) {
 	if vv == nil {
 		return 0, fmt.Errorf("field '%s' not found", key)
 	}
+
+	// some nodes return the quantities as json numbers
+	if vv.Type() == fastjson.TypeNumber {
+		num, err := strconv.ParseUint(vv.String(), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("field '%s' with content '%s' cannot be decoded as a number", key, vv.String())
+		}
+		return num, nil
+	}
+
 	str := vv.String()
 	str = strings.Trim(str, "\"")
 
 	if !strings.HasPrefix(str, "0x") {
 		return 0, fmt.Errorf("field %s does not have 0x prefix", str)
 	}
+	if str == "0x" {
+		// empty quantity
+		return 0, nil
+	}
 	return strconv.ParseUint(str[2:], 16, 64)
 }
 
@@ -2,7 +2,9 @@ package web3
 
 import (
 	"encoding/json"
+	"fmt"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -69,3 +71,168 @@ func TestUnmarshalBlock(t *testing.T) {
 		assert.Equal(t, b, c.Result)
 	}
 }
+
+func TestUnmarshalPendingBlock(t *testing.T) {
+	input := `{
+		"hash": null,
+		"parentHash": "` + hash2.String() + `",
+		"sha3Uncles": "` + hash3.String() + `",
+		"transactionsRoot": "` + hash1.String() + `",
+		"receiptsRoot": "` + hash2.String() + `",
+		"stateRoot": "` + hash3.String() + `",
+		"miner": null,
+		"nonce": null,
+		"number": "0x10",
+		"gasLimit": "0x2",
+		"gasUsed": "0x3",
+		"timestamp": "0x4",
+		"difficulty": "0x5",
+		"extraData": "0x01",
+		"transactions": [
+			{
+				"hash": "` + hash1.String() + `",
+				"from": "` + addr1.String() + `",
+				"to": "` + addr1.String() + `",
+				"input": "0x",
+				"gasPrice": "0x1",
+				"gas": "0x2",
+				"value": "0x3",
+				"nonce": "0x4",
+				"blockHash": null,
+				"blockNumber": null,
+				"transactionIndex": null
+			}
+		],
+		"uncles": []
+	}`
+
+	var b Block
+	assert.NoError(t, json.Unmarshal([]byte(input), &b))
+	assert.True(t, b.IsPending())
+	assert.Equal(t, b.Miner, Address{})
+	assert.Equal(t, b.Number, uint64(0x10))
+	assert.Len(t, b.Transactions, 1)
+
+	txn := b.Transactions[0]
+	assert.Equal(t, txn.BlockHash, Hash{})
+	assert.Equal(t, txn.BlockNumber, uint64(0))
+	assert.Equal(t, txn.Nonce, uint64(4))
+}
+
+func TestUnmarshalReceipt(t *testing.T) {
+	input := `{
+		"from": "` + addr1.String() + `",
+		"contractAddress": null,
+		"transactionHash": "` + hash1.String() + `",
+		"blockHash": "` + hash2.String() + `",
+		"transactionIndex": "0x1",
+		"blockNumber": "0x2",
+		"gasUsed": "0x5208",
+		"cumulativeGasUsed": "0x6000",
+		"logsBloom": "0x` + strings.Repeat("00", 256) + `",
+		"logs": [],
+		%s
+	}`
+
+	var r Receipt
+	assert.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(input, `"status": "0x1", "effectiveGasPrice": "0x3b9aca00", "type": "0x2"`)), &r))
+	assert.Equal(t, r.Status, uint64(1))
+	assert.Equal(t, r.EffectiveGasPrice, big.NewInt(1000000000))
+	assert.Equal(t, r.Type, TransactionDynamicFee)
+	assert.True(t, r.Successful())
+	assert.False(t, r.Failed())
+	assert.Nil(t, r.Root)
+
+	var r1 Receipt
+	assert.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(input, `"status": "0x0"`)), &r1))
+	assert.False(t, r1.Successful())
+	assert.True(t, r1.Failed())
+
+	// pre byzantium receipts have a root instead of the status
+	var r2 Receipt
+	assert.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(input, `"root": "`+hash3.String()+`"`)), &r2))
+	assert.Equal(t, r2.Status, uint64(0))
+	assert.Nil(t, r2.EffectiveGasPrice)
+	assert.Equal(t, r2.Type, TransactionLegacy)
+	assert.Equal(t, &hash3, r2.Root)
+	assert.False(t, r2.HasStatus())
+	assert.False(t, r2.Successful())
+	assert.False(t, r2.Failed())
+}
+
+func TestUnmarshalReceiptL2(t *testing.T) {
+	input := `{
+		"from": "` + addr1.String() + `",
+		"contractAddress": null,
+		"transactionHash": "` + hash1.String() + `",
+		"blockHash": "` + hash2.String() + `",
+		"transactionIndex": "0x1",
+		"blockNumber": "0x2",
+		"gasUsed": "0x5208",
+		"cumulativeGasUsed": "0x6000",
+		"logsBloom": "0x` + strings.Repeat("00", 256) + `",
+		"logs": [],
+		"status": "0x1"
+		%s
+	}`
+
+	// l1
+	var r Receipt
+	assert.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(input, "")), &r))
+	assert.Nil(t, r.L1Fee)
+	assert.Nil(t, r.L1GasPrice)
+	assert.Nil(t, r.L1GasUsed)
+	assert.Nil(t, r.GasUsedForL1)
+
+	// optimism
+	var r1 Receipt
+	assert.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(input, `,
+		"l1Fee": "0x2e2a8d5d8f",
+		"l1FeeScalar": "0.684",
+		"l1GasPrice": "0x5d21dba00",
+		"l1GasUsed": "0x640"`)), &r1))
+	assert.Equal(t, big.NewInt(0x2e2a8d5d8f), r1.L1Fee)
+	assert.Equal(t, big.NewInt(0x5d21dba00), r1.L1GasPrice)
+	assert.Equal(t, big.NewInt(0x640), r1.L1GasUsed)
+	assert.Nil(t, r1.GasUsedForL1)
+
+	// arbitrum
+	var r2 Receipt
+	assert.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(input, `,
+		"gasUsedForL1": "0x1a2b",
+		"l1BlockNumber": "0x100"`)), &r2))
+	assert.Equal(t, big.NewInt(0x1a2b), r2.GasUsedForL1)
+	assert.Nil(t, r2.L1Fee)
+
+	// the fields are cleared when the receipt is reused
+	assert.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(input, "")), &r1))
+	assert.Nil(t, r1.L1Fee)
+}
+
+func TestUnmarshalReceiptQuantities(t *testing.T) {
+	input := `{
+		"from": "` + addr1.String() + `",
+		"contractAddress": null,
+		"transactionHash": "` + hash1.String() + `",
+		"blockHash": "` + hash2.String() + `",
+		"transactionIndex": "0x01",
+		"blockNumber": 2,
+		"gasUsed": "0x",
+		"cumulativeGasUsed": "0x6000",
+		"logsBloom": "0x` + strings.Repeat("00", 256) + `",
+		"logs": [],
+		"status": "0x1",
+		"effectiveGasPrice": %s
+	}`
+
+	var r Receipt
+	assert.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(input, `1000000000`)), &r))
+	assert.Equal(t, uint64(1), r.TransactionIndex)
+	assert.Equal(t, uint64(2), r.BlockNumber)
+	assert.Equal(t, uint64(0), r.GasUsed)
+	assert.Equal(t, big.NewInt(1000000000), r.EffectiveGasPrice)
+
+	for _, price := range []string{`"0x-1"`, `1.5`, `-1`, `"1"`} {
+		assert.Error(t, json.Unmarshal([]byte(fmt.Sprintf(input, price)), &r), price)
+	}
+}
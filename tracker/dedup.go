@@ -0,0 +1,72 @@
+package tracker
+
+import (
+	"sync"
+
+	web3 "github.com/boolw/go-web3"
+)
+
+// logKey identifies a log in the chain
+type logKey struct {
+	blockHash web3.Hash
+	logIndex  uint64
+}
+
+// logWindow tracks the logs emitted by a filter in the last blocks so
+// that the logs queried twice (i.e. in the overlap between the backfill
+// and the live blocks) are only stored and emitted once.
+type logWindow struct {
+	lock sync.Mutex
+	size uint64
+	last uint64
+	logs map[logKey]uint64
+}
+
+func newLogWindow(size uint64) *logWindow {
+	return &logWindow{
+		size: size,
+		logs: map[logKey]uint64{},
+	}
+}
+
+// add returns the logs not seen before and tracks them
+func (w *logWindow) add(logs []*web3.Log) []*web3.Log {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	res := []*web3.Log{}
+	for _, log := range logs {
+		key := logKey{blockHash: log.BlockHash, logIndex: log.LogIndex}
+		if _, ok := w.logs[key]; ok {
+			continue
+		}
+		w.logs[key] = log.BlockNumber
+		if log.BlockNumber > w.last {
+			w.last = log.BlockNumber
+		}
+		res = append(res, log)
+	}
+	w.pruneLocked()
+	return res
+}
+
+// remove stops tracking the logs (i.e. removed by a reorg)
+func (w *logWindow) remove(logs []*web3.Log) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for _, log := range logs {
+		delete(w.logs, logKey{blockHash: log.BlockHash, logIndex: log.LogIndex})
+	}
+}
+
+func (w *logWindow) pruneLocked() {
+	if w.last < w.size {
+		return
+	}
+	for key, num := range w.logs {
+		if num <= w.last-w.size {
+			delete(w.logs, key)
+		}
+	}
+}
@@ -17,7 +17,9 @@ import (
 	"time"
 
 	web3 "github.com/boolw/go-web3"
+	"github.com/boolw/go-web3/abi"
 	"github.com/boolw/go-web3/etherscan"
+	"github.com/boolw/go-web3/jsonrpc"
 	"github.com/boolw/go-web3/jsonrpc/codec"
 	"github.com/boolw/go-web3/tracker/store"
 )
@@ -32,14 +34,29 @@ var (
 const (
 	defaultMaxBlockBacklog = 10
 	defaultBatchSize       = 100
+	defaultOverlapWindow   = 2
 )
 
 // FilterConfig is a tracker filter configuration
 type FilterConfig struct {
 	Address []web3.Address `json:"address"`
-	Topics  []*web3.Hash   `json:"topics"`
+	Topics  [][]web3.Hash  `json:"topics"`
 	hash    string
 	Async   bool
+
+	// Registry matches and decodes the logs of the filter. If Topics
+	// is empty, the filter only tracks the events in the registry.
+	Registry *abi.Registry `json:"-"`
+
+	// OnLog is called with each new log decoded by the registry
+	OnLog func(parsed map[string]interface{}, event *abi.Event) `json:"-"`
+}
+
+func (f *FilterConfig) getTopics() [][]web3.Hash {
+	if len(f.Topics) == 0 && f.Registry != nil {
+		return [][]web3.Hash{f.Registry.Topics()}
+	}
+	return f.Topics
 }
 
 // Hash returns a hash of the filter
@@ -51,10 +68,11 @@ func (f *FilterConfig) Hash() string {
 	for _, i := range f.Address {
 		h.Write([]byte(i.String()))
 	}
-	for _, i := range f.Topics {
-		if i == nil {
+	for _, topics := range f.getTopics() {
+		if len(topics) == 0 {
 			h.Write([]byte("empty"))
-		} else {
+		}
+		for _, i := range topics {
 			h.Write([]byte(i.String()))
 		}
 	}
@@ -67,8 +85,8 @@ func (f *FilterConfig) getFilterSearch() *web3.LogFilter {
 	if len(f.Address) != 0 {
 		filter.Address = f.Address
 	}
-	if len(f.Topics) != 0 {
-		filter.Topics = f.Topics
+	if topics := f.getTopics(); len(topics) != 0 {
+		filter.Topics = topics
 	}
 	return filter
 }
@@ -81,6 +99,7 @@ type Filter struct {
 	EventCh chan *Event
 	DoneCh  chan struct{}
 	entry   store.Entry
+	window  *logWindow
 	tracker *Tracker
 }
 
@@ -121,10 +140,83 @@ func (f *Filter) Sync(ctx context.Context) error {
 	return f.tracker.Sync(ctx, f)
 }
 
+// parsedLog is a log decoded by the registry of the filter
+type parsedLog struct {
+	match  bool
+	parsed map[string]interface{}
+	event  *abi.Event
+	err    error
+	doneCh chan struct{}
+}
+
+func (f *Filter) parseLog(log *web3.Log, res *parsedLog) {
+	if _, ok := f.config.Registry.Match(log); !ok {
+		return
+	}
+	res.match = true
+	res.parsed, res.event, res.err = f.config.Registry.ParseLog(log)
+}
+
+func (f *Filter) deliverLog(res *parsedLog) {
+	if !res.match {
+		return
+	}
+	if res.err != nil {
+		f.tracker.logger.Printf("[ERR]: Tracker failed to parse log: %v", res.err)
+		return
+	}
+	f.config.OnLog(res.parsed, res.event)
+}
+
+// handleLogs decodes the logs with the registry and calls OnLog in the
+// order of the logs. With more than one worker the logs are decoded
+// concurrently and each log is delivered once the previous ones are.
+func (f *Filter) handleLogs(logs []*web3.Log) {
+	if f.config.Registry == nil || f.config.OnLog == nil {
+		return
+	}
+	workers := f.tracker.config.Workers
+	if workers <= 1 || len(logs) <= 1 {
+		for _, log := range logs {
+			res := &parsedLog{}
+			f.parseLog(log, res)
+			f.deliverLog(res)
+		}
+		return
+	}
+	if workers > len(logs) {
+		workers = len(logs)
+	}
+
+	results := make([]*parsedLog, len(logs))
+	for indx := range results {
+		results[indx] = &parsedLog{doneCh: make(chan struct{})}
+	}
+	indexCh := make(chan int, len(logs))
+	for indx := range logs {
+		indexCh <- indx
+	}
+	close(indexCh)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for indx := range indexCh {
+				f.parseLog(logs[indx], results[indx])
+				close(results[indx].doneCh)
+			}
+		}()
+	}
+	for _, res := range results {
+		<-res.doneCh
+		f.deliverLog(res)
+	}
+}
+
 func (f *Filter) emitEvent(evnt *Event) {
 	if evnt == nil {
 		return
 	}
+	f.handleLogs(evnt.Added)
 	if f.config.Async {
 		select {
 		case f.EventCh <- evnt:
@@ -169,6 +261,21 @@ type Config struct {
 	MaxBlockBacklog    uint64
 	EtherscanFastTrack bool
 	EtherscanAPIKey    string
+
+	// Confirmations is the number of blocks on top of a block before
+	// its logs are emitted. The filters store the last confirmed block
+	// and resume from it.
+	Confirmations uint64
+
+	// OverlapWindow is the number of blocks at the end of the backfill
+	// that are queried again when the filter switches to the blocks of
+	// the backlog. The logs are deduplicated by block hash and log index.
+	OverlapWindow uint64
+
+	// Workers is the number of goroutines that decode the logs of the
+	// filters with a registry. The logs are still delivered to OnLog in
+	// order. Zero or one decodes the logs sequentially.
+	Workers int
 }
 
 // DefaultConfig returns the default tracker config
@@ -177,6 +284,7 @@ func DefaultConfig() *Config {
 		BatchSize:          defaultBatchSize,
 		MaxBlockBacklog:    defaultMaxBlockBacklog,
 		EtherscanFastTrack: false,
+		OverlapWindow:      defaultOverlapWindow,
 	}
 }
 
@@ -189,6 +297,13 @@ type Provider interface {
 	ChainID() (*big.Int, error)
 }
 
+// logsRangeProvider is implemented by the providers that split the log
+// queries by block range (i.e. jsonrpc.Eth). The tracker uses it for
+// the backfill if available.
+type logsRangeProvider interface {
+	GetLogsRange(filter *web3.LogFilter, from, to uint64, opts ...jsonrpc.LogsRangeOption) ([]*web3.Log, error)
+}
+
 // Tracker is a contract event tracker
 type Tracker struct {
 	logger      *log.Logger
@@ -215,6 +330,10 @@ func NewTracker(provider Provider, config *Config) *Tracker {
 	if config.MaxBlockBacklog == 0 {
 		config.MaxBlockBacklog = defaultMaxBlockBacklog
 	}
+	if config.Confirmations >= config.MaxBlockBacklog {
+		// the unconfirmed blocks have to be in the backlog
+		config.MaxBlockBacklog = config.Confirmations + 1
+	}
 	return &Tracker{
 		provider: provider,
 		config:   config,
@@ -235,6 +354,28 @@ func (t *Tracker) SetStore(store store.Store) {
 	t.store = store
 }
 
+// LastBlock returns the last block with enough confirmations or nil if
+// there is none yet. The checkpoint of each filter is Filter.GetLastBlock.
+func (t *Tracker) LastBlock() *web3.Block {
+	t.blocksLock.Lock()
+	defer t.blocksLock.Unlock()
+
+	confirmed := t.confirmedBlocksLocked()
+	if len(confirmed) == 0 {
+		return nil
+	}
+	return confirmed[len(confirmed)-1]
+}
+
+// confirmedBlocksLocked returns the blocks of the backlog with
+// enough confirmations
+func (t *Tracker) confirmedBlocksLocked() []*web3.Block {
+	if uint64(len(t.blocks)) <= t.config.Confirmations {
+		return nil
+	}
+	return t.blocks[:uint64(len(t.blocks))-t.config.Confirmations]
+}
+
 // NewFilter creates a new log filter
 func (t *Tracker) NewFilter(config *FilterConfig) (*Filter, error) {
 	if config == nil {
@@ -246,6 +387,10 @@ func (t *Tracker) NewFilter(config *FilterConfig) (*Filter, error) {
 	if err != nil {
 		return nil, err
 	}
+	window, err := t.loadLogWindow(entry)
+	if err != nil {
+		return nil, err
+	}
 
 	f := &Filter{
 		config:  config,
@@ -253,6 +398,7 @@ func (t *Tracker) NewFilter(config *FilterConfig) (*Filter, error) {
 		EventCh: make(chan *Event),
 		SyncCh:  make(chan uint64, 1),
 		entry:   entry,
+		window:  window,
 		synced:  0,
 		tracker: t,
 	}
@@ -280,6 +426,31 @@ func (t *Tracker) NewFilter(config *FilterConfig) (*Filter, error) {
 	return f, nil
 }
 
+// loadLogWindow tracks the last logs in the store, the logs stored before
+// the checkpoint of a previous run are not stored twice after a restart
+func (t *Tracker) loadLogWindow(entry store.Entry) (*logWindow, error) {
+	window := newLogWindow(t.config.MaxBlockBacklog + t.config.OverlapWindow)
+
+	index, err := entry.LastIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []*web3.Log
+	for ; index > 0; index-- {
+		log := new(web3.Log)
+		if err := entry.GetLog(index-1, log); err != nil {
+			return nil, err
+		}
+		if len(logs) != 0 && log.BlockNumber+window.size <= logs[0].BlockNumber {
+			break
+		}
+		logs = append(logs, log)
+	}
+	window.add(logs)
+	return window, nil
+}
+
 func (t *Tracker) findAncestor(block, pivot *web3.Block) (uint64, error) {
 	// block is part of a fork that is not the current head, find a common ancestor
 	// both block and pivot are at the same height
@@ -327,6 +498,16 @@ func tooMuchDataRequestedError(err error) bool {
 	return false
 }
 
+// getLogsRange queries the logs between the from and to blocks
+func (t *Tracker) getLogsRange(query *web3.LogFilter, from, to uint64) ([]*web3.Log, error) {
+	if provider, ok := t.provider.(logsRangeProvider); ok {
+		return provider.GetLogsRange(query, from, to, jsonrpc.WithBlockRange(to-from+1))
+	}
+	query.SetFromUint64(from)
+	query.SetToUint64(to)
+	return t.provider.GetLogs(query)
+}
+
 func (t *Tracker) syncBatch(ctx context.Context, filter *Filter, from, to uint64) error {
 	query := filter.config.getFilterSearch()
 
@@ -338,10 +519,7 @@ func (t *Tracker) syncBatch(ctx context.Context, filter *Filter, from, to uint64
 START:
 	dst := min(to, i+batchSize)
 
-	query.SetFromUint64(i)
-	query.SetToUint64(dst)
-
-	logs, err := t.provider.GetLogs(query)
+	logs, err := t.getLogsRange(query, i, dst)
 	if err != nil {
 		if tooMuchDataRequestedError(err) {
 			// multiplicative decrease
@@ -359,6 +537,7 @@ START:
 	}
 
 	// add logs to the store
+	logs = filter.window.add(logs)
 	if err := filter.entry.StoreLogs(logs); err != nil {
 		return err
 	}
@@ -547,11 +726,10 @@ func (t *Tracker) Sync(ctx context.Context, filter *Filter) error {
 	default:
 	}
 
-	atomic.StoreInt32(&filter.synced, 1)
 	return nil
 }
 
-func (t *Tracker) syncImpl(ctx context.Context, filter *Filter) error {
+func (t *Tracker) syncImpl(ctx context.Context, filter *Filter) (err error) {
 	if err := t.preSyncCheck(); err != nil {
 		return err
 	}
@@ -559,6 +737,11 @@ func (t *Tracker) syncImpl(ctx context.Context, filter *Filter) error {
 	lock := lock{lock: &t.blocksLock}
 	defer func() {
 		if lock.Locked {
+			if err == nil {
+				// the filter handles the new blocks from now on, it is set
+				// before the blocks are released so that none is skipped.
+				atomic.StoreInt32(&filter.synced, 1)
+			}
 			lock.Unlock()
 		}
 	}()
@@ -569,12 +752,13 @@ func (t *Tracker) syncImpl(ctx context.Context, filter *Filter) error {
 	// move the target block for the sync.
 
 	lock.Lock()
-	if len(t.blocks) == 0 {
+
+	// get the current target, the last confirmed block
+	confirmed := t.confirmedBlocksLocked()
+	if len(confirmed) == 0 {
 		return nil
 	}
-
-	// get the current target
-	target := t.blocks[len(t.blocks)-1]
+	target := confirmed[len(confirmed)-1]
 	if target == nil {
 		return nil
 	}
@@ -608,9 +792,14 @@ func (t *Tracker) syncImpl(ctx context.Context, filter *Filter) error {
 
 	var origin uint64
 	if last != nil {
-		if last.Number > targetNum {
+		if last.Number > t.blocks[len(t.blocks)-1].Number {
 			return fmt.Errorf("store is more advanced than the chain")
 		}
+		if last.Number > targetNum {
+			// the last block is not confirmed yet (i.e. the confirmations
+			// changed), the logs are added once the head moves forward.
+			return nil
+		}
 
 		pivot, err := t.provider.GetBlockByNumber(web3.BlockNumber(last.Number), false)
 		if err != nil {
@@ -634,6 +823,7 @@ func (t *Tracker) syncImpl(ctx context.Context, filter *Filter) error {
 			if err != nil {
 				return err
 			}
+			filter.window.remove(logs)
 			filter.emitLogs(EventDel, logs)
 
 			last, err = t.provider.GetBlockByNumber(web3.BlockNumber(ancestor), false)
@@ -643,39 +833,46 @@ func (t *Tracker) syncImpl(ctx context.Context, filter *Filter) error {
 		}
 	}
 
+	// number of confirmed blocks in the backlog
+	window := uint64(len(confirmed))
+
 	step := targetNum - origin + 1
-	if step > t.config.MaxBlockBacklog {
+	if step > window {
 		// we are far (more than maxBackLog) from the target block
 		// Do a bulk sync with the eth_getLogs endpoint and get closer
-		// to the target block.
+		// to the target block. The bulk sync goes OverlapWindow blocks
+		// into the backlog, the blocks of the backlog are queried again
+		// and the logs already found are skipped.
 
 		for {
 			if origin > targetNum {
 				return fmt.Errorf("from (%d) higher than to (%d)", origin, targetNum)
 			}
-			if targetNum-origin+1 <= t.config.MaxBlockBacklog {
+			if targetNum-origin+1 <= window {
 				break
 			}
 
 			// release the lock
 			lock.Unlock()
 
-			limit := targetNum - t.config.MaxBlockBacklog
+			limit := min(targetNum-window+t.config.OverlapWindow, targetNum)
 			if err := t.syncBatch(ctx, filter, origin, limit); err != nil {
 				return err
 			}
 
-			origin = limit + 1
+			origin = targetNum - window + 1
 
 			// lock again to reset the target block
 			lock.Lock()
-			targetNum = t.blocks[len(t.blocks)-1].Number
+			confirmed = t.confirmedBlocksLocked()
+			window = uint64(len(confirmed))
+			targetNum = confirmed[len(confirmed)-1].Number
 		}
 	}
 
 	// we are still holding the lock on the blocksLock so that we are sure
 	// that the targetNum has not changed
-	added := t.blocks[uint64(len(t.blocks))-1-(targetNum-origin):]
+	added := confirmed[uint64(len(confirmed))-1-(targetNum-origin):]
 
 	evnt, err := t.doFilter(filter, added, nil)
 	if err != nil {
@@ -684,9 +881,6 @@ func (t *Tracker) syncImpl(ctx context.Context, filter *Filter) error {
 	if evnt != nil {
 		filter.emitEvent(evnt)
 	}
-
-	// release the lock on the blocks
-	lock.Unlock()
 	return nil
 }
 
@@ -839,7 +1033,16 @@ func (t *Tracker) handleReconcile(block *web3.Block) error {
 
 	for _, filter := range t.filters {
 		if filter.IsSynced() {
-			evnt, err := t.doFilter(filter, blockEvnt.Added, blockEvnt.Removed)
+			added, removed := blockEvnt.Added, blockEvnt.Removed
+			if t.config.Confirmations != 0 {
+				if added, removed, err = t.confirmedEvent(filter, blockEvnt); err != nil {
+					return err
+				}
+				if len(added) == 0 && len(removed) == 0 {
+					continue
+				}
+			}
+			evnt, err := t.doFilter(filter, added, removed)
 			if err != nil {
 				return err
 			}
@@ -852,6 +1055,44 @@ func (t *Tracker) handleReconcile(block *web3.Block) error {
 	return nil
 }
 
+// confirmedEvent returns the blocks that the filter has to add and remove
+// after a block event when the logs are only emitted after some confirmations
+func (t *Tracker) confirmedEvent(filter *Filter, blockEvnt *BlockEvent) ([]*web3.Block, []*web3.Block, error) {
+	last, err := filter.GetLastBlock()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// a reorg may remove blocks already processed by the filter
+	removed := []*web3.Block{}
+	if last != nil {
+		for _, block := range blockEvnt.Removed {
+			if block.Number <= last.Number {
+				removed = append(removed, block)
+			}
+		}
+	}
+
+	t.blocksLock.Lock()
+	confirmed := t.confirmedBlocksLocked()
+	t.blocksLock.Unlock()
+
+	added := []*web3.Block{}
+	for _, block := range confirmed {
+		if last != nil {
+			if len(removed) != 0 {
+				if block.Number < removed[0].Number {
+					continue
+				}
+			} else if block.Number <= last.Number {
+				continue
+			}
+		}
+		added = append(added, block)
+	}
+	return added, removed, nil
+}
+
 func (t *Tracker) doFilter(filter *Filter, added []*web3.Block, removed []*web3.Block) (*Event, error) {
 	evnt := &Event{}
 	if len(removed) != 0 {
@@ -860,6 +1101,7 @@ func (t *Tracker) doFilter(filter *Filter, added []*web3.Block, removed []*web3.
 		if err != nil {
 			return nil, err
 		}
+		filter.window.remove(logs)
 		evnt.Removed = append(evnt.Removed, revertLogs(logs)...)
 	}
 
@@ -874,6 +1116,7 @@ func (t *Tracker) doFilter(filter *Filter, added []*web3.Block, removed []*web3.
 		}
 
 		// add logs to the store
+		logs = filter.window.add(logs)
 		if err := filter.entry.StoreLogs(logs); err != nil {
 			return nil, err
 		}
@@ -881,8 +1124,10 @@ func (t *Tracker) doFilter(filter *Filter, added []*web3.Block, removed []*web3.
 	}
 
 	// store the last block as the new index
-	if err := filter.storeLastBlock(added[len(added)-1]); err != nil {
-		return nil, err
+	if len(added) != 0 {
+		if err := filter.storeLastBlock(added[len(added)-1]); err != nil {
+			return nil, err
+		}
 	}
 	return evnt, nil
 }
@@ -155,7 +155,7 @@ func TestFilterIntegration(t *testing.T) {
 	typ, _ := abi.NewType("uint256")
 	topic, _ := abi.EncodeTopic(typ, 1)
 
-	logs = testFilter(t, client.Eth(), &FilterConfig{Topics: []*web3.Hash{nil, &topic}})
+	logs = testFilter(t, client.Eth(), &FilterConfig{Topics: [][]web3.Hash{nil, {topic}}})
 	if len(logs) != 20 {
 		t.Fatal("bad")
 	}
@@ -189,13 +189,13 @@ func TestFilterIntegrationEventHash(t *testing.T) {
 	}
 
 	eventTopicID := abi0.Events["A"].ID()
-	logs := testFilter(t, client.Eth(), &FilterConfig{Topics: []*web3.Hash{&eventTopicID}})
+	logs := testFilter(t, client.Eth(), &FilterConfig{Topics: [][]web3.Hash{{eventTopicID}}})
 	if len(logs) != 10 {
 		t.Fatal("bad")
 	}
 
 	eventTopicID[1] = 1
-	logs = testFilter(t, client.Eth(), &FilterConfig{Topics: []*web3.Hash{&eventTopicID}})
+	logs = testFilter(t, client.Eth(), &FilterConfig{Topics: [][]web3.Hash{{eventTopicID}}})
 	if len(logs) != 0 {
 		t.Fatal("bad")
 	}
@@ -813,8 +813,8 @@ func (m *mockBlock) Extra(data string) *mockBlock {
 }
 
 func (m *mockBlock) GetLogs() (logs []*web3.Log) {
-	for _, log := range m.logs {
-		logs = append(logs, &web3.Log{Data: mustDecodeHash(log.data), BlockNumber: uint64(m.num), BlockHash: m.Hash()})
+	for indx, log := range m.logs {
+		logs = append(logs, &web3.Log{Data: mustDecodeHash(log.data), BlockNumber: uint64(m.num), BlockHash: m.Hash(), LogIndex: uint64(indx)})
 	}
 	return
 }
@@ -1243,13 +1243,10 @@ func TestTooMuchDataRequested(t *testing.T) {
 		}
 	})
 
-	m := &mockClient{}
-	m.addScenario(l)
-
 	mm := &mockClientWithLimit{
-		limit:      3,
-		mockClient: *m,
+		limit: 3,
 	}
+	mm.addScenario(l)
 
 	config := DefaultConfig()
 	config.BatchSize = 11
@@ -1273,3 +1270,298 @@ func TestTooMuchDataRequested(t *testing.T) {
 		t.Fatal("not the same count")
 	}
 }
+
+func TestFilterRegistry(t *testing.T) {
+	transfer := abi.MustNewEvent("Transfer(address indexed from, address indexed to, uint256 value)")
+	approval := abi.MustNewEvent("Approval(address indexed owner, address indexed spender, uint256 value)")
+
+	registry := abi.NewRegistry()
+	registry.AddEvent(transfer)
+
+	addr := web3.Address{0x1}
+	value := abi.MustNewType("uint256")
+
+	l := mockList{}
+	l.create(0, 20, func(b *mockBlock) {
+		b.Log("0x1")
+	})
+
+	m := &mockClient{}
+	m.addScenario(l)
+
+	// half of the logs are transfers and the other half approvals
+	for i := uint64(0); i < 20; i++ {
+		logs := m.logs[m.blockNum[i]]
+		topic0 := transfer.ID()
+		if i%2 == 1 {
+			topic0 = approval.ID()
+		}
+		data, err := value.Encode(new(big.Int).SetUint64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		logs[0].Topics = []web3.Hash{topic0, {}, {}}
+		logs[0].Address = addr
+		logs[0].Data = data
+	}
+
+	config := &FilterConfig{
+		Async:    true,
+		Address:  []web3.Address{addr},
+		Registry: registry,
+	}
+
+	var parsed []map[string]interface{}
+	config.OnLog = func(p map[string]interface{}, event *abi.Event) {
+		if event != transfer {
+			t.Fatal("bad event")
+		}
+		parsed = append(parsed, p)
+	}
+
+	search := config.getFilterSearch()
+	if !reflect.DeepEqual(search.Topics, [][]web3.Hash{{transfer.ID()}}) {
+		t.Fatal("bad topics")
+	}
+
+	tt := NewTracker(m, testConfig())
+	tt.store = inmem.NewInmemStore()
+
+	if err := tt.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	filter, err := tt.NewFilter(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := filter.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parsed) != 10 {
+		t.Fatalf("expected 10 transfers but found %d", len(parsed))
+	}
+	for indx, p := range parsed {
+		if p["value"].(*big.Int).Uint64() != uint64(indx*2) {
+			t.Fatal("bad value")
+		}
+	}
+}
+
+func TestFilterRegistryWorkers(t *testing.T) {
+	transfer := abi.MustNewEvent("Transfer(address indexed from, address indexed to, uint256 value)")
+	approval := abi.MustNewEvent("Approval(address indexed owner, address indexed spender, uint256 value)")
+
+	registry := abi.NewRegistry()
+	registry.AddEvent(transfer)
+
+	value := abi.MustNewType("uint256")
+
+	logs := []*web3.Log{}
+	for i := uint64(0); i < 200; i++ {
+		data, err := value.Encode(new(big.Int).SetUint64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		topic0 := transfer.ID()
+		if i%3 == 1 {
+			// not in the registry
+			topic0 = approval.ID()
+		}
+		if i%3 == 2 {
+			// fails to decode
+			data = nil
+		}
+		logs = append(logs, &web3.Log{
+			Topics: []web3.Hash{topic0, {}, {}},
+			Data:   data,
+		})
+	}
+
+	for _, workers := range []int{0, 1, 4, 300} {
+		config := testConfig()
+		config.Workers = workers
+
+		var values []uint64
+		filter := &Filter{
+			config: &FilterConfig{
+				Registry: registry,
+				OnLog: func(p map[string]interface{}, event *abi.Event) {
+					values = append(values, p["value"].(*big.Int).Uint64())
+				},
+			},
+			tracker: NewTracker(&mockClient{}, config),
+		}
+		filter.handleLogs(logs)
+
+		if len(values) != 67 {
+			t.Fatalf("expected 67 logs but found %d", len(values))
+		}
+		for indx, val := range values {
+			if val != uint64(indx*3) {
+				t.Fatalf("bad order with %d workers: %v", workers, values)
+			}
+		}
+	}
+}
+
+func TestTrackerConfirmations(t *testing.T) {
+	store := inmem.NewInmemStore()
+
+	l := mockList{}
+	l.create(0, 30, func(b *mockBlock) {
+		b.Log("0x1")
+	})
+
+	m := &mockClient{}
+	m.addScenario(l)
+
+	newTracker := func() (*Tracker, *Filter) {
+		config := testConfig()
+		config.Confirmations = 3
+
+		tt := NewTracker(m, config)
+		tt.store = store
+
+		if err := tt.Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		f, err := tt.NewFilter(&FilterConfig{Async: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Sync(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return tt, f
+	}
+
+	checkLogs := func(f *Filter, num int) {
+		logs := f.entry.(*inmem.Entry).Logs()
+		if len(logs) != num {
+			t.Fatalf("expected %d logs but found %d", num, len(logs))
+		}
+		last, err := f.GetLastBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last.Number != uint64(num-1) {
+			t.Fatalf("expected checkpoint %d but found %d", num-1, last.Number)
+		}
+	}
+
+	// the head is 29, only the logs up to 26 are confirmed
+	tt, f := newTracker()
+	checkLogs(f, 27)
+	if tt.LastBlock().Number != 26 {
+		t.Fatal("bad last block")
+	}
+
+	// two new blocks confirm two more blocks
+	l.create(30, 32, func(b *mockBlock) {
+		b.Log("0x1")
+	})
+	m.addScenario(l)
+
+	for _, num := range []uint64{30, 31} {
+		block, _ := m.GetBlockByNumber(web3.BlockNumber(num), false)
+		if err := tt.handleReconcile(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkLogs(f, 29)
+	if tt.LastBlock().Number != 28 {
+		t.Fatal("bad last block")
+	}
+
+	// restart from the checkpoint with a longer chain
+	l.create(32, 35, func(b *mockBlock) {
+		b.Log("0x1")
+	})
+	m.addScenario(l)
+
+	// the head is 34
+	_, f = newTracker()
+	checkLogs(f, 32)
+}
+
+type mockClientWithRange struct {
+	mockClient
+	ranges [][2]uint64
+}
+
+func (m *mockClientWithRange) GetLogsRange(filter *web3.LogFilter, from, to uint64, opts ...jsonrpc.LogsRangeOption) ([]*web3.Log, error) {
+	m.ranges = append(m.ranges, [2]uint64{from, to})
+
+	query := &web3.LogFilter{
+		Address: filter.Address,
+		Topics:  filter.Topics,
+	}
+	query.SetFromUint64(from)
+	query.SetToUint64(to)
+	return m.mockClient.GetLogs(query)
+}
+
+func TestTrackerBackfillOverlap(t *testing.T) {
+	store := inmem.NewInmemStore()
+
+	l := mockList{}
+	l.create(0, 50, func(b *mockBlock) {
+		b.Log("0x1").Log("0x2")
+	})
+
+	m := &mockClientWithRange{}
+	m.addScenario(l)
+
+	newTracker := func() (*Tracker, *Filter) {
+		tt := NewTracker(m, testConfig())
+		tt.store = store
+
+		if err := tt.Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		f, err := tt.NewFilter(&FilterConfig{Async: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Sync(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return tt, f
+	}
+
+	tt, f := newTracker()
+
+	// the backlog starts at 40 and the backfill goes up to 41
+	expected := [][2]uint64{{0, 10}, {11, 21}, {22, 32}, {33, 41}}
+	if !reflect.DeepEqual(m.ranges, expected) {
+		t.Fatalf("bad ranges %v", m.ranges)
+	}
+	if !compareLogs(l.GetLogs(), f.entry.(*inmem.Entry).Logs()) {
+		t.Fatal("bad logs")
+	}
+
+	// a block handled by both the sync and the live blocks
+	// is only added once
+	evnt, err := tt.doFilter(f, []*web3.Block{tt.blocks[len(tt.blocks)-1]}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evnt.Added) != 0 {
+		t.Fatal("logs added twice")
+	}
+	if !compareLogs(l.GetLogs(), f.entry.(*inmem.Entry).Logs()) {
+		t.Fatal("bad logs")
+	}
+
+	// restart with logs stored after the checkpoint
+	block, _ := m.GetBlockByNumber(45, false)
+	if err := f.storeLastBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	_, f = newTracker()
+	if !compareLogs(l.GetLogs(), f.entry.(*inmem.Entry).Logs()) {
+		t.Fatal("bad logs after restart")
+	}
+}
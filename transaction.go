@@ -0,0 +1,181 @@
+package web3
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/boolw/go-web3/secp256k1"
+)
+
+// ComputeHash returns the hash of the signed transaction. Typed transactions
+// (eip-2718) are hashed with their envelope. It is named ComputeHash since
+// Hash is the field filled by the node.
+func (t *Transaction) ComputeHash() (Hash, error) {
+	if t.V == nil || t.R == nil || t.S == nil {
+		return Hash{}, fmt.Errorf("transaction is not signed")
+	}
+	buf, err := t.MarshalRLP()
+	if err != nil {
+		return Hash{}, err
+	}
+	return Keccak256(buf), nil
+}
+
+// MarshalRLP returns the rlp encoding of the signed transaction. Typed
+// transactions are prefixed with their type.
+func (t *Transaction) MarshalRLP() ([]byte, error) {
+	fields, err := t.rlpFields()
+	if err != nil {
+		return nil, err
+	}
+	fields = append(fields,
+		rlpEncodeBigInt(t.V),
+		rlpEncodeBigInt(t.R),
+		rlpEncodeBigInt(t.S),
+	)
+	return t.rlpEnvelope(fields), nil
+}
+
+// RecoverFrom recovers the sender of the transaction from its signature
+// without the from field returned by the node (i.e. for raw transactions).
+// The legacy transactions can be signed with or without replay protection
+// (eip-155).
+func (t *Transaction) RecoverFrom() (Address, error) {
+	if t.V == nil || t.R == nil || t.S == nil {
+		return Address{}, fmt.Errorf("transaction is not signed")
+	}
+	fields, err := t.rlpFields()
+	if err != nil {
+		return Address{}, err
+	}
+
+	var recid *big.Int
+	if t.Type == TransactionLegacy {
+		v := t.V.Uint64()
+		switch {
+		case !t.V.IsUint64():
+			return Address{}, fmt.Errorf("invalid v value %s", t.V)
+		case v == 27 || v == 28:
+			// not replay protected
+			recid = big.NewInt(int64(v - 27))
+		case v >= 35:
+			chainID := new(big.Int).Sub(t.V, big.NewInt(35))
+			recid = new(big.Int).And(chainID, big.NewInt(1))
+			chainID.Rsh(chainID, 1)
+			fields = append(fields, rlpEncodeBigInt(chainID), rlpEncodeUint(0), rlpEncodeUint(0))
+		default:
+			return Address{}, fmt.Errorf("invalid v value %s", t.V)
+		}
+	} else {
+		recid = t.V
+	}
+	if !recid.IsUint64() || recid.Uint64() > 1 {
+		return Address{}, fmt.Errorf("invalid recovery id %s", recid)
+	}
+
+	if t.R.BitLen() > 256 || t.S.BitLen() > 256 {
+		return Address{}, fmt.Errorf("invalid signature values")
+	}
+	if !secp256k1.IsLowS(t.S) {
+		// eip-2, the signature with s and n - s are both valid
+		return Address{}, fmt.Errorf("invalid signature, s is higher than n/2 (eip-2)")
+	}
+	sig := make([]byte, secp256k1.SignatureLength)
+	copy(sig[32-len(t.R.Bytes()):32], t.R.Bytes())
+	copy(sig[64-len(t.S.Bytes()):64], t.S.Bytes())
+	sig[64] = byte(recid.Uint64())
+
+	hash := Keccak256(t.rlpEnvelope(fields))
+	pub, err := secp256k1.RecoverPubkey(hash[:], sig)
+	if err != nil {
+		return Address{}, err
+	}
+	var addr Address
+	pubHash := Keccak256(secp256k1.MarshalPubkey(pub)[1:])
+	copy(addr[:], pubHash[12:])
+	return addr, nil
+}
+
+// rlpEnvelope encodes the fields as a list prefixed with the type of
+// the typed transactions
+func (t *Transaction) rlpEnvelope(fields [][]byte) []byte {
+	buf := rlpEncodeList(fields...)
+	if t.Type != TransactionLegacy {
+		buf = append([]byte{byte(t.Type)}, buf...)
+	}
+	return buf
+}
+
+// rlpFields returns the encoded fields of the transaction without the signature
+func (t *Transaction) rlpFields() ([][]byte, error) {
+	to, err := t.rlpTo()
+	if err != nil {
+		return nil, err
+	}
+
+	var fields [][]byte
+	switch t.Type {
+	case TransactionLegacy:
+		fields = [][]byte{
+			rlpEncodeUint(t.Nonce),
+			rlpEncodeUint(t.GasPrice),
+			rlpEncodeUint(t.Gas),
+			to,
+			rlpEncodeBigInt(t.Value),
+			rlpEncodeBytes(t.Input),
+		}
+
+	case TransactionAccessList:
+		fields = [][]byte{
+			rlpEncodeBigInt(t.ChainID),
+			rlpEncodeUint(t.Nonce),
+			rlpEncodeUint(t.GasPrice),
+			rlpEncodeUint(t.Gas),
+			to,
+			rlpEncodeBigInt(t.Value),
+			rlpEncodeBytes(t.Input),
+			t.AccessList.rlpEncode(),
+		}
+
+	case TransactionDynamicFee:
+		fields = [][]byte{
+			rlpEncodeBigInt(t.ChainID),
+			rlpEncodeUint(t.Nonce),
+			rlpEncodeBigInt(t.MaxPriorityFeePerGas),
+			rlpEncodeBigInt(t.MaxFeePerGas),
+			rlpEncodeUint(t.Gas),
+			to,
+			rlpEncodeBigInt(t.Value),
+			rlpEncodeBytes(t.Input),
+			t.AccessList.rlpEncode(),
+		}
+
+	default:
+		return nil, fmt.Errorf("transaction type %d not supported", t.Type)
+	}
+	return fields, nil
+}
+
+func (t *Transaction) rlpTo() ([]byte, error) {
+	if t.To == "" || t.To == "null" {
+		// contract creation
+		return rlpEncodeBytes(nil), nil
+	}
+	var addr Address
+	if err := addr.UnmarshalText([]byte(t.To)); err != nil {
+		return nil, fmt.Errorf("failed to decode to address: %v", err)
+	}
+	return rlpEncodeBytes(addr[:]), nil
+}
+
+func (a AccessList) rlpEncode() []byte {
+	entries := make([][]byte, len(a))
+	for i, entry := range a {
+		keys := make([][]byte, len(entry.Storage))
+		for j, key := range entry.Storage {
+			keys[j] = rlpEncodeBytes(key[:])
+		}
+		entries[i] = rlpEncodeList(rlpEncodeBytes(entry.Address[:]), rlpEncodeList(keys...))
+	}
+	return rlpEncodeList(entries...)
+}
@@ -0,0 +1,282 @@
+package web3
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/boolw/go-web3/secp256k1"
+	"github.com/stretchr/testify/assert"
+)
+
+func hexToBigInt(str string) *big.Int {
+	b, ok := new(big.Int).SetString(str, 16)
+	if !ok {
+		panic("bad big int")
+	}
+	return b
+}
+
+func TestTransactionHash(t *testing.T) {
+	ether, _ := new(big.Int).SetString("1000000000000000000", 10)
+
+	cases := []struct {
+		Txn  *Transaction
+		RLP  string
+		Hash string
+	}{
+		{
+			// eip-155 example
+			Txn: &Transaction{
+				Nonce:    9,
+				GasPrice: 20000000000,
+				Gas:      21000,
+				To:       "0x3535353535353535353535353535353535353535",
+				Value:    ether,
+				V:        big.NewInt(37),
+				R:        hexToBigInt("28ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276"),
+				S:        hexToBigInt("67cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"),
+			},
+			RLP:  "f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83",
+			Hash: "0x33469b22e9f636356c4160a87eb19df52b7412e8eac32a4a55ffe88ea8350788",
+		},
+		{
+			// eip-2930 with an access list
+			Txn: &Transaction{
+				Type:     TransactionAccessList,
+				ChainID:  big.NewInt(1),
+				GasPrice: 1,
+				Gas:      21000,
+				To:       "0x3535353535353535353535353535353535353535",
+				AccessList: AccessList{
+					{
+						Address: HexToAddress("0x3535353535353535353535353535353535353535"),
+						Storage: []Hash{HexToHash("0x0000000000000000000000000000000000000000000000000000000000000001")},
+					},
+				},
+				V: big.NewInt(1),
+				R: big.NewInt(1),
+				S: big.NewInt(1),
+			},
+			RLP: "01" + "f85a" + "018001825208" + "943535353535353535353535353535353535353535" + "8080" +
+				"f838" + "f7" + "943535353535353535353535353535353535353535" + "e1" + "a00000000000000000000000000000000000000000000000000000000000000001" +
+				"010101",
+			Hash: "0x29fb0ef678becbebc0edc6c89d5040e388e3a52783579bf1fea7465f9167c36f",
+		},
+		{
+			// eip-1559 without access list
+			Txn: &Transaction{
+				Type:                 TransactionDynamicFee,
+				ChainID:              big.NewInt(1),
+				MaxPriorityFeePerGas: big.NewInt(1),
+				MaxFeePerGas:         big.NewInt(2),
+				Gas:                  21000,
+				To:                   "0x3535353535353535353535353535353535353535",
+				V:                    big.NewInt(0),
+				R:                    big.NewInt(1),
+				S:                    big.NewInt(1),
+			},
+			RLP:  "02" + "e2" + "01800102825208" + "943535353535353535353535353535353535353535" + "8080" + "c0" + "800101",
+			Hash: "0x71f9285934f33793c493603c3f0c492960c76365dadd4846b554d599a03bdaca",
+		},
+		{
+			// eip-1559 contract creation
+			Txn: &Transaction{
+				Type:                 TransactionDynamicFee,
+				ChainID:              big.NewInt(1),
+				MaxPriorityFeePerGas: big.NewInt(1),
+				MaxFeePerGas:         big.NewInt(2),
+				Gas:                  21000,
+				Input:                []byte{0x60, 0x80},
+				V:                    big.NewInt(1),
+				R:                    big.NewInt(1),
+				S:                    big.NewInt(1),
+			},
+			RLP:  "02" + "d0" + "01800102825208" + "80" + "80" + "826080" + "c0" + "010101",
+			Hash: "0xeeef21f589d2acef194015f64fef752f70fcb4abc001f3e1837a3710027cf0c7",
+		},
+	}
+
+	for _, c := range cases {
+		buf, err := c.Txn.MarshalRLP()
+		assert.NoError(t, err)
+		assert.Equal(t, c.RLP, hex.EncodeToString(buf))
+
+		hash, err := c.Txn.ComputeHash()
+		assert.NoError(t, err)
+		assert.Equal(t, c.Hash, hash.String())
+	}
+
+	// the hash needs the signature
+	_, err := (&Transaction{}).ComputeHash()
+	assert.Error(t, err)
+}
+
+func TestTransactionTypedJSON(t *testing.T) {
+	txn := &Transaction{
+		Type:       TransactionAccessList,
+		ChainID:    big.NewInt(1),
+		GasPrice:   1,
+		Gas:        21000,
+		To:         "0x3535353535353535353535353535353535353535",
+		Value:      big.NewInt(0),
+		Input:      []byte{0x1},
+		AccessList: AccessList{{Address: Address{0x1}, Storage: []Hash{{0x1}}}},
+		V:          big.NewInt(1),
+		R:          big.NewInt(1),
+		S:          big.NewInt(1),
+	}
+	raw, err := txn.MarshalJSON()
+	assert.NoError(t, err)
+
+	txn2 := &Transaction{}
+	assert.NoError(t, txn2.UnmarshalJSON(raw))
+	assert.Equal(t, txn.Type, txn2.Type)
+	assert.Equal(t, txn.AccessList, txn2.AccessList)
+
+	hash, err := txn2.ComputeHash()
+	assert.NoError(t, err)
+	expected, _ := txn.ComputeHash()
+	assert.Equal(t, expected, hash)
+}
+
+func TestTransactionUnmarshalReused(t *testing.T) {
+	// an eip-1559 transaction as returned by the node
+	dynamic := []byte(`{
+		"hash": "` + Hash{0x1}.String() + `",
+		"from": "` + Address{0x1}.String() + `",
+		"to": "0x3535353535353535353535353535353535353535",
+		"gasPrice": "0x2",
+		"gas": "0x5208",
+		"input": "0x",
+		"value": "0x0",
+		"nonce": "0x1",
+		"type": "0x2",
+		"chainId": "0x1",
+		"maxPriorityFeePerGas": "0x1",
+		"maxFeePerGas": "0x2",
+		"accessList": [{"address": "` + Address{0x1}.String() + `", "storageKeys": []}],
+		"v": "0x1",
+		"r": "0x1",
+		"s": "0x1"
+	}`)
+
+	txn := &Transaction{}
+	assert.NoError(t, txn.UnmarshalJSON(dynamic))
+	assert.Equal(t, TransactionDynamicFee, txn.Type)
+
+	// a legacy transaction without the typed fields nor the signature
+	legacy := []byte(`{
+		"hash": "` + Hash{0x1}.String() + `",
+		"from": "` + Address{0x1}.String() + `",
+		"to": "0x3535353535353535353535353535353535353535",
+		"gasPrice": "0x1",
+		"gas": "0x5208",
+		"input": "0x",
+		"value": "0x0",
+		"nonce": "0x1"
+	}`)
+	assert.NoError(t, txn.UnmarshalJSON(legacy))
+	assert.Equal(t, TransactionLegacy, txn.Type)
+	assert.Nil(t, txn.ChainID)
+	assert.Nil(t, txn.MaxPriorityFeePerGas)
+	assert.Nil(t, txn.MaxFeePerGas)
+	assert.Nil(t, txn.AccessList)
+	assert.Nil(t, txn.V)
+	assert.Nil(t, txn.R)
+	assert.Nil(t, txn.S)
+	assert.Equal(t, uint64(1), txn.GasPrice)
+}
+
+func TestTransactionRecoverFrom(t *testing.T) {
+	ether, _ := new(big.Int).SetString("1000000000000000000", 10)
+	key, _ := hex.DecodeString("4646464646464646464646464646464646464646464646464646464646464646")
+	from := HexToAddress("0x9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f")
+
+	// eip-155 example
+	txn := &Transaction{
+		Nonce:    9,
+		GasPrice: 20000000000,
+		Gas:      21000,
+		To:       "0x3535353535353535353535353535353535353535",
+		Value:    ether,
+		V:        big.NewInt(37),
+		R:        hexToBigInt("28ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276"),
+		S:        hexToBigInt("67cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"),
+	}
+	addr, err := txn.RecoverFrom()
+	assert.NoError(t, err)
+	assert.Equal(t, from, addr)
+
+	// the high-s form of the same signature is rejected (eip-2)
+	highS := *txn
+	highS.S = new(big.Int).Sub(secp256k1.S256().Params().N, txn.S)
+	highS.V = big.NewInt(38)
+	_, err = highS.RecoverFrom()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "eip-2")
+
+	// sign sets the signature with the recovery id that recovers the key
+	sign := func(txn *Transaction, recid func(uint64) int64) {
+		priv, err := secp256k1.ToECDSA(key)
+		assert.NoError(t, err)
+
+		fields, err := txn.rlpFields()
+		assert.NoError(t, err)
+		hash := Keccak256(txn.rlpEnvelope(fields))
+
+		r, s, err := ecdsa.Sign(rand.Reader, priv, hash[:])
+		assert.NoError(t, err)
+		if !secp256k1.IsLowS(s) {
+			s.Sub(secp256k1.S256().Params().N, s)
+		}
+		txn.R, txn.S = r, s
+
+		for id := uint64(0); id < 2; id++ {
+			txn.V = big.NewInt(recid(id))
+			if addr, err := txn.RecoverFrom(); err == nil && addr == from {
+				return
+			}
+		}
+		t.Fatal("no recovery id recovers the key")
+	}
+
+	// legacy without replay protection
+	txn = &Transaction{
+		Nonce:    1,
+		GasPrice: 1,
+		Gas:      21000,
+		To:       "0x3535353535353535353535353535353535353535",
+		Value:    ether,
+	}
+	sign(txn, func(id uint64) int64 { return int64(27 + id) })
+
+	// eip-1559
+	txn = &Transaction{
+		Type:                 TransactionDynamicFee,
+		ChainID:              big.NewInt(1),
+		Nonce:                1,
+		MaxPriorityFeePerGas: big.NewInt(1),
+		MaxFeePerGas:         big.NewInt(2),
+		Gas:                  21000,
+		To:                   "0x3535353535353535353535353535353535353535",
+		Value:                ether,
+		Input:                []byte{0x1, 0x2},
+	}
+	sign(txn, func(id uint64) int64 { return int64(id) })
+
+	// the sender changes with the signed fields
+	txn.Nonce = 2
+	addr, err = txn.RecoverFrom()
+	assert.NoError(t, err)
+	assert.NotEqual(t, from, addr)
+
+	txn.V = big.NewInt(2)
+	_, err = txn.RecoverFrom()
+	assert.Error(t, err)
+
+	_, err = (&Transaction{}).RecoverFrom()
+	assert.Error(t, err)
+}